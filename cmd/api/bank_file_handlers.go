@@ -0,0 +1,87 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/abhi14nexu/mercor-scd/internal/bankfile"
+	"github.com/abhi14nexu/mercor-scd/internal/money"
+	"github.com/abhi14nexu/mercor-scd/internal/scd"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// exportRequest is the body accepted when exporting a bank payment file
+type exportRequest struct {
+	FileID        string              `json:"file_id"` // defaults to bankfile.ExportFileID
+	Format        string              `json:"format" binding:"required"`
+	Currency      money.Currency      `json:"currency"`
+	PaymentIDs    []string            `json:"payment_ids"` // all exportable items in the currency when empty
+	Originator    bankfile.Originator `json:"originator" binding:"required"`
+	ExecutionDate *time.Time          `json:"execution_date"`
+}
+
+// getBankAccounts returns all latest bank account versions, optionally filtered by contractor
+func getBankAccounts(repos *repositories) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		listLatest(c, repos.bankAccounts, scd.ListOptions{Filters: queryFilters(c, map[string]string{"contractor": "contractor_id"})})
+	}
+}
+
+// exportPayments writes not-paid line items to a pain.001 or NACHA file and returns the file
+// The file ID, item count and total are sent in X-Export-* headers.
+func exportPayments(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req exportRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		format, err := bankfile.ParseFormat(req.Format)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		export, err := bankfile.ExportPayments(db, bankfile.ExportRequest{
+			FileID:        req.FileID,
+			Format:        format,
+			Currency:      req.Currency,
+			PaymentIDs:    req.PaymentIDs,
+			Originator:    req.Originator,
+			ExecutionDate: derefTime(req.ExecutionDate),
+		})
+		if err != nil {
+			var invalid *bankfile.ValidationError
+			switch {
+			case errors.As(err, &invalid):
+				c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "invalid payment file", "problems": invalid.Problems})
+			case errors.Is(err, gorm.ErrRecordNotFound):
+				c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			case errors.Is(err, bankfile.ErrAlreadyExported), errors.Is(err, scd.ErrAlreadyExists):
+				c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			case errors.Is(err, bankfile.ErrNotExportable), errors.Is(err, bankfile.ErrNoBankAccount), errors.Is(err, bankfile.ErrNothingToExport):
+				c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+			default:
+				writeError(c, err)
+			}
+			return
+		}
+
+		c.Header("Content-Disposition", `attachment; filename="`+export.FileID+format.Extension()+`"`)
+		c.Header("X-Export-File-ID", export.FileID)
+		c.Header("X-Export-Count", strconv.Itoa(len(export.Payments)))
+		c.Header("X-Export-Total", export.Total.String())
+		c.Data(http.StatusCreated, format.ContentType(), export.Content)
+	}
+}
+
+// derefTime returns the time t points to, or the zero time
+func derefTime(t *time.Time) time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+	return *t
+}
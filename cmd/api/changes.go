@@ -0,0 +1,297 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/abhi14nexu/mercor-scd/internal/models"
+	"github.com/abhi14nexu/mercor-scd/internal/scd"
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	// changePollInterval controls how often the change feed looks for new versions
+	changePollInterval = 2 * time.Second
+
+	// changeBatchSize caps the number of events emitted per poll
+	changeBatchSize = 500
+)
+
+// Entity types exposed by the change feed, matching the API resource names
+const (
+	entityJobs     = "jobs"
+	entityTimelogs = "timelogs"
+	entityPayments = "payments"
+)
+
+var allEntityTypes = []string{entityJobs, entityTimelogs, entityPayments}
+
+// changeEvent describes a single new SCD version picked up by the change feed
+type changeEvent struct {
+	EntityType string      `json:"entity_type"`
+	BusinessID string      `json:"id"`
+	UID        uuid.UUID   `json:"uid"`
+	Version    int         `json:"version"`
+	ValidFrom  time.Time   `json:"valid_from"`
+	Data       interface{} `json:"data"`
+}
+
+// EventID returns the resumable cursor for this event, "<valid_from>/<uid>"
+func (e changeEvent) EventID() string {
+	return e.cursor().String()
+}
+
+// cursor returns the position of this event in the change feed
+func (e changeEvent) cursor() changeCursor {
+	return changeCursor{ValidFrom: e.ValidFrom, UID: e.UID}
+}
+
+// changeCursor is a position in the change feed, ordered by valid_from and then uid
+// Versions written in one unit of work share valid_from, so the uid breaks ties and
+// a batch that ends inside such a group resumes with the rest of it.
+type changeCursor struct {
+	ValidFrom time.Time
+	UID       uuid.UUID
+}
+
+// String formats the cursor as an event ID
+func (c changeCursor) String() string {
+	return c.ValidFrom.UTC().Format(time.RFC3339Nano) + "/" + c.UID.String()
+}
+
+// before reports whether the event at (validFrom, uid) comes before the other one in feed order
+func before(validFrom time.Time, uid uuid.UUID, otherFrom time.Time, otherUID uuid.UUID) bool {
+	if !validFrom.Equal(otherFrom) {
+		return validFrom.Before(otherFrom)
+	}
+	return uid.String() < otherUID.String()
+}
+
+// EventName returns the SSE event name, e.g. "job.version"
+func (e changeEvent) EventName() string {
+	return strings.TrimSuffix(e.EntityType, "s") + ".version"
+}
+
+// changeFilter restricts which versions the change feed returns
+type changeFilter struct {
+	EntityTypes []string
+	Company     string
+	Contractor  string
+}
+
+// includes reports whether the filter selects the given entity type
+func (f changeFilter) includes(entityType string) bool {
+	for _, t := range f.EntityTypes {
+		if t == entityType {
+			return true
+		}
+	}
+	return false
+}
+
+// parseChangeFilter reads the types, company and contractor query parameters
+func parseChangeFilter(c *gin.Context) (changeFilter, error) {
+	filter := changeFilter{
+		EntityTypes: allEntityTypes,
+		Company:     c.Query("company"),
+		Contractor:  c.Query("contractor"),
+	}
+
+	if types := c.Query("types"); types != "" {
+		filter.EntityTypes = nil
+		for _, t := range strings.Split(types, ",") {
+			t = strings.TrimSpace(t)
+			switch t {
+			case entityJobs, entityTimelogs, entityPayments:
+				filter.EntityTypes = append(filter.EntityTypes, t)
+			default:
+				return changeFilter{}, fmt.Errorf("unknown entity type %q (expected one of %s)", t, strings.Join(allEntityTypes, ", "))
+			}
+		}
+	}
+
+	return filter, nil
+}
+
+// parseEventID parses a cursor produced by changeEvent.EventID
+// A bare timestamp, the format of earlier event IDs, resumes after every version at that time.
+func parseEventID(id string) (changeCursor, error) {
+	timestamp, uidPart, compound := strings.Cut(id, "/")
+	validFrom, err := time.Parse(time.RFC3339Nano, timestamp)
+	if err != nil {
+		return changeCursor{}, err
+	}
+	if !compound {
+		return changeCursor{ValidFrom: validFrom, UID: uuid.Max}, nil
+	}
+	uid, err := uuid.Parse(uidPart)
+	if err != nil {
+		return changeCursor{}, err
+	}
+	return changeCursor{ValidFrom: validFrom, UID: uid}, nil
+}
+
+// jobFilterScope filters rows joined to the jobs table by company and contractor
+// For jobs themselves the columns are local; timelogs and payments join their pinned job version
+func jobFilterScope(table string, filter changeFilter) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if filter.Company == "" && filter.Contractor == "" {
+			return db
+		}
+
+		prefix := ""
+		if jobsTable := (models.Job{}).TableName(); table != jobsTable {
+			db = db.Joins("JOIN jobs ON " + table + ".job_uid = jobs.uid")
+			prefix = "jobs."
+		}
+		if filter.Company != "" {
+			db = db.Where(prefix+"company_id = ?", filter.Company)
+		}
+		if filter.Contractor != "" {
+			db = db.Where(prefix+"contractor_id = ?", filter.Contractor)
+		}
+		return db
+	}
+}
+
+// loadChanges returns up to limit versions after the cursor, in (valid_from, uid) order
+func loadChanges(db *gorm.DB, filter changeFilter, after changeCursor, limit int) ([]changeEvent, error) {
+	var events []changeEvent
+
+	if filter.includes(entityJobs) {
+		var jobs []models.Job
+		if err := changesQuery(db, models.Job{}.TableName(), filter, after, limit).Find(&jobs).Error; err != nil {
+			return nil, fmt.Errorf("failed to load job changes: %w", err)
+		}
+		for i := range jobs {
+			events = append(events, newChangeEvent(entityJobs, &jobs[i].Model, jobs[i]))
+		}
+	}
+
+	if filter.includes(entityTimelogs) {
+		var timelogs []models.Timelog
+		if err := changesQuery(db, models.Timelog{}.TableName(), filter, after, limit).Find(&timelogs).Error; err != nil {
+			return nil, fmt.Errorf("failed to load timelog changes: %w", err)
+		}
+		for i := range timelogs {
+			events = append(events, newChangeEvent(entityTimelogs, &timelogs[i].Model, timelogs[i]))
+		}
+	}
+
+	if filter.includes(entityPayments) {
+		var payments []models.PaymentLineItem
+		if err := changesQuery(db, models.PaymentLineItem{}.TableName(), filter, after, limit).Find(&payments).Error; err != nil {
+			return nil, fmt.Errorf("failed to load payment changes: %w", err)
+		}
+		for i := range payments {
+			events = append(events, newChangeEvent(entityPayments, &payments[i].Model, payments[i]))
+		}
+	}
+
+	// Merge the per-table batches into a single stream in cursor order
+	sort.SliceStable(events, func(i, j int) bool {
+		return before(events[i].ValidFrom, events[i].UID, events[j].ValidFrom, events[j].UID)
+	})
+	if len(events) > limit {
+		events = events[:limit]
+	}
+
+	return events, nil
+}
+
+// changesQuery builds the per-table query for versions after the cursor
+func changesQuery(db *gorm.DB, table string, filter changeFilter, after changeCursor, limit int) *gorm.DB {
+	return db.Table(table).
+		Select(table+".*").
+		Where("("+table+".valid_from > ? OR ("+table+".valid_from = ? AND "+table+".uid > ?))", after.ValidFrom, after.ValidFrom, after.UID).
+		Scopes(jobFilterScope(table, filter)).
+		Order(table + ".valid_from ASC, " + table + ".uid ASC").
+		Limit(limit)
+}
+
+// newChangeEvent builds a change event from a loaded version
+func newChangeEvent(entityType string, m *scd.Model, data interface{}) changeEvent {
+	return changeEvent{
+		EntityType: entityType,
+		BusinessID: m.GetBusinessID(),
+		UID:        m.GetUID(),
+		Version:    m.GetVersion(),
+		ValidFrom:  m.ValidFrom,
+		Data:       data,
+	}
+}
+
+// streamChanges pushes new job, timelog and payment versions as Server-Sent Events
+// Clients resume from the Last-Event-ID header (or last_event_id query parameter);
+// without one the stream starts from the current time
+func streamChanges(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		filter, err := parseChangeFilter(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		cursor := changeCursor{ValidFrom: time.Now(), UID: uuid.Max}
+		lastEventID := c.GetHeader("Last-Event-ID")
+		if lastEventID == "" {
+			lastEventID = c.Query("last_event_id")
+		}
+		if lastEventID != "" {
+			if cursor, err = parseEventID(lastEventID); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Last-Event-ID: " + err.Error()})
+				return
+			}
+		}
+
+		c.Header("Content-Type", "text/event-stream")
+		c.Header("Cache-Control", "no-cache")
+		c.Header("Connection", "keep-alive")
+		c.Header("X-Accel-Buffering", "no")
+
+		ticker := time.NewTicker(changePollInterval)
+		defer ticker.Stop()
+
+		// Emit anything already pending when resuming, then poll
+		poll := func() bool {
+			events, err := loadChanges(db, filter, cursor, changeBatchSize)
+			if err != nil {
+				c.SSEvent("error", gin.H{"error": err.Error()})
+				return false
+			}
+			for _, event := range events {
+				c.Render(-1, sse.Event{
+					Id:    event.EventID(),
+					Event: event.EventName(),
+					Data:  event,
+				})
+				cursor = event.cursor()
+			}
+			if len(events) == 0 {
+				// Comment line keeps proxies from closing an idle connection
+				fmt.Fprint(c.Writer, ": keepalive\n\n")
+			}
+			return true
+		}
+
+		if !poll() {
+			return
+		}
+		c.Writer.Flush()
+		c.Stream(func(w io.Writer) bool {
+			select {
+			case <-c.Request.Context().Done():
+				return false
+			case <-ticker.C:
+				return poll()
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/abhi14nexu/mercor-scd/internal/models"
+	"github.com/abhi14nexu/mercor-scd/internal/money"
+	"github.com/abhi14nexu/mercor-scd/internal/scd"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// createJobsTogether creates jobs in one unit of work, so every first version shares valid_from
+func createJobsTogether(t *testing.T, db *gorm.DB, ids ...string) {
+	err := scd.RunUnitOfWork(db, func(u *scd.UnitOfWork) error {
+		for _, id := range ids {
+			if _, err := scd.CreateIn(u, models.NewJob(id, "Engineer", "company-acme", "contractor-alice", money.FromMajor(50, money.USD))); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+// readStream collects the IDs and business IDs of the first n events of a change stream
+func readStream(t *testing.T, url, lastEventID string, n int) (eventIDs, businessIDs []string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	require.NoError(t, err)
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	scanner := bufio.NewScanner(resp.Body)
+	for len(businessIDs) < n && scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "id:"):
+			eventIDs = append(eventIDs, strings.TrimSpace(strings.TrimPrefix(line, "id:")))
+		case strings.HasPrefix(line, "data:"):
+			_, rest, _ := strings.Cut(line, `"id":"`)
+			id, _, _ := strings.Cut(rest, `"`)
+			businessIDs = append(businessIDs, id)
+		}
+	}
+	return eventIDs, businessIDs
+}
+
+// TestLoadChangesSplitsTimestampGroup tests that batches ending inside a group of versions with one valid_from lose nothing
+func TestLoadChangesSplitsTimestampGroup(t *testing.T) {
+	db := setupTestDB(t)
+	ids := []string{"job-1", "job-2", "job-3", "job-4", "job-5"}
+	createJobsTogether(t, db, ids...)
+
+	var seen []string
+	cursor := changeCursor{ValidFrom: time.Now().Add(-time.Minute)}
+	for batches := 0; batches < 10; batches++ {
+		events, err := loadChanges(db, changeFilter{EntityTypes: allEntityTypes}, cursor, 2)
+		require.NoError(t, err)
+		if len(events) == 0 {
+			break
+		}
+		for _, event := range events {
+			assert.True(t, event.ValidFrom.Equal(events[0].ValidFrom))
+			seen = append(seen, event.BusinessID)
+			cursor = event.cursor()
+		}
+	}
+	assert.ElementsMatch(t, ids, seen, "Every version is returned exactly once")
+}
+
+// TestStreamChangesResumesFromLastEventID tests that a reconnecting client receives only the events after its last one
+func TestStreamChangesResumesFromLastEventID(t *testing.T) {
+	db := setupTestDB(t)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/changes/stream", streamChanges(db))
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	createJobsTogether(t, db, "job-1", "job-2", "job-3")
+	start := time.Now().Add(-time.Minute).UTC().Format(time.RFC3339Nano)
+
+	eventIDs, jobs := readStream(t, server.URL+"/changes/stream?types=jobs", start, 3)
+	require.Len(t, jobs, 3)
+	assert.ElementsMatch(t, []string{"job-1", "job-2", "job-3"}, jobs)
+	cursor, err := parseEventID(eventIDs[0])
+	require.NoError(t, err)
+	assert.NotEqual(t, changeCursor{}.UID, cursor.UID, "Event IDs carry the version UID")
+
+	// Resuming after the first event of the group returns the other two, then new versions
+	_, err = scd.Update(db, "job-1", func(j *models.Job) { j.Pause() })
+	require.NoError(t, err)
+	_, resumed := readStream(t, server.URL+"/changes/stream?types=jobs", eventIDs[0], 3)
+	assert.Equal(t, append(append([]string{}, jobs[1:]...), "job-1"), resumed)
+
+	// A bare timestamp, as sent by older clients, resumes after the whole group
+	_, resumed = readStream(t, server.URL+"/changes/stream?types=jobs", cursor.ValidFrom.UTC().Format(time.RFC3339Nano), 1)
+	assert.Equal(t, []string{"job-1"}, resumed)
+
+	resp, err := http.Get(server.URL + "/changes/stream?last_event_id=yesterday")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
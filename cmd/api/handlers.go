@@ -1,8 +1,12 @@
 package main
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/abhi14nexu/mercor-scd/internal/models"
 	"github.com/abhi14nexu/mercor-scd/internal/scd"
@@ -10,206 +14,375 @@ import (
 	"gorm.io/gorm"
 )
 
-// getJobs returns all latest job versions with optional filtering
-func getJobs(db *gorm.DB) gin.HandlerFunc {
-	return func(c *gin.Context) {
-		var jobs []models.Job
-
-		query := db.Scopes(scd.Latest)
-
-		// Optional filters
-		if company := c.Query("company"); company != "" {
-			query = query.Where("company_id = ?", company)
-		}
-		if contractor := c.Query("contractor"); contractor != "" {
-			query = query.Where("contractor_id = ?", contractor)
-		}
-		if status := c.Query("status"); status != "" {
-			query = query.Where("status = ?", status)
-		}
-
-		if err := query.Find(&jobs).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
-		}
+// repositories holds the typed SCD repositories used by the handlers
+type repositories struct {
+	jobs         *scd.Repository[*models.Job]
+	timelogs     *scd.Repository[*models.Timelog]
+	payments     *scd.Repository[*models.PaymentLineItem]
+	fxRates      *scd.Repository[*models.FXRate]
+	rateRules    *scd.Repository[*models.RateRule]
+	payoutRuns   *scd.Repository[*models.PayoutRun]
+	bankAccounts *scd.Repository[*models.BankAccount]
+}
 
-		c.JSON(http.StatusOK, gin.H{"data": jobs, "count": len(jobs)})
+// newRepositories creates a repository for every SCD model served by the API
+func newRepositories(db *gorm.DB) (*repositories, error) {
+	jobs, err := scd.NewRepository[*models.Job](db)
+	if err != nil {
+		return nil, err
+	}
+	timelogs, err := scd.NewRepository[*models.Timelog](db)
+	if err != nil {
+		return nil, err
 	}
+	payments, err := scd.NewRepository[*models.PaymentLineItem](db)
+	if err != nil {
+		return nil, err
+	}
+	fxRates, err := scd.NewRepository[*models.FXRate](db)
+	if err != nil {
+		return nil, err
+	}
+	rateRules, err := scd.NewRepository[*models.RateRule](db)
+	if err != nil {
+		return nil, err
+	}
+	payoutRuns, err := scd.NewRepository[*models.PayoutRun](db)
+	if err != nil {
+		return nil, err
+	}
+	bankAccounts, err := scd.NewRepository[*models.BankAccount](db)
+	if err != nil {
+		return nil, err
+	}
+	return &repositories{jobs: jobs, timelogs: timelogs, payments: payments, fxRates: fxRates, rateRules: rateRules, payoutRuns: payoutRuns, bankAccounts: bankAccounts}, nil
 }
 
-// getJob returns the latest version of a specific job by business ID
-func getJob(db *gorm.DB) gin.HandlerFunc {
+// getEntity returns the latest version of an entity by business ID
+// as_of returns the version valid at that time and include_deleted=true also finds soft-deleted entities.
+func getEntity[T scd.SCDModel](repo *scd.Repository[T], notFound string) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		id := c.Param("id")
+		opts, err := readOptions(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 
-		var job models.Job
-		if err := db.Scopes(scd.Latest, scd.ByBusinessID(id)).First(&job).Error; err != nil {
-			if err == gorm.ErrRecordNotFound {
-				c.JSON(http.StatusNotFound, gin.H{"error": "Job not found"})
+		entity, err := repo.Get(c.Param("id"), opts)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": notFound})
 				return
 			}
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
 
-		c.JSON(http.StatusOK, gin.H{"data": job})
+		c.JSON(http.StatusOK, gin.H{"data": entity})
 	}
 }
 
-// getJobVersions returns all versions of a specific job by business ID
-func getJobVersions(db *gorm.DB) gin.HandlerFunc {
+// getVersions returns all versions of an entity by business ID, oldest first
+func getVersions[T scd.SCDModel](repo *scd.Repository[T], notFound string) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		id := c.Param("id")
-
-		var jobs []models.Job
-		query := db.Scopes(scd.ByBusinessID(id), scd.OrderByVersion(false))
-
-		if err := query.Find(&jobs).Error; err != nil {
+		versions, err := repo.History(c.Param("id"))
+		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
 
-		if len(jobs) == 0 {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Job not found"})
+		if len(versions) == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": notFound})
 			return
 		}
 
-		c.JSON(http.StatusOK, gin.H{"data": jobs, "count": len(jobs)})
+		c.JSON(http.StatusOK, gin.H{"data": versions, "count": len(versions)})
 	}
 }
 
-// getPayments returns all latest payment line item versions with optional filtering
-func getPayments(db *gorm.DB) gin.HandlerFunc {
+// getTimeline returns the values of one field over time as collapsed intervals
+func getTimeline[T scd.SCDModel](repo *scd.Repository[T], notFound string) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var payments []models.PaymentLineItem
-
-		query := db.Scopes(scd.Latest)
-
-		// Optional filters
-		if status := c.Query("status"); status != "" {
-			query = query.Where("status = ?", status)
-		}
-		if contractor := c.Query("contractor"); contractor != "" {
-			// Join with jobs to filter by contractor
-			query = query.Joins("JOIN jobs ON payment_line_items.job_uid = jobs.uid").
-				Where("jobs.contractor_id = ? AND jobs.valid_to IS NULL", contractor)
+		field := c.Query("field")
+		if field == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "field query parameter is required"})
+			return
 		}
 
-		if err := query.Find(&payments).Error; err != nil {
+		intervals, err := repo.Timeline(c.Param("id"), field)
+		if err != nil {
+			if errors.Is(err, scd.ErrUnknownField) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
 
-		c.JSON(http.StatusOK, gin.H{"data": payments, "count": len(payments)})
+		if len(intervals) == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": notFound})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"data": intervals, "field": field, "count": len(intervals)})
 	}
 }
 
-// getPayment returns the latest version of a specific payment by business ID
-func getPayment(db *gorm.DB) gin.HandlerFunc {
-	return func(c *gin.Context) {
-		id := c.Param("id")
+// statefulModel is an SCD model whose status follows a transition table
+type statefulModel interface {
+	scd.SCDModel
+	scd.StateMachine
+}
 
-		var payment models.PaymentLineItem
-		if err := db.Scopes(scd.Latest, scd.ByBusinessID(id)).First(&payment).Error; err != nil {
-			if err == gorm.ErrRecordNotFound {
-				c.JSON(http.StatusNotFound, gin.H{"error": "Payment not found"})
+// getTransitions returns the current status of an entity and the statuses it may move to next
+func getTransitions[T statefulModel](repo *scd.Repository[T], notFound string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		entity, err := repo.Get(c.Param("id"), scd.ReadOptions{})
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": notFound})
 				return
 			}
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
 
-		c.JSON(http.StatusOK, gin.H{"data": payment})
+		status := entity.State()
+		c.JSON(http.StatusOK, gin.H{"id": entity.GetBusinessID(), "status": status, "allowed": entity.Transitions().Next(status)})
 	}
 }
 
-// getPaymentVersions returns all versions of a specific payment by business ID
-func getPaymentVersions(db *gorm.DB) gin.HandlerFunc {
-	return func(c *gin.Context) {
-		id := c.Param("id")
-
-		var payments []models.PaymentLineItem
-		query := db.Scopes(scd.ByBusinessID(id), scd.OrderByVersion(false))
-
-		if err := query.Find(&payments).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
-		}
+// listLatest runs a paginated list query and writes the standard data/count/next_cursor response
+// as_of, valid_during and include_deleted select which versions are listed.
+func listLatest[T scd.SCDModel](c *gin.Context, repo *scd.Repository[T], opts scd.ListOptions) {
+	req, err := pageRequest(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	read, err := readOptions(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	opts.AsOf, opts.IncludeDeleted = read.AsOf, read.IncludeDeleted
+	if opts.ValidDuring, err = validDuring(c); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if opts.AsOf != nil && opts.ValidDuring != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "as_of and valid_during cannot be combined"})
+		return
+	}
 
-		if len(payments) == 0 {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Payment not found"})
+	page, err := repo.Page(opts, req)
+	if err != nil {
+		if errors.Is(err, scd.ErrInvalidCursor) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
 
-		c.JSON(http.StatusOK, gin.H{"data": payments, "count": len(payments)})
+	response := gin.H{"data": page.Items, "count": len(page.Items)}
+	if page.NextCursor != "" {
+		response["next_cursor"] = page.NextCursor
 	}
+	c.JSON(http.StatusOK, response)
 }
 
-// getTimelogs returns all latest timelog versions with optional filtering
-func getTimelogs(db *gorm.DB) gin.HandlerFunc {
-	return func(c *gin.Context) {
-		var timelogs []models.Timelog
-
-		query := db.Scopes(scd.Latest)
+// pageRequest reads the cursor, page_size and order query parameters
+// limit is still accepted as an alias of page_size for older clients.
+func pageRequest(c *gin.Context) (scd.PageRequest, error) {
+	req := scd.PageRequest{Cursor: c.Query("cursor"), Order: scd.PageOrder(c.DefaultQuery("order", string(scd.PageByBusinessID)))}
+	if req.Order != scd.PageByBusinessID && req.Order != scd.PageByValidFrom {
+		return req, fmt.Errorf("order must be %q or %q", scd.PageByBusinessID, scd.PageByValidFrom)
+	}
+	if size := c.DefaultQuery("page_size", c.Query("limit")); size != "" {
+		n, err := strconv.Atoi(size)
+		if err != nil || n <= 0 || n > scd.MaxPageSize {
+			return req, fmt.Errorf("page_size must be between 1 and %d", scd.MaxPageSize)
+		}
+		req.Size = n
+	}
+	return req, nil
+}
 
-		// Optional filters
-		if contractor := c.Query("contractor"); contractor != "" {
-			// Join with jobs to filter by contractor
-			query = query.Joins("JOIN jobs ON timelogs.job_uid = jobs.uid").
-				Where("jobs.contractor_id = ? AND jobs.valid_to IS NULL", contractor)
+// readOptions reads the as_of (RFC3339) and include_deleted query parameters
+func readOptions(c *gin.Context) (scd.ReadOptions, error) {
+	var opts scd.ReadOptions
+	if value := c.Query("as_of"); value != "" {
+		asOf, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return opts, fmt.Errorf("as_of must be an RFC3339 time: %w", err)
 		}
-		if limit := c.Query("limit"); limit != "" {
-			if l, err := strconv.Atoi(limit); err == nil && l > 0 {
-				query = query.Limit(l)
-			}
+		opts.AsOf = &asOf
+	}
+	if value := c.Query("include_deleted"); value != "" {
+		include, err := strconv.ParseBool(value)
+		if err != nil {
+			return opts, fmt.Errorf("include_deleted must be true or false")
 		}
+		opts.IncludeDeleted = include
+	}
+	return opts, nil
+}
 
-		if err := query.Find(&timelogs).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
+// validDuring reads the valid_during=start,end query parameter
+func validDuring(c *gin.Context) (*scd.TimeRange, error) {
+	value := c.Query("valid_during")
+	if value == "" {
+		return nil, nil
+	}
+	start, end, ok := strings.Cut(value, ",")
+	if !ok {
+		return nil, fmt.Errorf("valid_during must be start,end")
+	}
+	var r scd.TimeRange
+	var err error
+	if r.Start, err = time.Parse(time.RFC3339, strings.TrimSpace(start)); err != nil {
+		return nil, fmt.Errorf("valid_during start must be an RFC3339 time: %w", err)
+	}
+	if r.End, err = time.Parse(time.RFC3339, strings.TrimSpace(end)); err != nil {
+		return nil, fmt.Errorf("valid_during end must be an RFC3339 time: %w", err)
+	}
+	if r.End.Before(r.Start) {
+		return nil, fmt.Errorf("valid_during end is before start")
+	}
+	return &r, nil
+}
+
+// queryFilters copies the non-empty query parameters onto column filters
+// params maps query parameter names to column names
+func queryFilters(c *gin.Context, params map[string]string) map[string]interface{} {
+	filters := map[string]interface{}{}
+	for param, column := range params {
+		if value := c.Query(param); value != "" {
+			filters[column] = value
 		}
+	}
+	return filters
+}
 
-		c.JSON(http.StatusOK, gin.H{"data": timelogs, "count": len(timelogs)})
+// byContractor restricts a job-owned table to rows whose pinned job version belongs to the contractor
+// The pinned version may since have been superseded, so it is not restricted to current jobs.
+func byContractor(table, contractor string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Joins("JOIN jobs ON "+table+".job_uid = jobs.uid").
+			Where("jobs.contractor_id = ?", contractor)
 	}
 }
 
-// getTimelog returns the latest version of a specific timelog by business ID
-func getTimelog(db *gorm.DB) gin.HandlerFunc {
+// getJobs returns all latest job versions with optional filtering
+func getJobs(repos *repositories) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		id := c.Param("id")
+		listLatest(c, repos.jobs, scd.ListOptions{
+			Filters: queryFilters(c, map[string]string{
+				"company":    "company_id",
+				"contractor": "contractor_id",
+				"status":     "status",
+			}),
+		})
+	}
+}
 
-		var timelog models.Timelog
-		if err := db.Scopes(scd.Latest, scd.ByBusinessID(id)).First(&timelog).Error; err != nil {
-			if err == gorm.ErrRecordNotFound {
-				c.JSON(http.StatusNotFound, gin.H{"error": "Timelog not found"})
-				return
-			}
+// getPayments returns all latest payment line item versions with optional filtering
+func getPayments(repos *repositories) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		opts := scd.ListOptions{Filters: queryFilters(c, map[string]string{"status": "status"})}
+		if contractor := c.Query("contractor"); contractor != "" {
+			opts.Scopes = append(opts.Scopes, byContractor(repos.payments.Table(), contractor))
+		}
+
+		listLatest(c, repos.payments, opts)
+	}
+}
+
+// getStaleRefs reports current payment line items whose referenced job or timelog versions are out of date
+// ?contractor= and repeatable ?payment= narrow the report.
+func getStaleRefs(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		stale, err := models.StaleReferences(db, models.StaleReferenceFilter{
+			ContractorID: c.Query("contractor"),
+			PaymentIDs:   c.QueryArray("payment"),
+		})
+		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
 
-		c.JSON(http.StatusOK, gin.H{"data": timelog})
+		c.JSON(http.StatusOK, gin.H{"data": stale, "count": len(stale)})
 	}
 }
 
-// getTimelogVersions returns all versions of a specific timelog by business ID
-func getTimelogVersions(db *gorm.DB) gin.HandlerFunc {
+// getFXRates returns all latest exchange rate versions, optionally filtered by base and quote currency
+func getFXRates(repos *repositories) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		id := c.Param("id")
+		opts := scd.ListOptions{Filters: queryFilters(c, map[string]string{"base": "base", "quote": "quote"})}
+		listLatest(c, repos.fxRates, opts)
+	}
+}
+
+// getRateRules returns all latest rate rule versions, optionally filtered by job
+func getRateRules(repos *repositories) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		listLatest(c, repos.rateRules, scd.ListOptions{Filters: queryFilters(c, map[string]string{"job": "job_id"})})
+	}
+}
 
-		var timelogs []models.Timelog
-		query := db.Scopes(scd.ByBusinessID(id), scd.OrderByVersion(false))
+// payPeriod prices a job's timelogs for the start/end (RFC3339) period under its rate rules
+// With save the priced line items are created, otherwise the calculation is only returned.
+func payPeriod(db *gorm.DB, save bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var period models.PayPeriod
+		var err error
+		if period.Start, err = time.Parse(time.RFC3339, c.Query("start")); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "start must be an RFC3339 time"})
+			return
+		}
+		if period.End, err = time.Parse(time.RFC3339, c.Query("end")); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "end must be an RFC3339 time"})
+			return
+		}
+		if !period.End.After(period.Start) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "end must be after start"})
+			return
+		}
 
-		if err := query.Find(&timelogs).Error; err != nil {
+		calc, err := models.CalculatePayPeriod(db, c.Param("id"), period)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Job not found"})
+				return
+			}
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
+		if !save {
+			c.JSON(http.StatusOK, gin.H{"data": calc})
+			return
+		}
 
-		if len(timelogs) == 0 {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Timelog not found"})
+		if err := calc.Save(db); err != nil {
+			if errors.Is(err, scd.ErrAlreadyExists) {
+				c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+				return
+			}
+			writeError(c, err)
 			return
 		}
+		c.JSON(http.StatusCreated, gin.H{"data": calc})
+	}
+}
 
-		c.JSON(http.StatusOK, gin.H{"data": timelogs, "count": len(timelogs)})
+// getTimelogs returns all latest timelog versions with optional filtering
+func getTimelogs(repos *repositories) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var opts scd.ListOptions
+		if contractor := c.Query("contractor"); contractor != "" {
+			opts.Scopes = append(opts.Scopes, byContractor(repos.timelogs.Table(), contractor))
+		}
+		listLatest(c, repos.timelogs, opts)
 	}
 }
@@ -0,0 +1,571 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/abhi14nexu/mercor-scd/internal/models"
+	"github.com/abhi14nexu/mercor-scd/internal/money"
+	"github.com/abhi14nexu/mercor-scd/internal/payout"
+	"github.com/abhi14nexu/mercor-scd/internal/scd"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// listResponse is the body of the list endpoints
+type listResponse struct {
+	Data       []map[string]interface{} `json:"data"`
+	Count      int                      `json:"count"`
+	NextCursor string                   `json:"next_cursor"`
+	Error      string                   `json:"error"`
+}
+
+// setupTestDB creates an in-memory SQLite database with the model tables
+func setupTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	require.NoError(t, models.Register(db))
+
+	// Servers and background senders run concurrently; keep them on the one in-memory database
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	return db
+}
+
+// newTestRouter serves the read endpoints over the given database
+func newTestRouter(t *testing.T, db *gorm.DB) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	repos, err := newRepositories(db)
+	require.NoError(t, err)
+
+	router := gin.New()
+	router.GET("/jobs", getJobs(repos))
+	router.GET("/jobs/:id", getEntity(repos.jobs, "Job not found"))
+	router.GET("/timelogs", getTimelogs(repos))
+	router.GET("/payments", getPayments(repos))
+	router.POST("/jobs", createEntity(repos.jobs, jobWrites))
+	router.PATCH("/jobs/:id", updateEntity(repos.jobs, jobWrites, "Job not found"))
+	router.DELETE("/jobs/:id", deleteEntity(repos.jobs, "Job not found"))
+	router.GET("/jobs/:id/transitions", getTransitions(repos.jobs, "Job not found"))
+	router.POST("/timelogs", createEntity(repos.timelogs, timelogWrites))
+	router.PATCH("/timelogs/:id", updateEntity(repos.timelogs, timelogWrites, "Timelog not found"))
+	router.POST("/payments", createEntity(repos.payments, paymentWrites))
+	router.PATCH("/payments/:id", updateEntity(repos.payments, paymentWrites, "Payment not found"))
+	router.POST("/rate-rules", createEntity(repos.rateRules, rateRuleWrites))
+	router.GET("/jobs/:id/pay-period", payPeriod(db, false))
+	router.POST("/jobs/:id/pay-period", payPeriod(db, true))
+	router.POST("/payout-runs", createPayoutRun(db))
+	router.GET("/payout-runs/:id/items", getPayoutRunItems(db))
+	router.POST("/payout-runs/:id/approve", transitionPayoutRun(db, models.ApprovePayoutRun))
+	router.POST("/payout-runs/:id/submit", transitionPayoutRun(db, models.SubmitPayoutRun))
+	router.POST("/payout-runs/:id/settle", transitionPayoutRun(db, models.SettlePayoutRun))
+	router.POST("/bank-accounts", createEntity(repos.bankAccounts, bankAccountWrites))
+	router.POST("/payments/export", exportPayments(db))
+	payouts := payout.NewProcessor(db, payout.NewFake(payout.FakeOptions{}))
+	router.POST("/payout-runs/:id/process", processPayoutRun(payouts.Submit))
+	router.POST("/payout-runs/:id/poll", processPayoutRun(payouts.Poll))
+	return router
+}
+
+// getList performs a GET request and decodes the list response
+func getList(t *testing.T, router *gin.Engine, url string) (int, listResponse) {
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, url, nil))
+
+	var body listResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	return w.Code, body
+}
+
+// TestListEndpointsPaginate tests cursor pagination on list endpoints, including joined filters
+func TestListEndpointsPaginate(t *testing.T) {
+	db := setupTestDB(t)
+	router := newTestRouter(t, db)
+
+	job, err := scd.CreateNew(db, models.NewJob("job-1", "Engineer", "company-acme", "contractor-alice", money.FromMajor(50, money.USD)))
+	require.NoError(t, err)
+	_, err = scd.CreateNew(db, models.NewJob("job-2", "Designer", "company-acme", "contractor-bob", money.FromMajor(40, money.USD)))
+	require.NoError(t, err)
+	start := time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+	for i := 1; i <= 5; i++ {
+		timelog := models.NewTimelog(fmt.Sprintf("timelog-%d", i), job.UID, start, start.Add(time.Hour))
+		_, err := scd.CreateNew(db, timelog)
+		require.NoError(t, err)
+	}
+
+	var ids []string
+	url := "/timelogs?contractor=contractor-alice&page_size=2"
+	for pages := 0; ; pages++ {
+		require.Less(t, pages, 5, "Pagination should terminate")
+		code, body := getList(t, router, url)
+		require.Equal(t, http.StatusOK, code, body.Error)
+		for _, row := range body.Data {
+			ids = append(ids, row["id"].(string))
+		}
+		if body.NextCursor == "" {
+			break
+		}
+		url = "/timelogs?contractor=contractor-alice&page_size=2&cursor=" + body.NextCursor
+	}
+	assert.Equal(t, []string{"timelog-1", "timelog-2", "timelog-3", "timelog-4", "timelog-5"}, ids)
+
+	code, body := getList(t, router, "/jobs?cursor=garbage")
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.NotEmpty(t, body.Error)
+
+	code, _ = getList(t, router, "/jobs?page_size=0")
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	code, body = getList(t, router, "/jobs?order=valid_from&limit=1")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, 1, body.Count, "limit is an alias of page_size")
+	assert.NotEmpty(t, body.NextCursor)
+}
+
+// TestContractorFilterFollowsSupersededJobs tests that ?contractor= keeps rows pinned to an old job version
+func TestContractorFilterFollowsSupersededJobs(t *testing.T) {
+	db := setupTestDB(t)
+	router := newTestRouter(t, db)
+
+	job, err := scd.CreateNew(db, models.NewJob("job-1", "Engineer", "company-acme", "contractor-alice", money.FromMajor(50, money.USD)))
+	require.NoError(t, err)
+	_, err = scd.CreateNew(db, models.NewJob("job-2", "Designer", "company-acme", "contractor-bob", money.FromMajor(40, money.USD)))
+	require.NoError(t, err)
+	start := time.Date(2025, 1, 6, 9, 0, 0, 0, time.UTC)
+	timelog, err := scd.CreateNew(db, models.NewTimelog("timelog-1", job.UID, start, start.Add(2*time.Hour)))
+	require.NoError(t, err)
+	_, err = scd.CreateNew(db, models.NewCalculatedPaymentLineItem("payment-1", job, timelog))
+	require.NoError(t, err)
+
+	_, err = scd.Update(db, "job-1", func(j *models.Job) { j.UpdateRate(money.FromMajor(60, money.USD)) })
+	require.NoError(t, err)
+
+	code, body := getList(t, router, "/payments?contractor=contractor-alice")
+	require.Equal(t, http.StatusOK, code, body.Error)
+	require.Equal(t, 1, body.Count, "Payments pinned to a superseded job version still belong to the contractor")
+	assert.Equal(t, "payment-1", body.Data[0]["id"])
+
+	code, body = getList(t, router, "/timelogs?contractor=contractor-alice")
+	require.Equal(t, http.StatusOK, code, body.Error)
+	assert.Equal(t, 1, body.Count)
+
+	code, body = getList(t, router, "/payments?contractor=contractor-bob")
+	require.Equal(t, http.StatusOK, code, body.Error)
+	assert.Equal(t, 0, body.Count)
+}
+
+// TestReadEndpointsTimeTravel tests as_of, valid_during and include_deleted parameters
+func TestReadEndpointsTimeTravel(t *testing.T) {
+	db := setupTestDB(t)
+	router := newTestRouter(t, db)
+
+	_, err := scd.CreateNew(db, models.NewJob("job-1", "Engineer", "company-acme", "contractor-alice", money.FromMajor(50, money.USD)))
+	require.NoError(t, err)
+	before := time.Now()
+	time.Sleep(2 * time.Millisecond)
+	_, err = scd.Update(db, "job-1", func(j *models.Job) { j.UpdateRate(money.FromMajor(60, money.USD)) })
+	require.NoError(t, err)
+	require.NoError(t, scd.SoftDelete[*models.Job](db, "job-1"))
+	asOf := url.QueryEscape(before.Format(time.RFC3339Nano))
+
+	code, _ := getList(t, router, "/jobs/job-1")
+	assert.Equal(t, http.StatusNotFound, code, "Deleted jobs are hidden by default")
+
+	code, body := getList(t, router, "/jobs")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, 0, body.Count)
+
+	code, body = getList(t, router, "/jobs?include_deleted=true")
+	require.Equal(t, http.StatusOK, code, body.Error)
+	require.Equal(t, 1, body.Count)
+	assert.Equal(t, "60.00", body.Data[0]["rate"])
+
+	code, body = getList(t, router, "/jobs?as_of="+asOf)
+	require.Equal(t, http.StatusOK, code, body.Error)
+	require.Equal(t, 1, body.Count)
+	assert.Equal(t, "50.00", body.Data[0]["rate"])
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/jobs/job-1?as_of="+asOf, nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"version":1`)
+
+	during := url.QueryEscape(before.Add(-time.Hour).Format(time.RFC3339) + "," + time.Now().Add(time.Hour).Format(time.RFC3339))
+	code, body = getList(t, router, "/jobs?valid_during="+during)
+	require.Equal(t, http.StatusOK, code, body.Error)
+	assert.Equal(t, 2, body.Count, "Both versions overlap the range")
+
+	for _, bad := range []string{"as_of=yesterday", "include_deleted=maybe", "valid_during=" + asOf, "as_of=" + asOf + "&valid_during=" + during} {
+		code, _ = getList(t, router, "/jobs?"+bad)
+		assert.Equal(t, http.StatusBadRequest, code, bad)
+	}
+}
+
+// send performs a write request with a JSON body and decodes the response
+func send(t *testing.T, router *gin.Engine, method, url, body string) (int, map[string]interface{}) {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(method, url, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	var response map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	return w.Code, response
+}
+
+// TestWriteEndpoints tests create, partial update and delete with validation
+func TestWriteEndpoints(t *testing.T) {
+	db := setupTestDB(t)
+	router := newTestRouter(t, db)
+
+	job := `{"id":"job-1","title":"Engineer","company_id":"company-acme","contractor_id":"contractor-alice","status":"active","rate":50}`
+	code, body := send(t, router, http.MethodPost, "/jobs", job)
+	require.Equal(t, http.StatusCreated, code, body)
+	assert.Equal(t, 1.0, body["data"].(map[string]interface{})["version"])
+
+	code, _ = send(t, router, http.MethodPost, "/jobs", job)
+	assert.Equal(t, http.StatusConflict, code)
+
+	code, body = send(t, router, http.MethodPost, "/jobs", `{"id":"job-2","title":"","status":"banana","rate":-1}`)
+	require.Equal(t, http.StatusUnprocessableEntity, code)
+	fields := map[string]bool{}
+	for _, f := range body["fields"].([]interface{}) {
+		fields[f.(map[string]interface{})["field"].(string)] = true
+	}
+	for _, name := range []string{"title", "status", "rate", "company_id", "contractor_id"} {
+		assert.True(t, fields[name], "Expected a validation error for %s", name)
+	}
+
+	code, body = send(t, router, http.MethodPatch, "/jobs/job-1", `{"rate":65}`)
+	require.Equal(t, http.StatusOK, code, body)
+	updated := body["data"].(map[string]interface{})
+	assert.Equal(t, 2.0, updated["version"])
+	assert.Equal(t, "65.00", updated["rate"])
+	assert.Equal(t, "Engineer", updated["title"], "Fields missing from the patch keep their values")
+
+	code, _ = send(t, router, http.MethodPatch, "/jobs/job-1", `{"status":"banana"}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, code)
+	versions, err := scd.GetAllVersions[*models.Job](db, "job-1")
+	require.NoError(t, err)
+	assert.Len(t, versions, 2, "A rejected patch must not write a version")
+
+	for _, bad := range []string{`{"version":9}`, `{"id":"job-9"}`, `{"salary":1}`, `[1]`} {
+		code, _ = send(t, router, http.MethodPatch, "/jobs/job-1", bad)
+		assert.Equal(t, http.StatusBadRequest, code, bad)
+	}
+	code, _ = send(t, router, http.MethodPatch, "/jobs/job-404", `{"rate":1}`)
+	assert.Equal(t, http.StatusNotFound, code)
+
+	code, body = send(t, router, http.MethodDelete, "/jobs/job-1", "")
+	require.Equal(t, http.StatusOK, code, body)
+	assert.NotNil(t, body["data"].(map[string]interface{})["valid_to"])
+	code, _ = send(t, router, http.MethodDelete, "/jobs/job-1", "")
+	assert.Equal(t, http.StatusNotFound, code)
+
+	timelog := fmt.Sprintf(`{"id":"timelog-1","job_uid":"%s","time_start":2000,"time_end":1000,"type":"captured"}`, versions[1].UID)
+	code, body = send(t, router, http.MethodPost, "/timelogs", timelog)
+	require.Equal(t, http.StatusUnprocessableEntity, code)
+	assert.Equal(t, "time_end", body["fields"].([]interface{})[0].(map[string]interface{})["field"])
+}
+
+// TestWriteEndpointsRejectProtectedFields tests that only each resource's writable fields are accepted
+func TestWriteEndpointsRejectProtectedFields(t *testing.T) {
+	db := setupTestDB(t)
+	router := newTestRouter(t, db)
+
+	job, err := scd.CreateNew(db, models.NewJob("job-1", "Engineer", "company-acme", "contractor-alice", money.FromMajor(50, money.USD)))
+	require.NoError(t, err)
+	start := time.Date(2025, 1, 6, 9, 0, 0, 0, time.UTC)
+	timelog, err := scd.CreateNew(db, models.NewTimelog("timelog-1", job.UID, start, start.Add(2*time.Hour)))
+	require.NoError(t, err)
+	_, err = scd.CreateNew(db, models.NewCalculatedPaymentLineItem("payment-1", job, timelog))
+	require.NoError(t, err)
+
+	for _, field := range []string{
+		`"uid":"` + uuid.NewString() + `"`, `"version":9`, `"valid_from":"2025-01-01T00:00:00Z"`, `"valid_to":null`, `"id":"payment-2"`,
+		`"export_file_id":""`, `"payout_run_id":""`, `"payout_provider":"fake"`, `"payout_ref":"ref-1"`, `"payout_status":"succeeded"`,
+		`"payout_response":"{}"`, `"amount":1`, `"currency":"EUR"`, `"fx_rate_uid":"` + uuid.NewString() + `"`,
+		`"rate_rule_uid":"` + uuid.NewString() + `"`, `"explanation":{}`, `"job_uid":"` + job.UID.String() + `"`,
+		`"job":{"title":"x"}`, `"latest_job":{}`, `"timelog":{}`, `"fx_rate":{}`,
+	} {
+		code, body := send(t, router, http.MethodPatch, "/payments/payment-1", "{"+field+"}")
+		assert.Equal(t, http.StatusBadRequest, code, "PATCH %s: %v", field, body)
+	}
+	versions, err := scd.GetAllVersions[*models.PaymentLineItem](db, "payment-1")
+	require.NoError(t, err)
+	assert.Len(t, versions, 1, "Rejected patches must not write a version")
+
+	code, body := send(t, router, http.MethodPatch, "/payments/payment-1", `{"status":"paid"}`)
+	require.Equal(t, http.StatusOK, code, body)
+
+	payment := `{"id":"payment-2","job_uid":"%s","timelog_uid":"%s","amount":100,"status":"not-paid"%s}`
+	for _, field := range []string{`,"export_file_id":"ach-1"`, `,"payout_run_id":"run-1"`, `,"fx_rate_uid":"` + uuid.NewString() + `"`, `,"job":{}`} {
+		code, body = send(t, router, http.MethodPost, "/payments", fmt.Sprintf(payment, job.UID, timelog.UID, field))
+		assert.Equal(t, http.StatusBadRequest, code, "POST %s: %v", field, body)
+	}
+
+	code, body = send(t, router, http.MethodPost, "/payments", fmt.Sprintf(payment, uuid.New(), uuid.New(), ""))
+	require.Equal(t, http.StatusUnprocessableEntity, code, body)
+	fields := []string{}
+	for _, f := range body["fields"].([]interface{}) {
+		fields = append(fields, f.(map[string]interface{})["field"].(string))
+	}
+	assert.Equal(t, []string{"job_uid", "timelog_uid"}, fields, "References must name existing versions")
+
+	code, body = send(t, router, http.MethodPost, "/payments", fmt.Sprintf(payment, job.UID, timelog.UID, ""))
+	require.Equal(t, http.StatusCreated, code, body)
+
+	code, body = send(t, router, http.MethodPatch, "/timelogs/timelog-1", `{"job_uid":"`+uuid.NewString()+`"}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, code, body)
+	code, body = send(t, router, http.MethodPatch, "/timelogs/timelog-1", `{"latest_job":{}}`)
+	assert.Equal(t, http.StatusBadRequest, code, body)
+	code, body = send(t, router, http.MethodPatch, "/jobs/job-1", `{"salary":1}`)
+	assert.Equal(t, http.StatusBadRequest, code, body)
+}
+
+// TestPatchRecalculatesPayments tests that job and timelog PATCHes re-price unpaid line items
+func TestPatchRecalculatesPayments(t *testing.T) {
+	db := setupTestDB(t)
+	router := newTestRouter(t, db)
+
+	job, err := scd.CreateNew(db, models.NewJob("job-1", "Engineer", "company-acme", "contractor-alice", money.FromMajor(50, money.USD)))
+	require.NoError(t, err)
+	start := time.Date(2025, 1, 6, 9, 0, 0, 0, time.UTC)
+	timelog, err := scd.CreateNew(db, models.NewTimelog("timelog-1", job.UID, start, start.Add(2*time.Hour)))
+	require.NoError(t, err)
+	_, err = scd.CreateNew(db, models.NewCalculatedPaymentLineItem("payment-open", job, timelog))
+	require.NoError(t, err)
+	paid := models.NewCalculatedPaymentLineItem("payment-paid", job, timelog)
+	paid.MarkPaid()
+	_, err = scd.CreateNew(db, paid)
+	require.NoError(t, err)
+
+	code, body := send(t, router, http.MethodPatch, "/jobs/job-1", `{"rate":60}`)
+	require.Equal(t, http.StatusOK, code, body)
+	recalculation := body["recalculation"].(map[string]interface{})
+	require.Len(t, recalculation["updated"], 1)
+	assert.Len(t, recalculation["paid"], 1, "Paid items are reported, not re-priced")
+
+	open, err := scd.GetLatest[*models.PaymentLineItem](db, "payment-open")
+	require.NoError(t, err)
+	assert.Equal(t, 2, open.Version)
+	assert.Equal(t, "120.00", open.Amount.String())
+	assert.Equal(t, body["data"].(map[string]interface{})["uid"], open.JobUID.String())
+	stillPaid, err := scd.GetLatest[*models.PaymentLineItem](db, "payment-paid")
+	require.NoError(t, err)
+	assert.Equal(t, "100.00", stillPaid.Amount.String())
+
+	code, body = send(t, router, http.MethodPatch, "/timelogs/timelog-1", fmt.Sprintf(`{"time_end":%d}`, start.Add(3*time.Hour).Unix()))
+	require.Equal(t, http.StatusOK, code, body)
+	open, err = scd.GetLatest[*models.PaymentLineItem](db, "payment-open")
+	require.NoError(t, err)
+	assert.Equal(t, 3, open.Version)
+	assert.Equal(t, body["data"].(map[string]interface{})["uid"], open.TimelogUID.String())
+}
+
+// TestStatusTransitions tests that the allowed next statuses are exposed and enforced on PATCH
+func TestStatusTransitions(t *testing.T) {
+	db := setupTestDB(t)
+	router := newTestRouter(t, db)
+
+	_, err := scd.CreateNew(db, models.NewJob("job-1", "Engineer", "company-acme", "contractor-alice", money.FromMajor(50, money.USD)))
+	require.NoError(t, err)
+
+	code, body := send(t, router, http.MethodGet, "/jobs/job-1/transitions", "")
+	require.Equal(t, http.StatusOK, code, body)
+	assert.Equal(t, "active", body["status"])
+	assert.Equal(t, []interface{}{"paused", "extended", "completed"}, body["allowed"])
+
+	code, body = send(t, router, http.MethodPatch, "/jobs/job-1", `{"status":"completed"}`)
+	require.Equal(t, http.StatusOK, code, body)
+
+	code, body = send(t, router, http.MethodPatch, "/jobs/job-1", `{"status":"active"}`)
+	assert.Equal(t, http.StatusConflict, code)
+	assert.Equal(t, "completed", body["from"])
+	assert.Equal(t, []interface{}{}, body["allowed"], "Completed jobs are final")
+
+	code, body = send(t, router, http.MethodGet, "/jobs/job-1/transitions", "")
+	require.Equal(t, http.StatusOK, code, body)
+	assert.Equal(t, "completed", body["status"])
+	assert.Empty(t, body["allowed"])
+
+	code, _ = send(t, router, http.MethodGet, "/jobs/job-404/transitions", "")
+	assert.Equal(t, http.StatusNotFound, code)
+}
+
+// TestPayPeriodEndpoints tests previewing and saving a pay period priced under rate rules
+func TestPayPeriodEndpoints(t *testing.T) {
+	db := setupTestDB(t)
+	router := newTestRouter(t, db)
+
+	job, err := scd.CreateNew(db, models.NewJob("job-1", "Engineer", "company-acme", "contractor-alice", money.FromMajor(60, money.USD)))
+	require.NoError(t, err)
+	start := time.Date(2025, 1, 6, 9, 0, 0, 0, time.UTC)
+	_, err = scd.CreateNew(db, models.NewTimelog("timelog-1", job.UID, start, start.Add(10*time.Minute)))
+	require.NoError(t, err)
+
+	code, body := send(t, router, http.MethodPost, "/rate-rules", `{"id":"rules-job-1","job_id":"job-1","minimum_minutes":-5}`)
+	require.Equal(t, http.StatusUnprocessableEntity, code, body)
+	code, body = send(t, router, http.MethodPost, "/rate-rules", `{"id":"rules-job-1","job_id":"job-1","minimum_minutes":30}`)
+	require.Equal(t, http.StatusCreated, code, body)
+
+	url := "/jobs/job-1/pay-period?start=2025-01-06T00:00:00Z&end=2025-01-13T00:00:00Z"
+	code, body = send(t, router, http.MethodGet, url, "")
+	require.Equal(t, http.StatusOK, code, body)
+	calc := body["data"].(map[string]interface{})
+	assert.Equal(t, "30.00", calc["total"], "10m is raised to the 30m minimum")
+	items := calc["items"].([]interface{})
+	require.Len(t, items, 1)
+	explanation := items[0].(map[string]interface{})["explanation"].(map[string]interface{})
+	assert.Contains(t, explanation["steps"], "raised to the 30m minimum")
+
+	code, body = send(t, router, http.MethodPost, url, "")
+	require.Equal(t, http.StatusCreated, code, body)
+	stored, err := scd.GetLatest[*models.PaymentLineItem](db, "pay-timelog-1")
+	require.NoError(t, err)
+	assert.Equal(t, money.New(3000, money.USD), stored.Amount)
+
+	code, body = send(t, router, http.MethodGet, url, "")
+	require.Equal(t, http.StatusOK, code, body)
+	assert.Equal(t, []interface{}{"timelog-1"}, body["data"].(map[string]interface{})["billed"])
+
+	code, _ = send(t, router, http.MethodGet, "/jobs/job-1/pay-period?start=2025-01-06", "")
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, _ = send(t, router, http.MethodGet, "/jobs/job-404/pay-period?start=2025-01-06T00:00:00Z&end=2025-01-13T00:00:00Z", "")
+	assert.Equal(t, http.StatusNotFound, code)
+}
+
+// TestPayoutRunEndpoints tests creating a payout run and walking it through its lifecycle
+func TestPayoutRunEndpoints(t *testing.T) {
+	db := setupTestDB(t)
+	router := newTestRouter(t, db)
+
+	job, err := scd.CreateNew(db, models.NewJob("job-1", "Engineer", "company-acme", "contractor-alice", money.FromMajor(50, money.USD)))
+	require.NoError(t, err)
+	start := time.Date(2025, 1, 6, 9, 0, 0, 0, time.UTC)
+	timelog, err := scd.CreateNew(db, models.NewTimelog("timelog-1", job.UID, start, start.Add(2*time.Hour)))
+	require.NoError(t, err)
+	_, err = scd.CreateNew(db, models.NewCalculatedPaymentLineItem("payment-1", job, timelog))
+	require.NoError(t, err)
+
+	request := `{"contractor_id":"contractor-alice","period_start":"2025-01-06T00:00:00Z","period_end":"2025-01-13T00:00:00Z"}`
+	code, body := send(t, router, http.MethodPost, "/payout-runs", request)
+	require.Equal(t, http.StatusCreated, code, body)
+	run := body["data"].(map[string]interface{})
+	assert.Equal(t, "payout-contractor-alice-20250106-USD", run["id"])
+	assert.Equal(t, "draft", run["status"])
+	assert.Equal(t, "100.00", run["total"])
+
+	code, body = send(t, router, http.MethodPost, "/payout-runs", `{"id":"payout-other","contractor_id":"contractor-alice","period_start":"2025-01-06T00:00:00Z","period_end":"2025-01-13T00:00:00Z"}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, code, body)
+
+	url := "/payout-runs/payout-contractor-alice-20250106-USD"
+	code, body = send(t, router, http.MethodPost, url+"/settle", "")
+	require.Equal(t, http.StatusConflict, code, body)
+	assert.Equal(t, []interface{}{"approved"}, body["allowed"])
+
+	for _, action := range []string{"approve", "submit", "settle"} {
+		code, body = send(t, router, http.MethodPost, url+"/"+action, "")
+		require.Equal(t, http.StatusOK, code, body)
+	}
+	assert.Equal(t, "settled", body["data"].(map[string]interface{})["status"])
+
+	code, items := getList(t, router, url+"/items")
+	require.Equal(t, http.StatusOK, code)
+	require.Len(t, items.Data, 1)
+	assert.Equal(t, "paid", items.Data[0]["status"])
+
+	code, _ = send(t, router, http.MethodPost, "/payout-runs/payout-404/approve", "")
+	assert.Equal(t, http.StatusNotFound, code)
+}
+
+// TestProcessPayoutRunEndpoints tests paying a run through the fake provider
+func TestProcessPayoutRunEndpoints(t *testing.T) {
+	db := setupTestDB(t)
+	router := newTestRouter(t, db)
+
+	job, err := scd.CreateNew(db, models.NewJob("job-1", "Engineer", "company-acme", "contractor-alice", money.FromMajor(50, money.USD)))
+	require.NoError(t, err)
+	start := time.Date(2025, 1, 6, 9, 0, 0, 0, time.UTC)
+	timelog, err := scd.CreateNew(db, models.NewTimelog("timelog-1", job.UID, start, start.Add(2*time.Hour)))
+	require.NoError(t, err)
+	_, err = scd.CreateNew(db, models.NewCalculatedPaymentLineItem("payment-1", job, timelog))
+	require.NoError(t, err)
+	code, body := send(t, router, http.MethodPost, "/payout-runs", `{"id":"run-1","contractor_id":"contractor-alice","period_start":"2025-01-06T00:00:00Z","period_end":"2025-01-13T00:00:00Z"}`)
+	require.Equal(t, http.StatusCreated, code, body)
+
+	code, body = send(t, router, http.MethodPost, "/payout-runs/run-1/poll", "")
+	assert.Equal(t, http.StatusConflict, code, body)
+	code, body = send(t, router, http.MethodPost, "/payout-runs/run-1/process", "")
+	require.Equal(t, http.StatusConflict, code, body)
+	assert.Equal(t, []interface{}{"approved"}, body["allowed"], "Drafts must be approved before processing")
+
+	code, body = send(t, router, http.MethodPost, "/payout-runs/run-1/approve", "")
+	require.Equal(t, http.StatusOK, code, body)
+	code, body = send(t, router, http.MethodPost, "/payout-runs/run-1/process", "")
+	require.Equal(t, http.StatusOK, code, body)
+	assert.Equal(t, "settled", body["data"].(map[string]interface{})["status"])
+
+	code, items := getList(t, router, "/payout-runs/run-1/items")
+	require.Equal(t, http.StatusOK, code)
+	require.Len(t, items.Data, 1)
+	assert.Equal(t, "paid", items.Data[0]["status"])
+	assert.Equal(t, "fake", items.Data[0]["payout_provider"])
+	assert.Equal(t, "succeeded", items.Data[0]["payout_status"])
+	assert.NotEmpty(t, items.Data[0]["payout_ref"])
+
+	code, _ = send(t, router, http.MethodPost, "/payout-runs/payout-404/process", "")
+	assert.Equal(t, http.StatusNotFound, code)
+}
+
+// TestExportPaymentsEndpoint tests exporting a NACHA file and rejecting a second export of the same items
+func TestExportPaymentsEndpoint(t *testing.T) {
+	db := setupTestDB(t)
+	router := newTestRouter(t, db)
+
+	job, err := scd.CreateNew(db, models.NewJob("job-1", "Engineer", "company-acme", "contractor-alice", money.FromMajor(50, money.USD)))
+	require.NoError(t, err)
+	start := time.Date(2025, 1, 6, 9, 0, 0, 0, time.UTC)
+	timelog, err := scd.CreateNew(db, models.NewTimelog("timelog-1", job.UID, start, start.Add(2*time.Hour)))
+	require.NoError(t, err)
+	_, err = scd.CreateNew(db, models.NewCalculatedPaymentLineItem("payment-1", job, timelog))
+	require.NoError(t, err)
+
+	request := `{"file_id":"ach-1","format":"nacha","payment_ids":["payment-1"],"originator":{"name":"Acme Payroll","routing_number":"021000021","company_id":"1234567890"}}`
+	code, body := send(t, router, http.MethodPost, "/payments/export", request)
+	require.Equal(t, http.StatusUnprocessableEntity, code, body)
+	assert.Contains(t, body["error"], "no bank account")
+
+	code, body = send(t, router, http.MethodPost, "/bank-accounts", `{"id":"bank-contractor-alice","contractor_id":"contractor-alice","holder_name":"Alice Smith","routing_number":"011000015","account_number":"12345678"}`)
+	require.Equal(t, http.StatusCreated, code, body)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/payments/export", strings.NewReader(request)))
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	assert.Equal(t, "ach-1", w.Header().Get("X-Export-File-ID"))
+	assert.Equal(t, "100.00", w.Header().Get("X-Export-Total"))
+	assert.Equal(t, `attachment; filename="ach-1.ach"`, w.Header().Get("Content-Disposition"))
+	assert.True(t, strings.HasPrefix(w.Body.String(), "101 021000021"))
+
+	code, body = send(t, router, http.MethodPost, "/payments/export", strings.Replace(request, "ach-1", "ach-2", 1))
+	assert.Equal(t, http.StatusConflict, code, body)
+
+	code, body = send(t, router, http.MethodPost, "/payments/export", `{"format":"pain.001","currency":"USD","payment_ids":["payment-1"],"originator":{"name":"Acme"}}`)
+	assert.Equal(t, http.StatusConflict, code, body, "Already exported")
+	code, body = send(t, router, http.MethodPost, "/payments/export", `{"format":"swift","originator":{"name":"Acme"}}`)
+	assert.Equal(t, http.StatusBadRequest, code, body)
+}
@@ -1,10 +1,13 @@
 package main
 
 import (
+	"context"
 	"log"
 	"os"
+	"time"
 
 	"github.com/abhi14nexu/mercor-scd/internal/models"
+	"github.com/abhi14nexu/mercor-scd/internal/payout"
 	"github.com/gin-gonic/gin"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
@@ -14,7 +17,7 @@ import (
 func corsMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		c.Header("Access-Control-Allow-Origin", "*")
-		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
 		c.Header("Access-Control-Allow-Headers", "Origin, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization")
 
 		if c.Request.Method == "OPTIONS" {
@@ -46,6 +49,18 @@ func main() {
 
 	log.Println("✅ Connected to PostgreSQL database")
 
+	repos, err := newRepositories(db)
+	if err != nil {
+		log.Fatalf("Failed to create repositories: %v", err)
+	}
+
+	// Deliver webhook notifications for new versions in the background
+	go newWebhookDispatcher(db).Run(context.Background())
+
+	// Pay payout runs through the in-process fake until a real provider is configured;
+	// its payouts stay pending for a while so polling can be exercised
+	payouts := payout.NewProcessor(db, payout.NewFake(payout.FakeOptions{Delay: 30 * time.Second}))
+
 	// Create Gin router
 	router := gin.Default()
 
@@ -56,19 +71,89 @@ func main() {
 	api := router.Group("/api/v1")
 	{
 		// Jobs endpoints
-		api.GET("/jobs", getJobs(db))
-		api.GET("/jobs/:id", getJob(db))
-		api.GET("/jobs/:id/versions", getJobVersions(db))
+		api.GET("/jobs", getJobs(repos))
+		api.GET("/jobs/:id", getEntity(repos.jobs, "Job not found"))
+		api.GET("/jobs/:id/versions", getVersions(repos.jobs, "Job not found"))
+		api.GET("/jobs/:id/timeline", getTimeline(repos.jobs, "Job not found"))
+		api.GET("/jobs/:id/transitions", getTransitions(repos.jobs, "Job not found"))
+		api.GET("/jobs/:id/pay-period", payPeriod(db, false))
+		api.POST("/jobs/:id/pay-period", payPeriod(db, true))
+		api.POST("/jobs", createEntity(repos.jobs, jobWrites))
+		api.PATCH("/jobs/:id", updateEntity(repos.jobs, jobWrites, "Job not found"))
+		api.DELETE("/jobs/:id", deleteEntity(repos.jobs, "Job not found"))
 
 		// Payment line items endpoints
-		api.GET("/payments", getPayments(db))
-		api.GET("/payments/:id", getPayment(db))
-		api.GET("/payments/:id/versions", getPaymentVersions(db))
+		api.GET("/payments", getPayments(repos))
+		api.GET("/payments/:id", getEntity(repos.payments, "Payment not found"))
+		api.GET("/payments/:id/versions", getVersions(repos.payments, "Payment not found"))
+		api.GET("/payments/:id/timeline", getTimeline(repos.payments, "Payment not found"))
+		api.GET("/payments/:id/transitions", getTransitions(repos.payments, "Payment not found"))
+		api.POST("/payments", createEntity(repos.payments, paymentWrites))
+		api.PATCH("/payments/:id", updateEntity(repos.payments, paymentWrites, "Payment not found"))
+		api.DELETE("/payments/:id", deleteEntity(repos.payments, "Payment not found"))
+		api.POST("/payments/export", exportPayments(db))
 
 		// Timelogs endpoints
-		api.GET("/timelogs", getTimelogs(db))
-		api.GET("/timelogs/:id", getTimelog(db))
-		api.GET("/timelogs/:id/versions", getTimelogVersions(db))
+		api.GET("/timelogs", getTimelogs(repos))
+		api.GET("/timelogs/:id", getEntity(repos.timelogs, "Timelog not found"))
+		api.GET("/timelogs/:id/versions", getVersions(repos.timelogs, "Timelog not found"))
+		api.GET("/timelogs/:id/timeline", getTimeline(repos.timelogs, "Timelog not found"))
+		api.POST("/timelogs", createEntity(repos.timelogs, timelogWrites))
+		api.PATCH("/timelogs/:id", updateEntity(repos.timelogs, timelogWrites, "Timelog not found"))
+		api.DELETE("/timelogs/:id", deleteEntity(repos.timelogs, "Timelog not found"))
+
+		// Exchange rate endpoints; as_of on /fx-rates/:id returns the rate valid at that time
+		api.GET("/fx-rates", getFXRates(repos))
+		api.GET("/fx-rates/:id", getEntity(repos.fxRates, "Exchange rate not found"))
+		api.GET("/fx-rates/:id/versions", getVersions(repos.fxRates, "Exchange rate not found"))
+		api.GET("/fx-rates/:id/timeline", getTimeline(repos.fxRates, "Exchange rate not found"))
+		api.POST("/fx-rates", createEntity(repos.fxRates, fxRateWrites))
+		api.PATCH("/fx-rates/:id", updateEntity(repos.fxRates, fxRateWrites, "Exchange rate not found"))
+
+		// Rate rule endpoints; one rule entity per job, e.g. rules-job-1
+		api.GET("/rate-rules", getRateRules(repos))
+		api.GET("/rate-rules/:id", getEntity(repos.rateRules, "Rate rules not found"))
+		api.GET("/rate-rules/:id/versions", getVersions(repos.rateRules, "Rate rules not found"))
+		api.GET("/rate-rules/:id/timeline", getTimeline(repos.rateRules, "Rate rules not found"))
+		api.POST("/rate-rules", createEntity(repos.rateRules, rateRuleWrites))
+		api.PATCH("/rate-rules/:id", updateEntity(repos.rateRules, rateRuleWrites, "Rate rules not found"))
+
+		// Payout runs; each status change moves the run and all of its line items at once
+		api.GET("/payout-runs", getPayoutRuns(repos))
+		api.GET("/payout-runs/:id", getEntity(repos.payoutRuns, "Payout run not found"))
+		api.GET("/payout-runs/:id/versions", getVersions(repos.payoutRuns, "Payout run not found"))
+		api.GET("/payout-runs/:id/transitions", getTransitions(repos.payoutRuns, "Payout run not found"))
+		api.GET("/payout-runs/:id/items", getPayoutRunItems(db))
+		api.POST("/payout-runs", createPayoutRun(db))
+		api.POST("/payout-runs/:id/approve", transitionPayoutRun(db, models.ApprovePayoutRun))
+		api.POST("/payout-runs/:id/submit", transitionPayoutRun(db, models.SubmitPayoutRun))
+		api.POST("/payout-runs/:id/settle", transitionPayoutRun(db, models.SettlePayoutRun))
+		api.POST("/payout-runs/:id/fail", transitionPayoutRun(db, models.FailPayoutRun))
+		api.POST("/payout-runs/:id/process", processPayoutRun(payouts.Submit))
+		api.POST("/payout-runs/:id/poll", processPayoutRun(payouts.Poll))
+		api.POST("/payout-runs/:id/cancel", processPayoutRun(payouts.Cancel))
+
+		// Contractor bank accounts; one account entity per contractor, e.g. bank-contractor-alice
+		api.GET("/bank-accounts", getBankAccounts(repos))
+		api.GET("/bank-accounts/:id", getEntity(repos.bankAccounts, "Bank account not found"))
+		api.GET("/bank-accounts/:id/versions", getVersions(repos.bankAccounts, "Bank account not found"))
+		api.GET("/bank-accounts/:id/timeline", getTimeline(repos.bankAccounts, "Bank account not found"))
+		api.POST("/bank-accounts", createEntity(repos.bankAccounts, bankAccountWrites))
+		api.PATCH("/bank-accounts/:id", updateEntity(repos.bankAccounts, bankAccountWrites, "Bank account not found"))
+
+		// Reports
+		api.GET("/reports/stale-refs", getStaleRefs(db))
+
+		// Change feed (Server-Sent Events)
+		api.GET("/changes/stream", streamChanges(db))
+
+		// Webhook subscriptions and delivery log
+		api.POST("/webhooks", createWebhook(db))
+		api.GET("/webhooks", getWebhooks(db))
+		api.GET("/webhooks/:id", getWebhook(db))
+		api.PATCH("/webhooks/:id", updateWebhook(db))
+		api.DELETE("/webhooks/:id", deleteWebhook(db))
+		api.GET("/webhooks/:id/deliveries", getWebhookDeliveries(db))
 
 		// Health check
 		api.GET("/health", func(c *gin.Context) {
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/abhi14nexu/mercor-scd/internal/models"
+	"github.com/abhi14nexu/mercor-scd/internal/money"
+	"github.com/abhi14nexu/mercor-scd/internal/payout"
+	"github.com/abhi14nexu/mercor-scd/internal/scd"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// payoutRunRequest is the body accepted when creating a payout run
+type payoutRunRequest struct {
+	ID           string         `json:"id"` // defaults to models.PayoutRunID
+	ContractorID string         `json:"contractor_id" binding:"required"`
+	PeriodStart  time.Time      `json:"period_start" binding:"required"`
+	PeriodEnd    time.Time      `json:"period_end" binding:"required"`
+	Currency     money.Currency `json:"currency"` // defaults to money.DefaultCurrency
+}
+
+// getPayoutRuns returns all latest payout run versions, optionally filtered by contractor and status
+func getPayoutRuns(repos *repositories) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		listLatest(c, repos.payoutRuns, scd.ListOptions{
+			Filters: queryFilters(c, map[string]string{"contractor": "contractor_id", "status": "status"}),
+		})
+	}
+}
+
+// createPayoutRun creates a draft run of a contractor's payable line items for a period
+func createPayoutRun(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req payoutRunRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if !req.PeriodEnd.After(req.PeriodStart) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "period_end must be after period_start"})
+			return
+		}
+		if req.Currency == "" {
+			req.Currency = money.DefaultCurrency
+		}
+		if req.ID == "" {
+			req.ID = models.PayoutRunID(req.ContractorID, req.PeriodStart, req.Currency)
+		}
+
+		period := models.PayPeriod{Start: req.PeriodStart, End: req.PeriodEnd}
+		run, err := models.CreatePayoutRun(db, req.ID, req.ContractorID, period, req.Currency)
+		if err != nil {
+			switch {
+			case errors.Is(err, scd.ErrAlreadyExists):
+				c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			case errors.Is(err, models.ErrEmptyPayoutRun):
+				c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+			default:
+				writeError(c, err)
+			}
+			return
+		}
+
+		c.JSON(http.StatusCreated, gin.H{"data": run})
+	}
+}
+
+// getPayoutRunItems returns the current versions of a run's member line items
+func getPayoutRunItems(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		items, err := models.PayoutRunItems(db, c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"data": items, "count": len(items)})
+	}
+}
+
+// transitionPayoutRun moves a run to its next status with one of the models lifecycle functions
+// Disallowed moves are answered with 409 and the allowed statuses, like PATCH on other entities.
+func transitionPayoutRun(db *gorm.DB, transition func(*gorm.DB, string) (*models.PayoutRun, error)) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		run, err := transition(db, c.Param("id"))
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Payout run not found"})
+				return
+			}
+			writeError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"data": run})
+	}
+}
+
+// processPayoutRun submits, polls or cancels a run at the payout provider with one of the payout.Processor methods
+// Runs that were never submitted are answered with 409.
+func processPayoutRun(process func(context.Context, string) (*models.PayoutRun, error)) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		run, err := process(c.Request.Context(), c.Param("id"))
+		if err != nil {
+			switch {
+			case errors.Is(err, gorm.ErrRecordNotFound):
+				c.JSON(http.StatusNotFound, gin.H{"error": "Payout run not found"})
+			case errors.Is(err, payout.ErrNotSubmitted):
+				c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			default:
+				writeError(c, err)
+			}
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"data": run})
+	}
+}
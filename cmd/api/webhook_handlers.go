@@ -0,0 +1,210 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// webhookRequest is the body accepted when creating or updating a subscription
+type webhookRequest struct {
+	URL     *string   `json:"url"`
+	Filters *[]string `json:"filters"`
+	Secret  *string   `json:"secret"`
+	Active  *bool     `json:"active"`
+}
+
+// apply validates the request and copies the provided fields onto the subscription
+func (r webhookRequest) apply(sub *webhookSubscription) error {
+	if r.URL != nil {
+		u, err := url.Parse(*r.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return errors.New("url must be an absolute http(s) URL")
+		}
+		sub.URL = *r.URL
+	}
+	if r.Filters != nil {
+		for _, raw := range *r.Filters {
+			if _, err := parseWebhookFilter(raw); err != nil {
+				return err
+			}
+		}
+		sub.Filters = *r.Filters
+	}
+	if r.Secret != nil {
+		if *r.Secret == "" {
+			return errors.New("secret cannot be empty")
+		}
+		sub.Secret = *r.Secret
+	}
+	if r.Active != nil {
+		sub.Active = *r.Active
+	}
+	return nil
+}
+
+// findWebhook loads a subscription by the :id path parameter, writing 400/404 responses itself
+func findWebhook(c *gin.Context, db *gorm.DB) (*webhookSubscription, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid webhook ID"})
+		return nil, false
+	}
+
+	var sub webhookSubscription
+	if err := db.First(&sub, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Webhook not found"})
+			return nil, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return nil, false
+	}
+
+	return &sub, true
+}
+
+// createWebhook registers a new subscription, generating a signing secret if none is given
+// The secret is only returned in this response
+func createWebhook(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req webhookRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if req.URL == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "url is required"})
+			return
+		}
+
+		sub := webhookSubscription{ID: uuid.New(), Active: true, Filters: []string{}}
+		if err := req.apply(&sub); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if sub.Secret == "" {
+			secret, err := newWebhookSecret()
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			sub.Secret = secret
+		}
+
+		if err := db.Create(&sub).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusCreated, gin.H{"data": sub})
+	}
+}
+
+// getWebhooks lists all subscriptions without their secrets
+func getWebhooks(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var subs []webhookSubscription
+		if err := db.Order("created_at ASC").Find(&subs).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		for i := range subs {
+			subs[i].Secret = ""
+		}
+
+		c.JSON(http.StatusOK, gin.H{"data": subs, "count": len(subs)})
+	}
+}
+
+// getWebhook returns a single subscription without its secret
+func getWebhook(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		sub, ok := findWebhook(c, db)
+		if !ok {
+			return
+		}
+
+		sub.Secret = ""
+		c.JSON(http.StatusOK, gin.H{"data": sub})
+	}
+}
+
+// updateWebhook changes the URL, filters, secret or active flag of a subscription
+func updateWebhook(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		sub, ok := findWebhook(c, db)
+		if !ok {
+			return
+		}
+
+		var req webhookRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err := req.apply(sub); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		if err := db.Save(sub).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		sub.Secret = ""
+		c.JSON(http.StatusOK, gin.H{"data": sub})
+	}
+}
+
+// deleteWebhook removes a subscription together with its delivery log
+func deleteWebhook(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		sub, ok := findWebhook(c, db)
+		if !ok {
+			return
+		}
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Where("subscription_id = ?", sub.ID).Delete(&webhookDelivery{}).Error; err != nil {
+				return err
+			}
+			return tx.Delete(sub).Error
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// getWebhookDeliveries returns the delivery log for a subscription, newest first
+func getWebhookDeliveries(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		sub, ok := findWebhook(c, db)
+		if !ok {
+			return
+		}
+
+		query := db.Where("subscription_id = ?", sub.ID)
+		if status := c.Query("status"); status != "" {
+			query = query.Where("status = ?", status)
+		}
+
+		var deliveries []webhookDelivery
+		if err := query.Order("created_at DESC").Find(&deliveries).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"data": deliveries, "count": len(deliveries)})
+	}
+}
@@ -0,0 +1,449 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/abhi14nexu/mercor-scd/internal/models"
+	"github.com/abhi14nexu/mercor-scd/internal/scd"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Delivery statuses recorded in the webhook delivery log
+const (
+	deliveryPending   = "pending"
+	deliverySucceeded = "succeeded"
+	deliveryFailed    = "failed"
+)
+
+// webhookSubscription is a registered receiver for entity change notifications
+// Filters use the form "<entity>", "<entity>.<field>" or "<entity>.<field>=<value>",
+// e.g. "payment.status=failed" fires only when a payment version changes status to failed.
+// A subscription without filters receives every change.
+type webhookSubscription struct {
+	ID        uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	URL       string    `gorm:"type:text;not null" json:"url"`
+	Filters   []string  `gorm:"type:text;serializer:json" json:"filters"`
+	Secret    string    `gorm:"type:text;not null" json:"secret,omitempty"`
+	Active    bool      `gorm:"not null;default:true" json:"active"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName specifies the table name for GORM
+func (webhookSubscription) TableName() string {
+	return "webhook_subscriptions"
+}
+
+// webhookDelivery is one row of the delivery log, tracking every attempt for an event
+type webhookDelivery struct {
+	ID             uuid.UUID  `gorm:"type:uuid;primaryKey" json:"id"`
+	SubscriptionID uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex:idx_webhook_deliveries_sub_version" json:"subscription_id"`
+	VersionUID     uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex:idx_webhook_deliveries_sub_version" json:"version_uid"`
+	EventID        string     `gorm:"type:text;not null" json:"event_id"`
+	Event          string     `gorm:"type:text;not null" json:"event"`
+	OccurredAt     time.Time  `gorm:"not null" json:"occurred_at"`
+	Payload        string     `gorm:"type:text;not null" json:"payload"`
+	Status         string     `gorm:"type:text;not null" json:"status"`
+	Attempts       int        `gorm:"not null" json:"attempts"`
+	ResponseStatus int        `json:"response_status,omitempty"`
+	LastError      string     `gorm:"type:text" json:"last_error,omitempty"`
+	NextAttemptAt  *time.Time `gorm:"index" json:"next_attempt_at,omitempty"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// TableName specifies the table name for GORM
+func (webhookDelivery) TableName() string {
+	return "webhook_deliveries"
+}
+
+// webhookPayload is the JSON body POSTed to subscribers
+type webhookPayload struct {
+	Event   string `json:"event"`
+	EventID string `json:"event_id"`
+	changeEvent
+	Changes []scd.Change `json:"changes"`
+}
+
+// webhookFilter is a parsed subscription filter
+type webhookFilter struct {
+	Entity string // job, timelog or payment
+	Field  string // optional JSON field name
+	Value  string // optional new value
+	Exact  bool   // true when a value was given
+}
+
+// parseWebhookFilter parses "<entity>[.<field>[=<value>]]"
+func parseWebhookFilter(raw string) (webhookFilter, error) {
+	var f webhookFilter
+
+	expr := strings.TrimSpace(raw)
+	if key, value, ok := strings.Cut(expr, "="); ok {
+		expr, f.Value, f.Exact = key, strings.TrimSpace(value), true
+	}
+	f.Entity, f.Field, _ = strings.Cut(strings.TrimSpace(expr), ".")
+
+	switch f.Entity {
+	case "job", "timelog", "payment":
+	default:
+		return webhookFilter{}, fmt.Errorf("invalid filter %q: entity must be job, timelog or payment", raw)
+	}
+	if f.Exact && f.Field == "" {
+		return webhookFilter{}, fmt.Errorf("invalid filter %q: a value requires a field", raw)
+	}
+
+	return f, nil
+}
+
+// matches reports whether a change notification satisfies the filter
+func (f webhookFilter) matches(payload webhookPayload) bool {
+	if payload.Event != f.Entity+".version" {
+		return false
+	}
+	if f.Field == "" {
+		return true
+	}
+	for _, change := range payload.Changes {
+		if change.Field != f.Field {
+			continue
+		}
+		return !f.Exact || fmt.Sprint(change.New) == f.Value
+	}
+	return false
+}
+
+// matchesSubscription reports whether any of the subscription's filters match
+func matchesSubscription(sub webhookSubscription, payload webhookPayload) bool {
+	if len(sub.Filters) == 0 {
+		return true
+	}
+	for _, raw := range sub.Filters {
+		f, err := parseWebhookFilter(raw)
+		if err == nil && f.matches(payload) {
+			return true
+		}
+	}
+	return false
+}
+
+// signWebhookPayload computes the hex HMAC-SHA256 of "<timestamp>.<body>"
+func signWebhookPayload(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%d.", timestamp)
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// newWebhookSecret generates a random signing secret
+func newWebhookSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(buf), nil
+}
+
+// webhookDispatcher turns SCD versions into signed webhook deliveries
+type webhookDispatcher struct {
+	db          *gorm.DB
+	client      *http.Client
+	maxAttempts int
+	baseBackoff time.Duration
+	now         func() time.Time
+	cursor      changeCursor
+
+	// sending tracks subscriptions with a sender in flight, so a slow receiver
+	// only holds up its own deliveries
+	mu      sync.Mutex
+	sending map[uuid.UUID]bool
+	senders sync.WaitGroup
+}
+
+// newWebhookDispatcher creates a dispatcher that starts from the most recent delivered event
+func newWebhookDispatcher(db *gorm.DB) *webhookDispatcher {
+	d := &webhookDispatcher{
+		db:          db,
+		client:      &http.Client{Timeout: 10 * time.Second},
+		maxAttempts: 6,
+		baseBackoff: 30 * time.Second,
+		now:         time.Now,
+		sending:     make(map[uuid.UUID]bool),
+	}
+	d.cursor = changeCursor{ValidFrom: d.now(), UID: uuid.Max}
+
+	// Resume after the last logged event so restarts don't drop changes;
+	// the unique (subscription, version) index makes replays idempotent
+	var last webhookDelivery
+	if err := db.Order("occurred_at DESC, version_uid DESC").Limit(1).Find(&last).Error; err == nil && !last.OccurredAt.IsZero() {
+		d.cursor = changeCursor{ValidFrom: last.OccurredAt, UID: last.VersionUID}
+	}
+
+	return d
+}
+
+// Run polls for new versions and due deliveries until the context is cancelled
+func (d *webhookDispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(changePollInterval)
+	defer ticker.Stop()
+	defer d.wait()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := d.processChanges(); err != nil {
+				log.Printf("webhooks: failed to process changes: %v", err)
+			}
+			if err := d.retryDue(); err != nil {
+				log.Printf("webhooks: failed to retry deliveries: %v", err)
+			}
+		}
+	}
+}
+
+// processChanges fans new versions out to matching subscriptions
+func (d *webhookDispatcher) processChanges() error {
+	events, err := loadChanges(d.db, changeFilter{EntityTypes: allEntityTypes}, d.cursor, changeBatchSize)
+	if err != nil {
+		return err
+	}
+	if len(events) == 0 {
+		return nil
+	}
+
+	var subs []webhookSubscription
+	if err := d.db.Where("active = ?", true).Find(&subs).Error; err != nil {
+		return fmt.Errorf("failed to load subscriptions: %w", err)
+	}
+
+	// A version that cannot be turned into a delivery is logged and skipped,
+	// so one bad event never holds up the feed for every subscriber
+	for _, event := range events {
+		d.cursor = event.cursor()
+		if len(subs) == 0 {
+			continue
+		}
+		payload, err := d.buildPayload(event)
+		if err != nil {
+			log.Printf("webhooks: skipping %s: %v", event.EventID(), err)
+			continue
+		}
+		for _, sub := range subs {
+			if matchesSubscription(sub, payload) {
+				if err := d.enqueue(sub, payload); err != nil {
+					log.Printf("webhooks: skipping %s for subscription %s: %v", event.EventID(), sub.ID, err)
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// buildPayload diffs the event's version against the previous version of the same entity
+func (d *webhookDispatcher) buildPayload(event changeEvent) (webhookPayload, error) {
+	prev, err := loadPreviousVersion(d.db, event)
+	if err != nil {
+		return webhookPayload{}, fmt.Errorf("failed to load previous version of %s: %w", event.BusinessID, err)
+	}
+
+	changes, err := scd.Diff(prev, event.Data)
+	if err != nil {
+		return webhookPayload{}, err
+	}
+
+	return webhookPayload{
+		Event:       event.EventName(),
+		EventID:     event.EventID(),
+		changeEvent: event,
+		Changes:     changes,
+	}, nil
+}
+
+// loadPreviousVersion returns version-1 of the event's entity, or nil for first versions
+func loadPreviousVersion(db *gorm.DB, event changeEvent) (interface{}, error) {
+	if event.Version <= 1 {
+		return nil, nil
+	}
+
+	switch event.EntityType {
+	case entityJobs:
+		return scd.GetVersion[*models.Job](db, event.BusinessID, event.Version-1)
+	case entityTimelogs:
+		return scd.GetVersion[*models.Timelog](db, event.BusinessID, event.Version-1)
+	case entityPayments:
+		return scd.GetVersion[*models.PaymentLineItem](db, event.BusinessID, event.Version-1)
+	}
+	return nil, fmt.Errorf("unknown entity type %q", event.EntityType)
+}
+
+// enqueue records a pending delivery that is due immediately; retryDue sends it
+func (d *webhookDispatcher) enqueue(sub webhookSubscription, payload webhookPayload) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode webhook payload: %w", err)
+	}
+
+	now := d.now()
+	delivery := webhookDelivery{
+		ID:             uuid.New(),
+		SubscriptionID: sub.ID,
+		VersionUID:     payload.UID,
+		EventID:        payload.EventID,
+		Event:          payload.Event,
+		OccurredAt:     payload.ValidFrom,
+		Payload:        string(body),
+		Status:         deliveryPending,
+		NextAttemptAt:  &now,
+	}
+
+	// A replayed version after a restart hits the unique index and is skipped
+	if err := d.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&delivery).Error; err != nil {
+		return fmt.Errorf("failed to record webhook delivery: %w", err)
+	}
+	return nil
+}
+
+// retryDue hands pending deliveries whose backoff has elapsed to one sender per subscription
+// Subscriptions that still have a sender in flight are picked up on a later tick.
+func (d *webhookDispatcher) retryDue() error {
+	var due []webhookDelivery
+	if err := d.db.Where("status = ? AND next_attempt_at <= ?", deliveryPending, d.now()).
+		Order("next_attempt_at ASC").
+		Limit(changeBatchSize).
+		Find(&due).Error; err != nil {
+		return fmt.Errorf("failed to load due deliveries: %w", err)
+	}
+
+	var order []uuid.UUID
+	bySub := make(map[uuid.UUID][]webhookDelivery)
+	for _, delivery := range due {
+		if _, ok := bySub[delivery.SubscriptionID]; !ok {
+			order = append(order, delivery.SubscriptionID)
+		}
+		bySub[delivery.SubscriptionID] = append(bySub[delivery.SubscriptionID], delivery)
+	}
+
+	for _, subID := range order {
+		if !d.claim(subID) {
+			continue
+		}
+		var sub webhookSubscription
+		if err := d.db.First(&sub, "id = ?", subID).Error; err != nil {
+			d.release(subID)
+			return fmt.Errorf("failed to load subscription %s: %w", subID, err)
+		}
+
+		d.senders.Add(1)
+		go func(sub webhookSubscription, deliveries []webhookDelivery) {
+			defer d.senders.Done()
+			defer d.release(sub.ID)
+			for i := range deliveries {
+				if err := d.attempt(sub, &deliveries[i]); err != nil {
+					log.Printf("webhooks: %v", err)
+					return
+				}
+			}
+		}(sub, bySub[subID])
+	}
+
+	return nil
+}
+
+// claim marks a subscription as having a sender in flight, reporting false if one already is
+func (d *webhookDispatcher) claim(subID uuid.UUID) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.sending[subID] {
+		return false
+	}
+	d.sending[subID] = true
+	return true
+}
+
+// release clears a subscription's in-flight sender
+func (d *webhookDispatcher) release(subID uuid.UUID) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.sending, subID)
+}
+
+// wait blocks until every in-flight sender has finished
+func (d *webhookDispatcher) wait() {
+	d.senders.Wait()
+}
+
+// attempt POSTs the delivery and records the outcome with exponential backoff on failure
+func (d *webhookDispatcher) attempt(sub webhookSubscription, delivery *webhookDelivery) error {
+	delivery.Attempts++
+	statusCode, sendErr := d.send(sub, delivery)
+	delivery.ResponseStatus = statusCode
+
+	now := d.now()
+	switch {
+	case sendErr == nil:
+		delivery.Status = deliverySucceeded
+		delivery.LastError = ""
+		delivery.DeliveredAt = &now
+		delivery.NextAttemptAt = nil
+	case delivery.Attempts >= d.maxAttempts || !sub.Active:
+		delivery.Status = deliveryFailed
+		delivery.LastError = sendErr.Error()
+		delivery.NextAttemptAt = nil
+	default:
+		next := now.Add(d.baseBackoff * time.Duration(1<<(delivery.Attempts-1)))
+		delivery.LastError = sendErr.Error()
+		delivery.NextAttemptAt = &next
+	}
+
+	if err := d.db.Save(delivery).Error; err != nil {
+		return fmt.Errorf("failed to update webhook delivery %s: %w", delivery.ID, err)
+	}
+	return nil
+}
+
+// send performs a single signed HTTP delivery
+func (d *webhookDispatcher) send(sub webhookSubscription, delivery *webhookDelivery) (int, error) {
+	body := []byte(delivery.Payload)
+	timestamp := d.now().Unix()
+
+	req, err := http.NewRequest(http.MethodPost, sub.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Webhook-Event", delivery.Event)
+	req.Header.Set("X-Webhook-Delivery", delivery.ID.String())
+	req.Header.Set("X-Webhook-Timestamp", strconv.FormatInt(timestamp, 10))
+	req.Header.Set("X-Webhook-Signature", "sha256="+signWebhookPayload(sub.Secret, timestamp, body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body) //nolint:errcheck
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("receiver responded with %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,288 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/abhi14nexu/mercor-scd/internal/models"
+	"github.com/abhi14nexu/mercor-scd/internal/money"
+	"github.com/abhi14nexu/mercor-scd/internal/scd"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// receivedWebhook is a request captured by the test receiver
+type receivedWebhook struct {
+	Header http.Header
+	Body   []byte
+}
+
+// webhookReceiver is an httptest server that records requests and replies with scripted status codes
+type webhookReceiver struct {
+	*httptest.Server
+	mu       sync.Mutex
+	requests []receivedWebhook
+	statuses []int
+}
+
+func newWebhookReceiver(t *testing.T, statuses ...int) *webhookReceiver {
+	r := &webhookReceiver{statuses: statuses}
+	r.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.requests = append(r.requests, receivedWebhook{Header: req.Header.Clone(), Body: body})
+
+		status := http.StatusOK
+		if len(r.statuses) > 0 {
+			status, r.statuses = r.statuses[0], r.statuses[1:]
+		}
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(r.Close)
+	return r
+}
+
+func (r *webhookReceiver) received() []receivedWebhook {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]receivedWebhook(nil), r.requests...)
+}
+
+// setupWebhookTestDB creates a test database that also has the webhook tables
+func setupWebhookTestDB(t *testing.T) *gorm.DB {
+	db := setupTestDB(t)
+	require.NoError(t, db.AutoMigrate(&webhookSubscription{}, &webhookDelivery{}))
+	return db
+}
+
+// deliverPending enqueues new versions and waits for the resulting sends
+func deliverPending(t *testing.T, dispatcher *webhookDispatcher) {
+	require.NoError(t, dispatcher.processChanges())
+	require.NoError(t, dispatcher.retryDue())
+	dispatcher.wait()
+}
+
+// createFailedPayment creates a payment and moves it to failed, returning the failed version
+func createFailedPayment(t *testing.T, db *gorm.DB, businessID string) *models.PaymentLineItem {
+	payment := models.NewPaymentLineItem(businessID, uuid.New(), uuid.New(), money.FromMajor(120, money.USD))
+	_, err := scd.CreateNew(db, payment)
+	require.NoError(t, err)
+
+	failed, err := scd.Update(db, businessID, func(p *models.PaymentLineItem) {
+		p.MarkFailed()
+	})
+	require.NoError(t, err)
+	return failed
+}
+
+func TestWebhookDeliversSignedDiff(t *testing.T) {
+	db := setupWebhookTestDB(t)
+	receiver := newWebhookReceiver(t)
+
+	sub := webhookSubscription{
+		ID:      uuid.New(),
+		URL:     receiver.URL,
+		Filters: []string{"payment.status=failed"},
+		Secret:  "test-secret",
+		Active:  true,
+	}
+	require.NoError(t, db.Create(&sub).Error)
+
+	dispatcher := newWebhookDispatcher(db)
+	dispatcher.cursor = changeCursor{ValidFrom: time.Now().Add(-time.Minute)}
+
+	failed := createFailedPayment(t, db, "payment-webhook-1")
+	deliverPending(t, dispatcher)
+
+	// Only the failed version matches; the not-paid creation does not
+	requests := receiver.received()
+	require.Len(t, requests, 1, "Exactly one matching version should be delivered")
+
+	req := requests[0]
+	assert.Equal(t, "payment.version", req.Header.Get("X-Webhook-Event"))
+
+	timestamp, err := strconv.ParseInt(req.Header.Get("X-Webhook-Timestamp"), 10, 64)
+	require.NoError(t, err)
+	assert.Equal(t, "sha256="+signWebhookPayload("test-secret", timestamp, req.Body), req.Header.Get("X-Webhook-Signature"),
+		"Signature should be the HMAC of the timestamped body")
+
+	var payload struct {
+		Event   string       `json:"event"`
+		ID      string       `json:"id"`
+		Version int          `json:"version"`
+		UID     uuid.UUID    `json:"uid"`
+		Changes []scd.Change `json:"changes"`
+	}
+	require.NoError(t, json.Unmarshal(req.Body, &payload))
+	assert.Equal(t, "payment-webhook-1", payload.ID)
+	assert.Equal(t, 2, payload.Version)
+	assert.Equal(t, failed.GetUID(), payload.UID)
+	require.Len(t, payload.Changes, 1)
+	assert.Equal(t, scd.Change{Field: "status", Old: "not-paid", New: "failed"}, payload.Changes[0])
+
+	var delivery webhookDelivery
+	require.NoError(t, db.First(&delivery, "subscription_id = ?", sub.ID).Error)
+	assert.Equal(t, deliverySucceeded, delivery.Status)
+	assert.Equal(t, 1, delivery.Attempts)
+
+	// Re-processing the same versions must not deliver twice
+	dispatcher.cursor = changeCursor{ValidFrom: time.Now().Add(-time.Minute)}
+	deliverPending(t, dispatcher)
+	assert.Len(t, receiver.received(), 1, "Replayed versions should be deduplicated")
+}
+
+func TestWebhookRetriesWithBackoff(t *testing.T) {
+	db := setupWebhookTestDB(t)
+	receiver := newWebhookReceiver(t, http.StatusInternalServerError, http.StatusBadGateway, http.StatusOK)
+
+	sub := webhookSubscription{ID: uuid.New(), URL: receiver.URL, Filters: []string{"payment.status=failed"}, Secret: "s", Active: true}
+	require.NoError(t, db.Create(&sub).Error)
+
+	clock := time.Now()
+	dispatcher := newWebhookDispatcher(db)
+	dispatcher.cursor = changeCursor{ValidFrom: clock.Add(-time.Minute)}
+	dispatcher.baseBackoff = time.Minute
+	dispatcher.now = func() time.Time { return clock }
+
+	createFailedPayment(t, db, "payment-webhook-2")
+	deliverPending(t, dispatcher)
+
+	var delivery webhookDelivery
+	require.NoError(t, db.First(&delivery, "subscription_id = ?", sub.ID).Error)
+	assert.Equal(t, deliveryPending, delivery.Status)
+	assert.Equal(t, 1, delivery.Attempts)
+	assert.Equal(t, http.StatusInternalServerError, delivery.ResponseStatus)
+	require.NotNil(t, delivery.NextAttemptAt)
+	assert.WithinDuration(t, clock.Add(time.Minute), *delivery.NextAttemptAt, time.Second, "First retry after one backoff unit")
+
+	// Not due yet
+	require.NoError(t, dispatcher.retryDue())
+	dispatcher.wait()
+	assert.Len(t, receiver.received(), 1)
+
+	// Second attempt fails again and doubles the backoff
+	clock = clock.Add(time.Minute)
+	require.NoError(t, dispatcher.retryDue())
+	dispatcher.wait()
+	require.NoError(t, db.First(&delivery, "id = ?", delivery.ID).Error)
+	assert.Equal(t, 2, delivery.Attempts)
+	assert.WithinDuration(t, clock.Add(2*time.Minute), *delivery.NextAttemptAt, time.Second, "Backoff should double")
+
+	// Third attempt succeeds
+	clock = clock.Add(2 * time.Minute)
+	require.NoError(t, dispatcher.retryDue())
+	dispatcher.wait()
+	var delivered webhookDelivery
+	require.NoError(t, db.First(&delivered, "id = ?", delivery.ID).Error)
+	assert.Equal(t, deliverySucceeded, delivered.Status)
+	assert.Equal(t, 3, delivered.Attempts)
+	assert.Nil(t, delivered.NextAttemptAt)
+	assert.NotNil(t, delivered.DeliveredAt)
+	assert.Len(t, receiver.received(), 3)
+}
+
+func TestWebhookSlowSubscriberDoesNotBlockOthers(t *testing.T) {
+	db := setupWebhookTestDB(t)
+	receiver := newWebhookReceiver(t)
+
+	release := make(chan struct{})
+	hanging := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		<-release
+	}))
+	defer hanging.Close()
+	defer close(release)
+
+	slow := webhookSubscription{ID: uuid.New(), URL: hanging.URL, Secret: "s", Active: true}
+	fast := webhookSubscription{ID: uuid.New(), URL: receiver.URL, Secret: "s", Active: true}
+	require.NoError(t, db.Create(&slow).Error)
+	require.NoError(t, db.Create(&fast).Error)
+
+	dispatcher := newWebhookDispatcher(db)
+	dispatcher.cursor = changeCursor{ValidFrom: time.Now().Add(-time.Minute)}
+
+	createFailedPayment(t, db, "payment-webhook-3")
+	require.NoError(t, dispatcher.processChanges(), "Enqueueing must not wait on receivers")
+	require.NoError(t, dispatcher.retryDue())
+
+	assert.Eventually(t, func() bool { return len(receiver.received()) == 2 }, 5*time.Second, 10*time.Millisecond,
+		"The responsive subscriber should get both versions while the other hangs")
+
+	// The hanging subscription is still in flight, so a later tick does not start a second sender for it
+	assert.False(t, dispatcher.claim(slow.ID))
+}
+
+func TestWebhookDispatcherResumesFromLastDelivery(t *testing.T) {
+	db := setupWebhookTestDB(t)
+	sub := webhookSubscription{ID: uuid.New(), URL: "http://127.0.0.1:0", Secret: "s", Active: true}
+	require.NoError(t, db.Create(&sub).Error)
+
+	ids := []string{"job-1", "job-2", "job-3"}
+	createJobsTogether(t, db, ids...)
+
+	// Log a delivery for only the first version of the shared timestamp, as if the
+	// dispatcher stopped mid-group
+	events, err := loadChanges(db, changeFilter{EntityTypes: allEntityTypes}, changeCursor{ValidFrom: time.Now().Add(-time.Minute)}, 1)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	first := events[0]
+	require.NoError(t, db.Create(&webhookDelivery{
+		ID: uuid.New(), SubscriptionID: sub.ID, VersionUID: first.UID, EventID: first.EventID(),
+		Event: first.EventName(), OccurredAt: first.ValidFrom, Payload: "{}", Status: deliverySucceeded,
+	}).Error)
+
+	dispatcher := newWebhookDispatcher(db)
+	assert.Equal(t, first.cursor(), dispatcher.cursor)
+
+	require.NoError(t, dispatcher.processChanges())
+	var deliveries []webhookDelivery
+	require.NoError(t, db.Find(&deliveries).Error)
+	assert.Len(t, deliveries, len(ids), "Versions sharing the last delivered timestamp must still be enqueued")
+}
+
+func TestWebhookSkipsEventsThatCannotBeDiffed(t *testing.T) {
+	db := setupWebhookTestDB(t)
+	receiver := newWebhookReceiver(t)
+	sub := webhookSubscription{ID: uuid.New(), URL: receiver.URL, Filters: []string{"payment.status=failed"}, Secret: "s", Active: true}
+	require.NoError(t, db.Create(&sub).Error)
+
+	dispatcher := newWebhookDispatcher(db)
+	dispatcher.cursor = changeCursor{ValidFrom: time.Now().Add(-time.Minute)}
+
+	// Without version 1 the failed version has nothing to diff against
+	createFailedPayment(t, db, "payment-broken")
+	require.NoError(t, db.Exec("DELETE FROM payment_line_items WHERE id = ? AND version = 1", "payment-broken").Error)
+	good := createFailedPayment(t, db, "payment-good")
+
+	deliverPending(t, dispatcher)
+	requests := receiver.received()
+	require.Len(t, requests, 1, "Events after the broken one must still be delivered")
+	assert.Contains(t, string(requests[0].Body), `"id":"payment-good"`)
+	assert.Equal(t, good.GetUID(), dispatcher.cursor.UID, "The cursor moves past the broken event")
+
+	require.NoError(t, dispatcher.processChanges())
+	var count int64
+	require.NoError(t, db.Model(&webhookDelivery{}).Count(&count).Error)
+	assert.Equal(t, int64(1), count)
+}
+
+func TestParseWebhookFilter(t *testing.T) {
+	f, err := parseWebhookFilter("job.status=paused")
+	require.NoError(t, err)
+	assert.Equal(t, webhookFilter{Entity: "job", Field: "status", Value: "paused", Exact: true}, f)
+
+	_, err = parseWebhookFilter("invoice.status=paid")
+	assert.Error(t, err, "Unknown entity should be rejected")
+
+	_, err = parseWebhookFilter("job=paused")
+	assert.Error(t, err, "Value without a field should be rejected")
+}
@@ -0,0 +1,252 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"slices"
+	"sort"
+
+	"github.com/abhi14nexu/mercor-scd/internal/models"
+	"github.com/abhi14nexu/mercor-scd/internal/scd"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// writableFields lists the JSON fields clients may send when creating and updating a resource
+// Everything else is rejected: SCD bookkeeping, fields owned by payout runs and bank exports, and
+// preloaded relations. The business ID is accepted on create only.
+type writableFields struct {
+	create []string
+	update []string
+	refs   map[string]scd.SCDModel // fields holding a version UID of the given model, checked to exist
+
+	// recalculate re-prices the line items of an updated entity, see models.RecalculateForJob
+	recalculate func(tx *gorm.DB, businessID string) (*models.RecalculationReport, error)
+}
+
+// Writable fields of each resource
+var (
+	jobWrites = writableFields{
+		create:      []string{"title", "company_id", "contractor_id", "status", "rate", "currency"},
+		update:      []string{"title", "company_id", "contractor_id", "status", "rate", "currency"},
+		recalculate: models.RecalculateForJob,
+	}
+	timelogWrites = writableFields{
+		create:      []string{"job_uid", "duration", "time_start", "time_end", "type"},
+		update:      []string{"job_uid", "duration", "time_start", "time_end", "type"},
+		refs:        map[string]scd.SCDModel{"job_uid": &models.Job{}},
+		recalculate: models.RecalculateForTimelog,
+	}
+	// Amounts and references are fixed once a line item exists; re-pricing goes through recalculation
+	paymentWrites = writableFields{
+		create: []string{"job_uid", "timelog_uid", "amount", "status", "currency"},
+		update: []string{"status"},
+		refs:   map[string]scd.SCDModel{"job_uid": &models.Job{}, "timelog_uid": &models.Timelog{}},
+	}
+	fxRateWrites = writableFields{
+		create: []string{"base", "quote", "rate"},
+		update: []string{"base", "quote", "rate"},
+	}
+	rateRuleWrites = writableFields{
+		create: []string{"job_id", "rounding_minutes", "minimum_minutes", "daily_cap_minutes", "weekly_overtime_after_minutes", "overtime_percent"},
+		update: []string{"rounding_minutes", "minimum_minutes", "daily_cap_minutes", "weekly_overtime_after_minutes", "overtime_percent"},
+	}
+	bankAccountWrites = writableFields{
+		create: []string{"contractor_id", "holder_name", "iban", "bic", "routing_number", "account_number", "account_type"},
+		update: []string{"holder_name", "iban", "bic", "routing_number", "account_number", "account_type"},
+	}
+)
+
+// writeError writes 422 for validation failures, listing every failing field, 409 for disallowed
+// status transitions, listing the allowed ones, and 500 otherwise
+func writeError(c *gin.Context, err error) {
+	var invalid *scd.ValidationError
+	if errors.As(err, &invalid) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "validation failed", "fields": invalid.Fields})
+		return
+	}
+	var transition *scd.TransitionError
+	if errors.As(err, &transition) {
+		c.JSON(http.StatusConflict, gin.H{"error": transition.Error(), "from": transition.From, "to": transition.To, "allowed": transition.Allowed})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
+
+// decodeBody reads a JSON object body into dest, rejecting any field not in allowed
+// It returns the raw fields so references can be checked.
+func decodeBody(c *gin.Context, dest interface{}, allowed []string) (map[string]json.RawMessage, error) {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var keys map[string]json.RawMessage
+	if err := json.Unmarshal(body, &keys); err != nil {
+		return nil, fmt.Errorf("body must be a JSON object: %w", err)
+	}
+	names := make([]string, 0, len(keys))
+	for name := range keys {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if !slices.Contains(allowed, name) {
+			return nil, fmt.Errorf("%s cannot be set", name)
+		}
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.DisallowUnknownFields()
+	return keys, decoder.Decode(dest)
+}
+
+// checkRefs reports a validation error for referenced versions that do not exist
+// Versions are never removed, so checking before the write is enough.
+func checkRefs(db *gorm.DB, keys map[string]json.RawMessage, refs map[string]scd.SCDModel) error {
+	var fields []scd.FieldError
+	for name, model := range refs {
+		raw, ok := keys[name]
+		if !ok {
+			continue
+		}
+		var uid uuid.UUID
+		if err := json.Unmarshal(raw, &uid); err != nil {
+			return err
+		}
+		var count int64
+		if err := db.Model(model).Where("uid = ?", uid).Count(&count).Error; err != nil {
+			return fmt.Errorf("failed to check %s: %w", name, err)
+		}
+		if count == 0 {
+			fields = append(fields, scd.FieldError{Field: name, Rule: "exists", Message: fmt.Sprintf("%s %s does not exist", name, uid)})
+		}
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+	sort.Slice(fields, func(i, j int) bool { return fields[i].Field < fields[j].Field })
+	return &scd.ValidationError{Fields: fields}
+}
+
+// createEntity creates the first version of an entity from the JSON body
+func createEntity[T scd.SCDModel](repo *scd.Repository[T], fields writableFields) gin.HandlerFunc {
+	allowed := append([]string{"id"}, fields.create...)
+	return func(c *gin.Context) {
+		entity := newEntity[T]()
+		keys, err := decodeBody(c, entity, allowed)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if entity.GetBusinessID() == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "id is required"})
+			return
+		}
+		if err := checkRefs(repo.DB(), keys, fields.refs); err != nil {
+			writeError(c, err)
+			return
+		}
+		created, err := repo.Create(entity)
+		if err != nil {
+			if errors.Is(err, scd.ErrAlreadyExists) {
+				c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+				return
+			}
+			writeError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusCreated, gin.H{"data": created})
+	}
+}
+
+// updateEntity applies a partial JSON body to the latest version and stores the result as a new version
+// Fields missing from the body keep their current values.
+func updateEntity[T scd.SCDModel](repo *scd.Repository[T], fields writableFields, notFound string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		// Check the body shape once before touching the database
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		keys, err := decodeBody(c, newEntity[T](), fields.update)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err := checkRefs(repo.DB(), keys, fields.refs); err != nil {
+			writeError(c, err)
+			return
+		}
+
+		// The patch is applied to the latest version inside the update transaction,
+		// so it always builds on the version being closed. Dependent line items are
+		// re-priced in the same transaction.
+		var updated T
+		var report *models.RecalculationReport
+		err = repo.DB().Transaction(func(tx *gorm.DB) error {
+			var err error
+			updated, err = repo.WithTx(tx).Update(c.Param("id"), func(entity T) {
+				// The body was decoded successfully above
+				_ = json.Unmarshal(body, entity)
+			})
+			if err != nil || fields.recalculate == nil {
+				return err
+			}
+			report, err = fields.recalculate(tx, c.Param("id"))
+			return err
+		})
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": notFound})
+				return
+			}
+			writeError(c, err)
+			return
+		}
+
+		if report != nil {
+			c.JSON(http.StatusOK, gin.H{"data": updated, "recalculation": report})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"data": updated})
+	}
+}
+
+// deleteEntity soft deletes an entity and returns its closed last version
+func deleteEntity[T scd.SCDModel](repo *scd.Repository[T], notFound string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.Param("id")
+		if err := repo.Delete(id); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": notFound})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		closed, err := repo.Get(id, scd.ReadOptions{IncludeDeleted: true})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"data": closed})
+	}
+}
+
+// newEntity allocates the struct behind a pointer model type
+func newEntity[T scd.SCDModel]() T {
+	var zero T
+	return reflect.New(reflect.TypeOf(zero).Elem()).Interface().(T)
+}
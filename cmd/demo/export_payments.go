@@ -0,0 +1,93 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/abhi14nexu/mercor-scd/internal/bankfile"
+	"github.com/abhi14nexu/mercor-scd/internal/money"
+	"github.com/spf13/cobra"
+)
+
+// exportPaymentsCmd represents the export-payments command
+var exportPaymentsCmd = &cobra.Command{
+	Use:   "export-payments",
+	Short: "Write not-paid line items to a bank payment file (ISO 20022 pain.001 or NACHA)",
+	Long: `Writes not-paid line items with their contractors' bank accounts to a bulk payment file
+for upload to the bank. The file is validated before anything is stored; each exported item then
+gets a new version recording the file ID, so it cannot be exported again or join a payout run.
+
+Without --payment every not-paid item in the currency that is not in a payout run or another
+file is exported. The originator flags default to demo company details.
+
+Examples:
+  demo export-payments --format=nacha
+  demo export-payments --format=pain.001 --currency=EUR --out=payouts.xml
+  demo export-payments --format=nacha --payment=payment-1 --payment=payment-5`,
+	Run: runExportPayments,
+}
+
+var (
+	exportFormatFlag     string
+	exportCurrencyFlag   string
+	exportPaymentsFlag   []string
+	exportFileIDFlag     string
+	exportOutFlag        string
+	exportOriginatorFlag bankfile.Originator
+)
+
+func init() {
+	exportPaymentsCmd.Flags().StringVar(&exportFormatFlag, "format", "", "File format: pain.001 or nacha (required)")
+	exportPaymentsCmd.Flags().StringVar(&exportCurrencyFlag, "currency", "", "Currency of the items to export (default: USD for nacha, EUR for pain.001)")
+	exportPaymentsCmd.Flags().StringArrayVar(&exportPaymentsFlag, "payment", nil, "Payment ID to export, repeatable (default: every exportable item)")
+	exportPaymentsCmd.Flags().StringVar(&exportFileIDFlag, "file-id", "", "File ID (default: <format>-<timestamp>)")
+	exportPaymentsCmd.Flags().StringVar(&exportOutFlag, "out", "", "Output path (default: <file id> with the format's extension)")
+	exportPaymentsCmd.Flags().StringVar(&exportOriginatorFlag.Name, "originator-name", "Mercor Demo Inc", "Name of the paying company")
+	exportPaymentsCmd.Flags().StringVar(&exportOriginatorFlag.IBAN, "originator-iban", "DE89370400440532013000", "IBAN debited for pain.001 files")
+	exportPaymentsCmd.Flags().StringVar(&exportOriginatorFlag.BIC, "originator-bic", "COBADEFFXXX", "BIC of the debited account for pain.001 files")
+	exportPaymentsCmd.Flags().StringVar(&exportOriginatorFlag.RoutingNumber, "originator-routing", "021000021", "ABA routing number of the originating bank for NACHA files")
+	exportPaymentsCmd.Flags().StringVar(&exportOriginatorFlag.BankName, "originator-bank", "JPMorgan Chase", "Name of the originating bank for NACHA files")
+	exportPaymentsCmd.Flags().StringVar(&exportOriginatorFlag.CompanyID, "originator-company-id", "1234567890", "NACHA company identification")
+	exportPaymentsCmd.MarkFlagRequired("format")
+}
+
+func runExportPayments(cmd *cobra.Command, args []string) {
+	format, err := bankfile.ParseFormat(exportFormatFlag)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid format: %v\n", err)
+		os.Exit(1)
+	}
+
+	export, err := bankfile.ExportPayments(db, bankfile.ExportRequest{
+		FileID:     exportFileIDFlag,
+		Format:     format,
+		Currency:   money.Currency(exportCurrencyFlag),
+		PaymentIDs: exportPaymentsFlag,
+		Originator: exportOriginatorFlag,
+	})
+	var invalid *bankfile.ValidationError
+	if errors.As(err, &invalid) {
+		fmt.Fprintf(os.Stderr, "❌ %d problem(s) found, nothing was exported:\n", len(invalid.Problems))
+		for _, problem := range invalid.Problems {
+			fmt.Fprintf(os.Stderr, "• %s\n", problem)
+		}
+		os.Exit(1)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Failed to export payments: %v\n", err)
+		os.Exit(1)
+	}
+
+	out := exportOutFlag
+	if out == "" {
+		out = export.FileID + format.Extension()
+	}
+	if err := os.WriteFile(out, export.Content, 0o644); err != nil {
+		// The items already record the file; keep the content so it can be uploaded anyway
+		fmt.Fprintf(os.Stderr, "❌ Exported %s but failed to write %s: %v\n", export.FileID, out, err)
+		os.Stdout.Write(export.Content)
+		os.Exit(1)
+	}
+	fmt.Fprintf(os.Stderr, "✅ Exported %d payment(s), total %s, to %s (file ID %s)\n", len(export.Payments), export.Total.Format(), out, export.FileID)
+}
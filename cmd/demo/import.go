@@ -0,0 +1,227 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/abhi14nexu/mercor-scd/internal/models"
+	"github.com/abhi14nexu/mercor-scd/internal/scd"
+	"github.com/spf13/cobra"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// importCmd represents the import command
+var importCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Backfill historical versions with explicit validity windows",
+	Long: `Loads past versions from a CSV or JSONL file with their real valid_from/valid_to
+values, e.g. when migrating history out of spreadsheets.
+
+Versions of the same business ID must be listed oldest first and form an unbroken
+chain; only the last one may have an empty valid_to. Every row is validated before
+anything is written, and all rows are inserted in one transaction.
+
+CSV headers are column names (id, valid_from, valid_to, rate, ...). Times are RFC3339.
+
+Example:
+  demo import --model=jobs --file=jobs-2019-2024.csv --dry-run`,
+	Run: runImport,
+}
+
+var (
+	importModelFlag  string
+	importFileFlag   string
+	importFormatFlag string
+	importDryRunFlag bool
+)
+
+func init() {
+	importCmd.Flags().StringVar(&importModelFlag, "model", "", "Table to import into: jobs, timelogs, fx_rates, rate_rules, payment_line_items, payout_runs or bank_accounts (required)")
+	importCmd.Flags().StringVar(&importFileFlag, "file", "", "CSV or JSONL file to read (required)")
+	importCmd.Flags().StringVar(&importFormatFlag, "format", "", "Input format: csv or jsonl (default from the file extension)")
+	importCmd.Flags().BoolVar(&importDryRunFlag, "dry-run", false, "Validate the file without writing")
+	importCmd.MarkFlagRequired("model")
+	importCmd.MarkFlagRequired("file")
+}
+
+func runImport(cmd *cobra.Command, args []string) {
+	format := importFormatFlag
+	if format == "" {
+		format = strings.TrimPrefix(strings.ToLower(filepath.Ext(importFileFlag)), ".")
+	}
+	if format != "csv" && format != "jsonl" {
+		fmt.Fprintf(os.Stderr, "Unsupported format %q (use csv or jsonl)\n", format)
+		os.Exit(1)
+	}
+
+	fmt.Fprintf(os.Stderr, "📥 Importing %s from %s (%s)\n", importModelFlag, importFileFlag, format)
+
+	var result scd.ImportResult
+	var err error
+	switch importModelFlag {
+	case models.Job{}.TableName():
+		result, err = importFile[*models.Job](importFileFlag, format, importDryRunFlag)
+	case models.Timelog{}.TableName():
+		result, err = importFile[*models.Timelog](importFileFlag, format, importDryRunFlag)
+	case models.PaymentLineItem{}.TableName():
+		result, err = importFile[*models.PaymentLineItem](importFileFlag, format, importDryRunFlag)
+	case models.FXRate{}.TableName():
+		result, err = importFile[*models.FXRate](importFileFlag, format, importDryRunFlag)
+	case models.RateRule{}.TableName():
+		result, err = importFile[*models.RateRule](importFileFlag, format, importDryRunFlag)
+	case models.PayoutRun{}.TableName():
+		result, err = importFile[*models.PayoutRun](importFileFlag, format, importDryRunFlag)
+	case models.BankAccount{}.TableName():
+		result, err = importFile[*models.BankAccount](importFileFlag, format, importDryRunFlag)
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown model %q (use one of %s)\n", importModelFlag, strings.Join(models.TableNames(), ", "))
+		os.Exit(1)
+	}
+
+	var importErr *scd.ImportError
+	if errors.As(err, &importErr) {
+		fmt.Fprintf(os.Stderr, "❌ %d issue(s) found, nothing was written:\n", len(importErr.Issues))
+		for _, issue := range importErr.Issues {
+			fmt.Fprintf(os.Stderr, "• row %d (%s): %s\n", issue.Index+1, issue.BusinessID, issue.Message)
+		}
+		os.Exit(1)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to import: %v\n", err)
+		os.Exit(1)
+	}
+
+	if importDryRunFlag {
+		fmt.Fprintf(os.Stderr, "✅ Dry run: %d version(s) across %d entities are valid\n", result.Versions, result.Entities)
+		return
+	}
+	fmt.Fprintf(os.Stderr, "✅ Imported %d version(s) across %d entities\n", result.Versions, result.Entities)
+}
+
+// importFile reads the versions in the file and imports (or only validates) them
+func importFile[T scd.SCDModel](path, format string, dryRun bool) (scd.ImportResult, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return scd.ImportResult{}, err
+	}
+	defer f.Close()
+
+	var versions []T
+	if format == "csv" {
+		versions, err = readCSVVersions[T](f)
+	} else {
+		versions, err = readJSONLVersions[T](f)
+	}
+	if err != nil {
+		return scd.ImportResult{}, err
+	}
+
+	if dryRun {
+		if err := scd.ValidateImport(db, versions); err != nil {
+			return scd.ImportResult{}, err
+		}
+		entities := map[string]bool{}
+		for _, version := range versions {
+			entities[version.GetBusinessID()] = true
+		}
+		return scd.ImportResult{Entities: len(entities), Versions: len(versions)}, nil
+	}
+	return scd.Import(db, versions)
+}
+
+// readJSONLVersions decodes one model per line
+func readJSONLVersions[T scd.SCDModel](r io.Reader) ([]T, error) {
+	var versions []T
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		version := newModel[T]()
+		if err := json.Unmarshal(scanner.Bytes(), version); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		versions = append(versions, version)
+	}
+	return versions, scanner.Err()
+}
+
+// readCSVVersions maps CSV columns onto model fields by column name
+// Empty cells are left unset, so an empty valid_to means the version is open.
+func readCSVVersions[T scd.SCDModel](r io.Reader) ([]T, error) {
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(newModel[T]()); err != nil {
+		return nil, fmt.Errorf("failed to parse model schema: %w", err)
+	}
+
+	cr := csv.NewReader(r)
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read header: %w", err)
+	}
+	fields := make([]*schema.Field, len(header))
+	for i, name := range header {
+		field := stmt.Schema.LookUpField(strings.TrimSpace(name))
+		if field == nil || field.DBName == "" {
+			return nil, fmt.Errorf("unknown column %q for %s", name, stmt.Schema.Table)
+		}
+		fields[i] = field
+	}
+
+	var versions []T
+	for line := 2; ; line++ {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		version := newModel[T]()
+		row := reflect.ValueOf(version).Elem()
+		for i, cell := range record {
+			if cell == "" {
+				continue
+			}
+			if err := setCSVValue(fields[i], row, cell); err != nil {
+				return nil, fmt.Errorf("line %d, column %s: %w", line, fields[i].DBName, err)
+			}
+		}
+		versions = append(versions, version)
+	}
+	return versions, nil
+}
+
+// setCSVValue assigns one cell, parsing times as RFC3339 and serialized fields (e.g. JSON) as stored
+func setCSVValue(field *schema.Field, row reflect.Value, cell string) error {
+	if field.Serializer != nil {
+		return field.Serializer.Scan(context.Background(), field, row, cell)
+	}
+	if field.IndirectFieldType == reflect.TypeOf(time.Time{}) {
+		t, err := time.Parse(time.RFC3339Nano, cell)
+		if err != nil {
+			return err
+		}
+		return field.Set(context.Background(), row, t)
+	}
+	return field.Set(context.Background(), row, cell)
+}
+
+// newModel allocates the struct behind a pointer model type
+func newModel[T scd.SCDModel]() T {
+	var zero T
+	return reflect.New(reflect.TypeOf(zero).Elem()).Interface().(T)
+}
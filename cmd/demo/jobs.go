@@ -6,6 +6,7 @@ import (
 	"os"
 
 	"github.com/abhi14nexu/mercor-scd/internal/models"
+	"github.com/abhi14nexu/mercor-scd/internal/money"
 	"github.com/abhi14nexu/mercor-scd/internal/scd"
 	"github.com/spf13/cobra"
 )
@@ -59,16 +60,16 @@ func runLatestJobs(cmd *cobra.Command, args []string) {
 
 	// Create output structure with additional metadata
 	type JobOutput struct {
-		BusinessID   string  `json:"business_id"`
-		UID          string  `json:"uid"`
-		Version      int     `json:"version"`
-		Status       string  `json:"status"`
-		Rate         float64 `json:"rate"`
-		Title        string  `json:"title"`
-		CompanyID    string  `json:"company_id"`
-		ContractorID string  `json:"contractor_id"`
-		ValidFrom    string  `json:"valid_from"`
-		ValidTo      *string `json:"valid_to"`
+		BusinessID   string      `json:"business_id"`
+		UID          string      `json:"uid"`
+		Version      int         `json:"version"`
+		Status       string      `json:"status"`
+		Rate         money.Money `json:"rate"`
+		Title        string      `json:"title"`
+		CompanyID    string      `json:"company_id"`
+		ContractorID string      `json:"contractor_id"`
+		ValidFrom    string      `json:"valid_from"`
+		ValidTo      *string     `json:"valid_to"`
 	}
 
 	var output []JobOutput
@@ -110,13 +111,16 @@ func runLatestJobs(cmd *cobra.Command, args []string) {
 
 	// Status breakdown
 	statusCount := make(map[string]int)
-	var totalRate float64
+	var totalRate money.Money
 	for _, job := range jobs {
 		statusCount[job.Status]++
-		totalRate += job.Rate
+		if totalRate, err = totalRate.Add(job.Rate); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to total rates: %v\n", err)
+			os.Exit(1)
+		}
 	}
 
-	fmt.Fprintf(os.Stderr, "• Average rate: $%.2f\n", totalRate/float64(len(jobs)))
+	fmt.Fprintf(os.Stderr, "• Average rate: %s\n", totalRate.Div(int64(len(jobs)), money.HalfUp).Format())
 	fmt.Fprintf(os.Stderr, "• Status breakdown:\n")
 	for status, count := range statusCount {
 		fmt.Fprintf(os.Stderr, "  - %s: %d\n", status, count)
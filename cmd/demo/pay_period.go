@@ -0,0 +1,139 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/abhi14nexu/mercor-scd/internal/models"
+	"github.com/abhi14nexu/mercor-scd/internal/scd"
+	"github.com/spf13/cobra"
+)
+
+// payPeriodCmd represents the pay-period command
+var payPeriodCmd = &cobra.Command{
+	Use:   "pay-period",
+	Short: "Price a job's timelogs for a pay period under its rate rules",
+	Long: `Prices the latest timelogs of a job that started within a pay period, applying the
+job's current rate rules: rounding to the nearest increment, a minimum per timelog, a daily
+cap and weekly overtime. Each line item comes with an explanation of how it was priced.
+
+Timelogs that already have a payment line item are listed but not priced again; they
+still count toward daily caps and weekly overtime. Nothing is written unless --save is given.
+
+Examples:
+  demo pay-period --job=job-1 --from=2025-07-07
+  demo pay-period --job=job-1 --from=2025-07-01 --to=2025-08-01 --save
+  demo pay-period --job=job-2 --from=2025-07-07 --json`,
+	Run: runPayPeriod,
+}
+
+var (
+	payPeriodJobFlag  string
+	payPeriodFromFlag string
+	payPeriodToFlag   string
+	payPeriodSaveFlag bool
+	payPeriodJSONFlag bool
+)
+
+func init() {
+	payPeriodCmd.Flags().StringVar(&payPeriodJobFlag, "job", "", "Job ID to price (required)")
+	payPeriodCmd.Flags().StringVar(&payPeriodFromFlag, "from", "", "First day of the period, YYYY-MM-DD in UTC (required)")
+	payPeriodCmd.Flags().StringVar(&payPeriodToFlag, "to", "", "Day after the period, YYYY-MM-DD in UTC (default: one week after --from)")
+	payPeriodCmd.Flags().BoolVar(&payPeriodSaveFlag, "save", false, "Create the priced payment line items")
+	payPeriodCmd.Flags().BoolVar(&payPeriodJSONFlag, "json", false, "Print the calculation as JSON")
+	payPeriodCmd.MarkFlagRequired("job")
+	payPeriodCmd.MarkFlagRequired("from")
+}
+
+func runPayPeriod(cmd *cobra.Command, args []string) {
+	period, err := parsePayPeriod(payPeriodFromFlag, payPeriodToFlag)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid period: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Fprintf(os.Stderr, "🧮 Pricing %s from %s to %s...\n",
+		payPeriodJobFlag, period.Start.Format(time.DateOnly), period.End.Format(time.DateOnly))
+
+	calc, err := models.CalculatePayPeriod(db, payPeriodJobFlag, period)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to calculate pay period: %v\n", err)
+		os.Exit(1)
+	}
+
+	if payPeriodJSONFlag {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(calc); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to encode calculation: %v\n", err)
+			os.Exit(1)
+		}
+	} else {
+		printPayPeriod(calc)
+	}
+
+	if !payPeriodSaveFlag || len(calc.Items) == 0 {
+		return
+	}
+	if err := calc.Save(db); err != nil {
+		if errors.Is(err, scd.ErrAlreadyExists) {
+			fmt.Fprintf(os.Stderr, "❌ Some line items were already saved, nothing was written: %v\n", err)
+		} else {
+			fmt.Fprintf(os.Stderr, "Failed to save line items: %v\n", err)
+		}
+		os.Exit(1)
+	}
+	fmt.Fprintf(os.Stderr, "✅ Saved %d payment line item(s)\n", len(calc.Items))
+}
+
+// parsePayPeriod reads the --from and --to days; an empty to means one week after from
+func parsePayPeriod(from, to string) (models.PayPeriod, error) {
+	var period models.PayPeriod
+	var err error
+	if period.Start, err = time.Parse(time.DateOnly, from); err != nil {
+		return period, fmt.Errorf("--from must be YYYY-MM-DD: %w", err)
+	}
+	if to == "" {
+		period.End = period.Start.AddDate(0, 0, 7)
+		return period, nil
+	}
+	if period.End, err = time.Parse(time.DateOnly, to); err != nil {
+		return period, fmt.Errorf("--to must be YYYY-MM-DD: %w", err)
+	}
+	if !period.End.After(period.Start) {
+		return period, fmt.Errorf("--to must be after --from")
+	}
+	return period, nil
+}
+
+// printPayPeriod writes the calculation as a table with each item's explanation
+func printPayPeriod(calc *models.PayPeriodCalculation) {
+	if calc.Rule == nil {
+		fmt.Fprintf(os.Stderr, "ℹ️  %s has no rate rules, exact time is billed\n", calc.Job.ID)
+	} else {
+		fmt.Fprintf(os.Stderr, "📏 Rules v%d: rounding %dm, minimum %dm, daily cap %dm, overtime after %dm at %d%%\n",
+			calc.Rule.Version, calc.Rule.RoundingMinutes, calc.Rule.MinimumMinutes, calc.Rule.DailyCapMinutes,
+			calc.Rule.WeeklyOvertimeAfterMinutes, calc.Rule.OvertimePercent)
+	}
+
+	if len(calc.Items) == 0 {
+		fmt.Fprintln(os.Stderr, "⚠️  No unbilled timelogs in this period")
+	} else {
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "PAYMENT\tAMOUNT\tEXPLANATION")
+		for _, item := range calc.Items {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", item.ID, item.Amount.Format(), strings.Join(item.Explanation.Steps, "; "))
+		}
+		w.Flush()
+	}
+
+	if len(calc.Billed) > 0 {
+		fmt.Fprintf(os.Stderr, "\n🔁 Already billed: %s\n", strings.Join(calc.Billed, ", "))
+	}
+	fmt.Fprintf(os.Stderr, "\n📊 %d line item(s), total %s\n", len(calc.Items), calc.Total.Format())
+}
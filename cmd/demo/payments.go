@@ -6,6 +6,7 @@ import (
 	"os"
 
 	"github.com/abhi14nexu/mercor-scd/internal/models"
+	"github.com/abhi14nexu/mercor-scd/internal/money"
 	"github.com/abhi14nexu/mercor-scd/internal/scd"
 	"github.com/spf13/cobra"
 )
@@ -14,13 +15,13 @@ import (
 var paymentsCmd = &cobra.Command{
 	Use:   "payments",
 	Short: "Query payment line items by contractor",
-	Long: `Finds all job versions associated with a contractor, then retrieves the latest
-versions of all related payment line items.
+	Long: `Retrieves the latest versions of all payment line items whose job version
+belongs to a contractor, together with that job version and the job's latest version.
 
 This command demonstrates:
 - Querying across multiple SCD entities with relationships
-- Using scd.Latest scope for multiple related tables
-- Complex queries involving foreign key relationships between versioned entities
+- Joining the exact referenced version with scd.JoinSCD
+- Preloading pinned (db.Preload) and floating (scd.PreloadLatest) relations in constant queries
 
 Example:
   demo payments --contractor=contractor-alice`,
@@ -40,40 +41,17 @@ func init() {
 func runPayments(cmd *cobra.Command, args []string) {
 	fmt.Fprintf(os.Stderr, "💰 Querying payments for contractor: %s\n", contractorFlag)
 
-	// Step 1: Find all job versions for this contractor
-	// We need ALL versions because payments might reference any version
-	var jobVersions []models.Job
-	result := db.Where("contractor_id = ?", contractorFlag).
-		Order("id ASC, version ASC").
-		Find(&jobVersions)
-
-	if result.Error != nil {
-		fmt.Fprintf(os.Stderr, "Failed to query job versions: %v\n", result.Error)
-		os.Exit(1)
-	}
-
-	if len(jobVersions) == 0 {
-		fmt.Fprintf(os.Stderr, "⚠️  No jobs found for contractor: %s\n", contractorFlag)
-		return
-	}
-
-	fmt.Fprintf(os.Stderr, "📋 Found %d job version(s) for contractor: %s\n", len(jobVersions), contractorFlag)
-
-	// Step 2: Get UIDs of all job versions
-	var jobUIDs []string
-	jobUIDToInfo := make(map[string]models.Job)
-
-	for _, job := range jobVersions {
-		uidStr := job.GetUID().String()
-		jobUIDs = append(jobUIDs, uidStr)
-		jobUIDToInfo[uidStr] = job
-	}
-
-	// Step 3: Find latest payment line items that reference these job versions
+	// Latest payments whose referenced job version belongs to the contractor,
+	// with that job version (pinned) and the job's current version (floating) preloaded
 	var payments []models.PaymentLineItem
-	result = db.Scopes(scd.Latest).
-		Where("job_uid IN ?", jobUIDs).
-		Order("id ASC").
+	result := db.Scopes(
+		scd.Latest,
+		scd.JoinSCD(&models.Job{}).As("job").ByUID("job_uid"),
+		scd.PreloadLatest("LatestJob"),
+	).
+		Where("job.contractor_id = ?", contractorFlag).
+		Preload("Job").
+		Order("payment_line_items.id ASC").
 		Find(&payments)
 
 	if result.Error != nil {
@@ -91,34 +69,34 @@ func runPayments(cmd *cobra.Command, args []string) {
 	// Create enhanced output structure with job information
 	type PaymentOutput struct {
 		PaymentInfo struct {
-			BusinessID string  `json:"business_id"`
-			UID        string  `json:"uid"`
-			Version    int     `json:"version"`
-			Amount     float64 `json:"amount"`
-			Status     string  `json:"status"`
-			ValidFrom  string  `json:"valid_from"`
-			ValidTo    *string `json:"valid_to"`
+			BusinessID string      `json:"business_id"`
+			UID        string      `json:"uid"`
+			Version    int         `json:"version"`
+			Amount     money.Money `json:"amount"`
+			Status     string      `json:"status"`
+			ValidFrom  string      `json:"valid_from"`
+			ValidTo    *string     `json:"valid_to"`
 		} `json:"payment_info"`
 		RelatedJob struct {
-			BusinessID string  `json:"business_id"`
-			UID        string  `json:"uid"`
-			Version    int     `json:"version"`
-			Title      string  `json:"title"`
-			Rate       float64 `json:"rate"`
-			Status     string  `json:"status"`
+			BusinessID string      `json:"business_id"`
+			UID        string      `json:"uid"`
+			Version    int         `json:"version"`
+			Title      string      `json:"title"`
+			Rate       money.Money `json:"rate"`
+			Status     string      `json:"status"`
 		} `json:"related_job"`
 		TimelogUID string `json:"timelog_uid"`
 	}
 
 	var output []PaymentOutput
-	var totalAmount float64
+	var totalAmount money.Money
+	var err error
 
 	for _, payment := range payments {
-		// Get related job information
-		jobUID := payment.JobUID.String()
-		relatedJob, exists := jobUIDToInfo[jobUID]
-		if !exists {
-			fmt.Fprintf(os.Stderr, "Warning: Could not find job info for UID %s\n", jobUID)
+		// Related job information (the exact version the payment was calculated from)
+		relatedJob := payment.Job
+		if relatedJob == nil {
+			fmt.Fprintf(os.Stderr, "Warning: Could not find job info for UID %s\n", payment.JobUID)
 			continue
 		}
 
@@ -148,7 +126,10 @@ func runPayments(cmd *cobra.Command, args []string) {
 		paymentOut.RelatedJob.Status = relatedJob.Status
 
 		output = append(output, paymentOut)
-		totalAmount += payment.Amount
+		if totalAmount, err = totalAmount.Add(payment.Amount); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to total amounts: %v\n", err)
+			os.Exit(1)
+		}
 	}
 
 	// Pretty-print JSON output
@@ -165,36 +146,38 @@ func runPayments(cmd *cobra.Command, args []string) {
 	// Additional summary
 	fmt.Fprintf(os.Stderr, "\n📊 Summary:\n")
 	fmt.Fprintf(os.Stderr, "• Total payment line items: %d\n", len(payments))
-	fmt.Fprintf(os.Stderr, "• Total amount: $%.2f\n", totalAmount)
-	fmt.Fprintf(os.Stderr, "• Average amount: $%.2f\n", totalAmount/float64(len(payments)))
+	fmt.Fprintf(os.Stderr, "• Total amount: %s\n", totalAmount.Format())
+	fmt.Fprintf(os.Stderr, "• Average amount: %s\n", totalAmount.Div(int64(len(payments)), money.HalfUp).Format())
 
 	// Status breakdown
 	statusCount := make(map[string]int)
-	statusAmount := make(map[string]float64)
+	statusAmount := make(map[string]money.Money)
 
 	for _, payment := range payments {
 		statusCount[payment.Status]++
-		statusAmount[payment.Status] += payment.Amount
+		if statusAmount[payment.Status], err = statusAmount[payment.Status].Add(payment.Amount); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to total amounts: %v\n", err)
+			os.Exit(1)
+		}
 	}
 
 	fmt.Fprintf(os.Stderr, "• Payment status breakdown:\n")
 	for status, count := range statusCount {
-		fmt.Fprintf(os.Stderr, "  - %s: %d items ($%.2f total)\n", status, count, statusAmount[status])
+		fmt.Fprintf(os.Stderr, "  - %s: %d items (%s total)\n", status, count, statusAmount[status].Format())
 	}
 
 	// Job information
-	latestJobs := make(map[string]models.Job)
-	for _, job := range jobVersions {
-		existing, exists := latestJobs[job.GetBusinessID()]
-		if !exists || job.GetVersion() > existing.GetVersion() {
-			latestJobs[job.GetBusinessID()] = job
+	latestJobs := make(map[string]*models.Job)
+	for _, payment := range payments {
+		if payment.LatestJob != nil {
+			latestJobs[payment.LatestJob.GetBusinessID()] = payment.LatestJob
 		}
 	}
 
 	fmt.Fprintf(os.Stderr, "• Related jobs (latest versions): %d\n", len(latestJobs))
 	for _, job := range latestJobs {
-		fmt.Fprintf(os.Stderr, "  - %s: %s ($%.2f/hr, %s)\n",
-			job.GetBusinessID(), job.Title, job.Rate, job.Status)
+		fmt.Fprintf(os.Stderr, "  - %s: %s (%s/hr, %s)\n",
+			job.GetBusinessID(), job.Title, job.Rate.Format(), job.Status)
 	}
 
 	fmt.Fprintf(os.Stderr, "\n💡 To see all versions: SELECT * FROM payment_line_items WHERE job_uid IN (SELECT uid FROM jobs WHERE contractor_id='%s');\n", contractorFlag)
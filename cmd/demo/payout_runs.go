@@ -0,0 +1,194 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/abhi14nexu/mercor-scd/internal/models"
+	"github.com/abhi14nexu/mercor-scd/internal/money"
+	"github.com/abhi14nexu/mercor-scd/internal/payout"
+	"github.com/abhi14nexu/mercor-scd/internal/scd"
+	"github.com/spf13/cobra"
+	"gorm.io/gorm"
+)
+
+// payoutRunCmd represents the payout-run command group
+var payoutRunCmd = &cobra.Command{
+	Use:   "payout-run",
+	Short: "Create and move payout runs through draft → approved → submitted → settled/failed",
+	Long: `A payout run groups a contractor's payable line items (not-paid or failed) whose
+timelogs started within a period. Every status change writes a new run version and, for
+settle and fail, a new version of each member line item, all in one unit of work.
+
+Examples:
+  demo payout-run create --contractor=contractor-alice --from=2025-07-07
+  demo payout-run approve payout-contractor-alice-20250707-USD
+  demo payout-run submit payout-contractor-alice-20250707-USD
+  demo payout-run settle payout-contractor-alice-20250707-USD
+  demo payout-run process payout-contractor-alice-20250707-USD --fail=payment-1
+  demo payout-run show payout-contractor-alice-20250707-USD`,
+}
+
+var payoutRunCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Create a draft run of a contractor's payable line items for a period",
+	Run:   runPayoutRunCreate,
+}
+
+var payoutRunShowCmd = &cobra.Command{
+	Use:   "show <run-id>",
+	Short: "Show a run and its line items",
+	Args:  cobra.ExactArgs(1),
+	Run:   runPayoutRunShow,
+}
+
+var payoutRunProcessCmd = &cobra.Command{
+	Use:   "process <run-id>",
+	Short: "Pay an approved run through the in-process fake provider, polling until every payout is final",
+	Long: `Submits each line item of an approved run to a fake payout provider and polls it until
+every payout has succeeded or failed. Provider references and responses are recorded on new line
+item versions; the run is then settled, or failed if no payout succeeded.`,
+	Args: cobra.ExactArgs(1),
+	Run:  runPayoutRunProcess,
+}
+
+var (
+	payoutDelayFlag      time.Duration
+	payoutFailFlag       string
+	payoutContractorFlag string
+	payoutFromFlag       string
+	payoutToFlag         string
+	payoutCurrencyFlag   string
+	payoutIDFlag         string
+)
+
+func init() {
+	payoutRunCreateCmd.Flags().StringVar(&payoutContractorFlag, "contractor", "", "Contractor ID (required)")
+	payoutRunCreateCmd.Flags().StringVar(&payoutFromFlag, "from", "", "First day of the period, YYYY-MM-DD in UTC (required)")
+	payoutRunCreateCmd.Flags().StringVar(&payoutToFlag, "to", "", "Day after the period, YYYY-MM-DD in UTC (default: one week after --from)")
+	payoutRunCreateCmd.Flags().StringVar(&payoutCurrencyFlag, "currency", string(money.DefaultCurrency), "Currency of the line items to pay")
+	payoutRunCreateCmd.Flags().StringVar(&payoutIDFlag, "id", "", "Run ID (default: payout-<contractor>-<from>-<currency>)")
+	payoutRunCreateCmd.MarkFlagRequired("contractor")
+	payoutRunCreateCmd.MarkFlagRequired("from")
+
+	payoutRunProcessCmd.Flags().DurationVar(&payoutDelayFlag, "delay", 2*time.Second, "Time the fake provider keeps each payout pending")
+	payoutRunProcessCmd.Flags().StringVar(&payoutFailFlag, "fail", "", "Comma-separated payment IDs the fake provider should fail")
+
+	payoutRunCmd.AddCommand(payoutRunCreateCmd)
+	payoutRunCmd.AddCommand(payoutRunTransitionCmd("approve", "Approve a draft run", models.ApprovePayoutRun))
+	payoutRunCmd.AddCommand(payoutRunTransitionCmd("submit", "Mark an approved run as sent for payment", models.SubmitPayoutRun))
+	payoutRunCmd.AddCommand(payoutRunTransitionCmd("settle", "Settle a submitted run, marking its line items paid", models.SettlePayoutRun))
+	payoutRunCmd.AddCommand(payoutRunTransitionCmd("fail", "Fail a submitted run, marking its line items failed", models.FailPayoutRun))
+	payoutRunCmd.AddCommand(payoutRunProcessCmd)
+	payoutRunCmd.AddCommand(payoutRunShowCmd)
+}
+
+// payoutRunTransitionCmd builds a subcommand that moves a run with one of the models lifecycle functions
+func payoutRunTransitionCmd(use, short string, transition func(*gorm.DB, string) (*models.PayoutRun, error)) *cobra.Command {
+	return &cobra.Command{
+		Use:   use + " <run-id>",
+		Short: short,
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			run, err := transition(db, args[0])
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "❌ Failed to %s %s: %v\n", use, args[0], err)
+				os.Exit(1)
+			}
+			fmt.Fprintf(os.Stderr, "✅ %s is %s (v%d, %d item(s), %s)\n", run.ID, run.Status, run.Version, run.ItemCount, run.Total.Format())
+		},
+	}
+}
+
+func runPayoutRunCreate(cmd *cobra.Command, args []string) {
+	period, err := parsePayPeriod(payoutFromFlag, payoutToFlag)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid period: %v\n", err)
+		os.Exit(1)
+	}
+	currency := money.Currency(payoutCurrencyFlag)
+	id := payoutIDFlag
+	if id == "" {
+		id = models.PayoutRunID(payoutContractorFlag, period.Start, currency)
+	}
+
+	run, err := models.CreatePayoutRun(db, id, payoutContractorFlag, period, currency)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Failed to create payout run: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Fprintf(os.Stderr, "✅ Created draft %s with %d item(s), total %s\n", run.ID, run.ItemCount, run.Total.Format())
+}
+
+func runPayoutRunProcess(cmd *cobra.Command, args []string) {
+	failing := make(map[string]bool)
+	for _, id := range strings.Split(payoutFailFlag, ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			failing[id] = true
+		}
+	}
+	provider := payout.NewFake(payout.FakeOptions{
+		Delay: payoutDelayFlag,
+		Fail: func(req payout.Request) string {
+			if failing[req.PaymentID] {
+				return "rejected by the fake provider"
+			}
+			return ""
+		},
+	})
+	processor := payout.NewProcessor(db, provider)
+	ctx := context.Background()
+
+	run, err := processor.Submit(ctx, args[0])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Failed to submit %s: %v\n", args[0], err)
+		os.Exit(1)
+	}
+	fmt.Fprintf(os.Stderr, "📤 Submitted %d payout(s) to the %s provider\n", provider.Submissions(), provider.Name())
+
+	for run.Status == "submitted" {
+		time.Sleep(500 * time.Millisecond)
+		if run, err = processor.Poll(ctx, args[0]); err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Failed to poll %s: %v\n", args[0], err)
+			os.Exit(1)
+		}
+	}
+	fmt.Fprintf(os.Stderr, "✅ %s is %s (v%d, %d item(s), %s)\n", run.ID, run.Status, run.Version, run.ItemCount, run.Total.Format())
+}
+
+func runPayoutRunShow(cmd *cobra.Command, args []string) {
+	run, err := scd.GetLatest[*models.PayoutRun](db, args[0])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to find payout run %s: %v\n", args[0], err)
+		os.Exit(1)
+	}
+	items, err := models.PayoutRunItems(db, run.ID)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load line items: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("%s v%d: %s, %s, %s to %s, %d item(s), total %s\n", run.ID, run.Version, run.Status, run.ContractorID,
+		run.PeriodStart.Format("2006-01-02"), run.PeriodEnd.Format("2006-01-02"), run.ItemCount, run.Total.Format())
+	if len(items) == 0 {
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PAYMENT\tVERSION\tSTATUS\tAMOUNT\tPAYOUT")
+	for _, item := range items {
+		fmt.Fprintf(w, "%s\tv%d\t%s\t%s\t%s\n", item.ID, item.Version, item.Status, item.Amount.Format(), payoutSummary(item))
+	}
+	w.Flush()
+}
+
+// payoutSummary describes what the payout provider reported for an item, e.g. "fake fake_000001 succeeded"
+func payoutSummary(item *models.PaymentLineItem) string {
+	if item.PayoutStatus == "" {
+		return "-"
+	}
+	return strings.TrimSpace(fmt.Sprintf("%s %s %s", item.PayoutProvider, item.PayoutRef, item.PayoutStatus))
+}
@@ -5,6 +5,7 @@ import (
 	"os"
 
 	"github.com/abhi14nexu/mercor-scd/internal/models"
+	"github.com/abhi14nexu/mercor-scd/internal/scd"
 	"github.com/spf13/cobra"
 	"gorm.io/driver/postgres"
 	"gorm.io/driver/sqlite"
@@ -60,6 +61,11 @@ func initDB(cmd *cobra.Command, args []string) {
 
 		log.Println("✅ Connected to PostgreSQL database")
 	}
+
+	// Enable scd.PreloadLatest for floating relations
+	if err := db.Use(&scd.RelationsPlugin{}); err != nil {
+		log.Fatalf("Failed to register SCD relations: %v", err)
+	}
 }
 
 func init() {
@@ -67,4 +73,11 @@ func init() {
 	rootCmd.AddCommand(seedCmd)
 	rootCmd.AddCommand(latestJobsCmd)
 	rootCmd.AddCommand(paymentsCmd)
+	rootCmd.AddCommand(timelineCmd)
+	rootCmd.AddCommand(snapshotCmd)
+	rootCmd.AddCommand(importCmd)
+	rootCmd.AddCommand(staleRefsCmd)
+	rootCmd.AddCommand(payPeriodCmd)
+	rootCmd.AddCommand(payoutRunCmd)
+	rootCmd.AddCommand(exportPaymentsCmd)
 }
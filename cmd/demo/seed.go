@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/abhi14nexu/mercor-scd/internal/models"
+	"github.com/abhi14nexu/mercor-scd/internal/money"
 	"github.com/abhi14nexu/mercor-scd/internal/scd"
 	"github.com/google/uuid"
 	"github.com/spf13/cobra"
@@ -21,6 +22,9 @@ var seedCmd = &cobra.Command{
 - Creates 10 jobs, each with 3 versions showing status and rate changes
 - Creates 40 timelogs referencing various job versions
 - Creates 40 payment line items based on the timelogs
+- Creates USD exchange rates for EUR, GBP and INR, each with a rate change
+- Creates pay rules for job-1 (with a contract change) and job-2
+- Creates bank accounts for every contractor (US for most, IBAN for carol)
 - Uses realistic company and contractor IDs for querying`,
 	Run: runSeed,
 }
@@ -39,8 +43,6 @@ func runSeed(cmd *cobra.Command, args []string) {
 		"UI/UX Designer", "QA Engineer", "Tech Lead", "Full Stack Developer",
 	}
 
-	statuses := []string{"active", "paused", "completed"}
-
 	// Step 1: Create 10 jobs with 3 versions each
 	log.Println("📋 Creating jobs with version history...")
 
@@ -55,7 +57,7 @@ func runSeed(cmd *cobra.Command, args []string) {
 			jobTitles[i-1],
 			companies[rand.Intn(len(companies))],
 			contractors[rand.Intn(len(contractors))],
-			float64(40+rand.Intn(60)), // Rate between $40-100
+			money.FromMajor(int64(40+rand.Intn(60)), money.USD), // Rate between $40-100
 		)
 
 		createdJob, err := scd.CreateNew[*models.Job](db, job)
@@ -70,16 +72,17 @@ func runSeed(cmd *cobra.Command, args []string) {
 		for version := 2; version <= 3; version++ {
 			time.Sleep(10 * time.Millisecond) // Small delay for distinct timestamps
 
-			updatedJob, err := scd.Update[*models.Job](db, jobID, func(j *models.Job) {
+			updatedJob, _, err := models.UpdateJob(db, jobID, func(j *models.Job) {
 				// Vary status and rate changes
 				if version == 2 {
 					// Version 2: Change rate
-					j.Rate = j.Rate + float64(5+rand.Intn(15)) // Increase rate
+					j.UpdateRate(addDollars(j.Rate, 5+rand.Intn(15))) // Increase rate
 				} else {
-					// Version 3: Change status
-					j.Status = statuses[rand.Intn(len(statuses))]
+					// Version 3: Change status along the job transition table
+					next := j.NextStatuses()
+					j.Status = next[rand.Intn(len(next))]
 					if rand.Float32() < 0.3 { // 30% chance to also change rate
-						j.Rate = j.Rate + float64(-5+rand.Intn(11)) // ±5 rate change
+						j.UpdateRate(addDollars(j.Rate, -5+rand.Intn(11))) // ±5 rate change
 					}
 				}
 			})
@@ -92,8 +95,8 @@ func runSeed(cmd *cobra.Command, args []string) {
 			jobUIDs = append(jobUIDs, currentJob.GetUID())
 		}
 
-		log.Printf("✅ Created job %s with 3 versions (latest: %s, rate: $%.2f)",
-			jobID, currentJob.Status, currentJob.Rate)
+		log.Printf("✅ Created job %s with 3 versions (latest: %s, rate: %s)",
+			jobID, currentJob.Status, currentJob.Rate.Format())
 	}
 
 	// Step 2: Create 40 timelogs
@@ -131,7 +134,7 @@ func runSeed(cmd *cobra.Command, args []string) {
 		if rand.Float32() < 0.15 { // 15% get adjustments
 			time.Sleep(5 * time.Millisecond)
 
-			_, err := scd.Update[*models.Timelog](db, timelogID, func(t *models.Timelog) {
+			_, _, err := models.UpdateTimelog(db, timelogID, func(t *models.Timelog) {
 				// Adjust duration by ±30 minutes
 				adjustment := time.Duration(-30+rand.Intn(61)) * time.Minute
 				newEndTime := time.Unix(t.TimeEnd, 0).Add(adjustment)
@@ -151,8 +154,6 @@ func runSeed(cmd *cobra.Command, args []string) {
 	// Step 3: Create 40 payment line items
 	log.Println("💰 Creating payment line items...")
 
-	paymentStatuses := []string{"not-paid", "paid", "failed"}
-
 	for i := 1; i <= 40; i++ {
 		paymentID := fmt.Sprintf("payment-%d", i)
 
@@ -161,7 +162,8 @@ func runSeed(cmd *cobra.Command, args []string) {
 		timelogUID := timelogUIDs[rand.Intn(len(timelogUIDs))]
 
 		// Calculate realistic amount (rate * hours)
-		amount := float64(50+rand.Intn(50)) * (1.0 + rand.Float64()*7.0) // $50-100 rate * 1-8 hours
+		rate := money.FromMajor(int64(50+rand.Intn(50)), money.USD)       // $50-100 rate
+		amount := rate.MulRat(int64(60+rand.Intn(421)), 60, money.HalfUp) // * 1-8 hours
 
 		payment := models.NewPaymentLineItem(paymentID, jobUID, timelogUID, amount)
 
@@ -175,19 +177,19 @@ func runSeed(cmd *cobra.Command, args []string) {
 			payment.Status = "failed"
 		}
 
-		_, err := scd.CreateNew[*models.PaymentLineItem](db, payment)
+		created, err := scd.CreateNew[*models.PaymentLineItem](db, payment)
 		if err != nil {
 			log.Fatalf("Failed to create payment %s: %v", paymentID, err)
 		}
 
-		// Some payments get status updates
-		if rand.Float32() < 0.2 { // 20% get status changes
+		// Some unpaid payments get status updates; paid items are final
+		next := created.NextStatuses()
+		if len(next) > 0 && rand.Float32() < 0.2 { // 20% get status changes
 			time.Sleep(5 * time.Millisecond)
 
 			_, err := scd.Update[*models.PaymentLineItem](db, paymentID, func(p *models.PaymentLineItem) {
 				// Change status (e.g., not-paid -> paid)
-				newStatus := paymentStatuses[rand.Intn(len(paymentStatuses))]
-				p.Status = newStatus
+				p.Status = next[rand.Intn(len(next))]
 			})
 
 			if err != nil {
@@ -200,12 +202,82 @@ func runSeed(cmd *cobra.Command, args []string) {
 		}
 	}
 
+	// Step 4: Create exchange rates with one rate change each
+	log.Println("💱 Creating exchange rates...")
+
+	fxRates := map[money.Currency][2]string{
+		money.EUR: {"0.9215", "0.9188"},
+		money.GBP: {"0.7893", "0.7921"},
+		money.INR: {"83.1250", "83.4010"},
+	}
+	for quote, rates := range fxRates {
+		fxID := models.FXRateID(money.USD, quote)
+		if _, err := scd.CreateNew(db, models.NewFXRate(money.USD, quote, money.MustExchangeRate(rates[0]))); err != nil {
+			log.Fatalf("Failed to create exchange rate %s: %v", fxID, err)
+		}
+		time.Sleep(5 * time.Millisecond)
+		if _, err := scd.Update(db, fxID, func(r *models.FXRate) { r.UpdateRate(money.MustExchangeRate(rates[1])) }); err != nil {
+			log.Fatalf("Failed to update exchange rate %s: %v", fxID, err)
+		}
+	}
+
+	// Step 5: Create pay rules for two jobs; job-1's contract later adds weekly overtime
+	log.Println("📏 Creating rate rules...")
+
+	rule := models.NewRateRule("job-1")
+	rule.RoundingMinutes = 15
+	rule.DailyCapMinutes = 8 * 60
+	if _, err := scd.CreateNew(db, rule); err != nil {
+		log.Fatalf("Failed to create rate rules for job-1: %v", err)
+	}
+	time.Sleep(5 * time.Millisecond)
+	if _, err := scd.Update(db, rule.ID, func(r *models.RateRule) { r.WeeklyOvertimeAfterMinutes = 40 * 60 }); err != nil {
+		log.Fatalf("Failed to update rate rules for job-1: %v", err)
+	}
+
+	rule = models.NewRateRule("job-2")
+	rule.RoundingMinutes = 6
+	rule.MinimumMinutes = 30
+	if _, err := scd.CreateNew(db, rule); err != nil {
+		log.Fatalf("Failed to create rate rules for job-2: %v", err)
+	}
+
+	// Step 6: Create bank accounts; carol is paid by IBAN transfer, everyone else by ACH
+	log.Println("🏦 Creating bank accounts...")
+
+	holders := map[string]string{
+		"contractor-alice": "Alice Johnson", "contractor-bob": "Bob Martinez",
+		"contractor-carol": "Carol Smith", "contractor-dave": "Dave Okafor",
+	}
+	ibans := map[string][2]string{"contractor-carol": {"GB82WEST12345698765432", "NWBKGB2L"}}
+	for i, contractor := range contractors {
+		account := models.NewBankAccount(contractor, holders[contractor])
+		if iban, ok := ibans[contractor]; ok {
+			account.IBAN, account.BIC = iban[0], iban[1]
+		} else {
+			account.RoutingNumber = "011000015"
+			account.AccountNumber = fmt.Sprintf("10002000%d", i+1)
+			account.AccountType = "checking"
+		}
+		if _, err := scd.CreateNew(db, account); err != nil {
+			log.Fatalf("Failed to create bank account for %s: %v", contractor, err)
+		}
+	}
+
 	log.Println("🎉 Database seeding completed successfully!")
 	log.Println("📊 Summary:")
 	log.Println("   • 10 jobs (30 total versions)")
 	log.Println("   • 40+ timelogs (some with adjustments)")
 	log.Println("   • 40+ payment line items (some with status updates)")
+	log.Println("   • 3 exchange rates (USD to EUR, GBP and INR, 2 versions each)")
+	log.Println("   • 2 rate rules (job-1 with 2 versions, job-2)")
+	log.Printf("   • %d bank accounts", len(contractors))
 	log.Println("")
 	log.Printf("💡 Try: go run cmd/demo latest-jobs --company=%s\n", companies[0])
 	log.Printf("💡 Try: go run cmd/demo payments --contractor=%s\n", contractors[0])
 }
+
+// addDollars adds a whole number of dollars to an amount
+func addDollars(amount money.Money, dollars int) money.Money {
+	return money.New(amount.Minor()+int64(dollars)*100, amount.Currency())
+}
@@ -0,0 +1,345 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"time"
+
+	"github.com/abhi14nexu/mercor-scd/internal/models"
+	"github.com/abhi14nexu/mercor-scd/internal/scd"
+	"github.com/spf13/cobra"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/logger"
+	"gorm.io/gorm/schema"
+)
+
+// snapshotCmd represents the snapshot command
+var snapshotCmd = &cobra.Command{
+	Use:   "snapshot",
+	Short: "Export all models as they were at a point in time",
+	Long: `Writes a frozen copy of every model (jobs, timelogs, payments) using scd.AsOf.
+
+All tables are read in a single transaction so the snapshot is consistent.
+The output directory gets one file per table (jsonl, csv) or a standalone
+SQLite database (sqlite), plus manifest.json with row counts and SHA-256 checksums.
+
+Example:
+  demo snapshot --as-of=2025-06-30T23:59:00Z --format=jsonl --out=close-2025-06`,
+	Run: runSnapshot,
+}
+
+var (
+	snapshotAsOfFlag   string
+	snapshotFormatFlag string
+	snapshotOutFlag    string
+)
+
+// Supported snapshot formats
+const (
+	snapshotJSONL  = "jsonl"
+	snapshotCSV    = "csv"
+	snapshotSQLite = "sqlite"
+)
+
+func init() {
+	snapshotCmd.Flags().StringVar(&snapshotAsOfFlag, "as-of", "", "Point in time, RFC3339 or \"2006-01-02 15:04\" (required)")
+	snapshotCmd.Flags().StringVar(&snapshotFormatFlag, "format", snapshotJSONL, "Output format: jsonl, csv or sqlite")
+	snapshotCmd.Flags().StringVar(&snapshotOutFlag, "out", "", "Output directory (default snapshot-<as-of>)")
+	snapshotCmd.MarkFlagRequired("as-of")
+}
+
+// snapshotManifest describes a snapshot and lets consumers verify it
+type snapshotManifest struct {
+	AsOf      time.Time       `json:"as_of"`
+	Format    string          `json:"format"`
+	CreatedAt time.Time       `json:"created_at"`
+	Tables    []snapshotTable `json:"tables"`
+}
+
+// snapshotTable is the manifest entry for one model
+type snapshotTable struct {
+	Table  string `json:"table"`
+	File   string `json:"file"`
+	Count  int    `json:"count"`
+	SHA256 string `json:"sha256"`
+}
+
+// snapshotData holds the rows read for one model
+type snapshotData struct {
+	schema *schema.Schema
+	rows   reflect.Value // slice of model pointers
+}
+
+func runSnapshot(cmd *cobra.Command, args []string) {
+	asOf, err := parseAsOf(snapshotAsOfFlag)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid --as-of: %v\n", err)
+		os.Exit(1)
+	}
+	switch snapshotFormatFlag {
+	case snapshotJSONL, snapshotCSV, snapshotSQLite:
+	default:
+		fmt.Fprintf(os.Stderr, "Unsupported format %q (use jsonl, csv or sqlite)\n", snapshotFormatFlag)
+		os.Exit(1)
+	}
+
+	out := snapshotOutFlag
+	if out == "" {
+		out = "snapshot-" + asOf.UTC().Format("20060102T150405Z")
+	}
+	if err := os.MkdirAll(out, 0o755); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to create output directory: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Fprintf(os.Stderr, "📸 Snapshotting all models as of %s (%s) into %s\n", asOf.Format(time.RFC3339), snapshotFormatFlag, out)
+
+	data, err := readSnapshot(asOf)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to read snapshot: %v\n", err)
+		os.Exit(1)
+	}
+
+	manifest := snapshotManifest{AsOf: asOf, Format: snapshotFormatFlag, CreatedAt: time.Now().UTC()}
+	switch snapshotFormatFlag {
+	case snapshotJSONL:
+		manifest.Tables, err = writeSnapshotFiles(out, "jsonl", data, writeJSONL)
+	case snapshotCSV:
+		manifest.Tables, err = writeSnapshotFiles(out, "csv", data, writeCSV)
+	case snapshotSQLite:
+		manifest.Tables, err = writeSnapshotSQLite(filepath.Join(out, "snapshot.db"), data)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write snapshot: %v\n", err)
+		os.Exit(1)
+	}
+
+	manifestData, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to marshal manifest: %v\n", err)
+		os.Exit(1)
+	}
+	if err := os.WriteFile(filepath.Join(out, "manifest.json"), append(manifestData, '\n'), 0o644); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write manifest: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Fprintf(os.Stderr, "\n📊 Summary:\n")
+	for _, table := range manifest.Tables {
+		fmt.Fprintf(os.Stderr, "• %s: %d row(s) → %s\n", table.Table, table.Count, table.File)
+	}
+	fmt.Println(filepath.Join(out, "manifest.json"))
+}
+
+// parseAsOf accepts RFC3339 or a "2006-01-02 15:04" local time
+func parseAsOf(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.ParseInLocation("2006-01-02 15:04", value, time.Local)
+}
+
+// readSnapshot reads every model as of t inside one transaction
+func readSnapshot(asOf time.Time) ([]snapshotData, error) {
+	var opts []*sql.TxOptions
+	if db.Dialector.Name() == "postgres" {
+		// A repeatable-read snapshot makes all tables reflect the same moment
+		opts = append(opts, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	}
+
+	var data []snapshotData
+	err := db.Transaction(func(tx *gorm.DB) error {
+		for _, model := range models.GetAllModels() {
+			stmt := &gorm.Statement{DB: tx}
+			if err := stmt.Parse(model); err != nil {
+				return fmt.Errorf("failed to parse %T: %w", model, err)
+			}
+
+			bound := scd.On(model)
+			rows := reflect.New(reflect.SliceOf(reflect.TypeOf(model)))
+			if err := tx.Model(model).Scopes(scd.AsOf(asOf)).
+				Order(clause.OrderByColumn{Column: bound.Column(tx, bound.Columns(tx).BusinessID)}).
+				Find(rows.Interface()).Error; err != nil {
+				return fmt.Errorf("failed to read %s: %w", stmt.Schema.Table, err)
+			}
+
+			data = append(data, snapshotData{schema: stmt.Schema, rows: rows.Elem()})
+		}
+		return nil
+	}, opts...)
+
+	return data, err
+}
+
+// writeSnapshotFiles writes one file per table with the given writer and checksums it
+func writeSnapshotFiles(dir, ext string, data []snapshotData, write func(io.Writer, snapshotData) error) ([]snapshotTable, error) {
+	var tables []snapshotTable
+	for _, d := range data {
+		name := d.schema.Table + "." + ext
+		path := filepath.Join(dir, name)
+
+		f, err := os.Create(path)
+		if err != nil {
+			return nil, err
+		}
+		if err := write(f, d); err != nil {
+			f.Close()
+			return nil, fmt.Errorf("failed to write %s: %w", name, err)
+		}
+		if err := f.Close(); err != nil {
+			return nil, err
+		}
+
+		sum, err := fileSHA256(path)
+		if err != nil {
+			return nil, err
+		}
+		tables = append(tables, snapshotTable{Table: d.schema.Table, File: name, Count: d.rows.Len(), SHA256: sum})
+	}
+	return tables, nil
+}
+
+// writeJSONL writes one JSON object per row
+func writeJSONL(w io.Writer, d snapshotData) error {
+	enc := json.NewEncoder(w)
+	for i := 0; i < d.rows.Len(); i++ {
+		if err := enc.Encode(d.rows.Index(i).Interface()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeCSV writes a header of column names followed by one line per row
+func writeCSV(w io.Writer, d snapshotData) error {
+	var fields []*schema.Field
+	var header []string
+	for _, field := range d.schema.Fields {
+		if field.DBName != "" {
+			fields = append(fields, field)
+			header = append(header, field.DBName)
+		}
+	}
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	for i := 0; i < d.rows.Len(); i++ {
+		row := d.rows.Index(i).Elem()
+		record := make([]string, len(fields))
+		for j, field := range fields {
+			value, _ := field.ValueOf(context.Background(), row)
+			record[j] = csvValue(value)
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// csvValue formats a field value for CSV, with RFC3339 times and empty NULLs
+// Other values are written as stored, e.g. nullable UUIDs and JSON-serialized fields.
+func csvValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case time.Time:
+		return v.UTC().Format(time.RFC3339Nano)
+	case *time.Time:
+		if v == nil {
+			return ""
+		}
+		return v.UTC().Format(time.RFC3339Nano)
+	}
+	if rv := reflect.ValueOf(value); rv.Kind() == reflect.Ptr && rv.IsNil() {
+		return ""
+	}
+	if valuer, ok := value.(driver.Valuer); ok {
+		stored, err := valuer.Value()
+		if err != nil || stored == nil {
+			return ""
+		}
+		if b, ok := stored.([]byte); ok {
+			return string(b)
+		}
+		return fmt.Sprint(stored)
+	}
+	return fmt.Sprint(value)
+}
+
+// writeSnapshotSQLite copies the rows into a new standalone SQLite database
+func writeSnapshotSQLite(path string, data []snapshotData) ([]snapshotTable, error) {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	out, err := gorm.Open(sqlite.Open(path), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create snapshot database: %w", err)
+	}
+
+	var tables []snapshotTable
+	err = out.Transaction(func(tx *gorm.DB) error {
+		for _, d := range data {
+			model := reflect.New(d.schema.ModelType).Interface()
+			if err := tx.AutoMigrate(model); err != nil {
+				return fmt.Errorf("failed to create %s: %w", d.schema.Table, err)
+			}
+			if d.rows.Len() > 0 {
+				err := tx.Session(&gorm.Session{SkipHooks: true}).Omit(clause.Associations).
+					CreateInBatches(d.rows.Interface(), 500).Error
+				if err != nil {
+					return fmt.Errorf("failed to copy %s: %w", d.schema.Table, err)
+				}
+			}
+			tables = append(tables, snapshotTable{Table: d.schema.Table, File: filepath.Base(path), Count: d.rows.Len()})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if sqlDB, err := out.DB(); err == nil {
+		sqlDB.Close()
+	}
+
+	sum, err := fileSHA256(path)
+	if err != nil {
+		return nil, err
+	}
+	for i := range tables {
+		tables[i].SHA256 = sum
+	}
+	return tables, nil
+}
+
+// fileSHA256 returns the hex SHA-256 of a file
+func fileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/abhi14nexu/mercor-scd/internal/models"
+	"github.com/abhi14nexu/mercor-scd/internal/money"
+	"github.com/abhi14nexu/mercor-scd/internal/scd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// setupSnapshotTestDB points the command's database at a fresh in-memory SQLite database
+func setupSnapshotTestDB(t *testing.T) {
+	testDB, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	require.NoError(t, models.Register(testDB))
+
+	previous := db
+	db = testDB
+	t.Cleanup(func() { db = previous })
+}
+
+// snapshotRows returns the snapshot data for a table
+func snapshotRows(t *testing.T, data []snapshotData, table string) snapshotData {
+	for _, d := range data {
+		if d.schema.Table == table {
+			return d
+		}
+	}
+	t.Fatalf("no snapshot data for %s", table)
+	return snapshotData{}
+}
+
+// TestSnapshotReadsVersionsAsOf tests that a snapshot holds the versions valid at as-of, ordered by business ID
+func TestSnapshotReadsVersionsAsOf(t *testing.T) {
+	setupSnapshotTestDB(t)
+
+	_, err := scd.CreateNew(db, models.NewJob("job-b", "Designer", "company-acme", "contractor-bob", money.FromMajor(40, money.USD)))
+	require.NoError(t, err)
+	_, err = scd.CreateNew(db, models.NewJob("job-a", "Engineer", "company-acme", "contractor-alice", money.FromMajor(50, money.USD)))
+	require.NoError(t, err)
+	asOf := time.Now()
+	time.Sleep(2 * time.Millisecond)
+
+	_, err = scd.Update(db, "job-a", func(j *models.Job) { j.UpdateRate(money.FromMajor(60, money.USD)) })
+	require.NoError(t, err)
+	_, err = scd.CreateNew(db, models.NewJob("job-c", "Writer", "company-acme", "contractor-carol", money.FromMajor(30, money.USD)))
+	require.NoError(t, err)
+
+	data, err := readSnapshot(asOf)
+	require.NoError(t, err)
+	assert.Len(t, data, len(models.GetAllModels()), "Every model gets a table, even when empty")
+
+	jobs := snapshotRows(t, data, "jobs")
+	require.Equal(t, 2, jobs.rows.Len(), "Jobs created after as-of are excluded")
+	first := jobs.rows.Index(0).Interface().(*models.Job)
+	second := jobs.rows.Index(1).Interface().(*models.Job)
+	assert.Equal(t, "job-a", first.ID)
+	assert.Equal(t, 1, first.Version, "The version valid at as-of is exported, not the current one")
+	assert.Equal(t, money.FromMajor(50, money.USD), first.Rate)
+	assert.Equal(t, "job-b", second.ID)
+}
+
+// TestSnapshotWritesChecksummedFiles tests the jsonl, csv and sqlite writers and their manifest entries
+func TestSnapshotWritesChecksummedFiles(t *testing.T) {
+	setupSnapshotTestDB(t)
+
+	_, err := scd.CreateNew(db, models.NewJob("job-a", "Engineer", "company-acme", "contractor-alice", money.FromMajor(50, money.USD)))
+	require.NoError(t, err)
+	data, err := readSnapshot(time.Now())
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	tables, err := writeSnapshotFiles(dir, "jsonl", data, writeJSONL)
+	require.NoError(t, err)
+	require.Len(t, tables, len(data))
+	for _, table := range tables {
+		sum, err := fileSHA256(filepath.Join(dir, table.File))
+		require.NoError(t, err)
+		assert.Equal(t, sum, table.SHA256, "Manifest checksum for %s", table.File)
+	}
+
+	f, err := os.Open(filepath.Join(dir, "jobs.jsonl"))
+	require.NoError(t, err)
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	require.True(t, scanner.Scan())
+	var job models.Job
+	require.NoError(t, json.Unmarshal(scanner.Bytes(), &job))
+	assert.Equal(t, "job-a", job.ID)
+	assert.False(t, scanner.Scan(), "One line per row")
+
+	_, err = writeSnapshotFiles(dir, "csv", data, writeCSV)
+	require.NoError(t, err)
+	csvData, err := os.ReadFile(filepath.Join(dir, "jobs.csv"))
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(csvData)), "\n")
+	require.Len(t, lines, 2)
+	assert.Contains(t, lines[0], "valid_to")
+	assert.Contains(t, lines[1], "job-a")
+
+	tables, err = writeSnapshotSQLite(filepath.Join(dir, "snapshot.db"), data)
+	require.NoError(t, err)
+	out, err := gorm.Open(sqlite.Open(filepath.Join(dir, "snapshot.db")), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+	var count int64
+	require.NoError(t, out.Table("jobs").Count(&count).Error)
+	assert.Equal(t, int64(1), count)
+	for _, table := range tables {
+		assert.Equal(t, "snapshot.db", table.File)
+	}
+}
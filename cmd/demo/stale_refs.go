@@ -0,0 +1,86 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/abhi14nexu/mercor-scd/internal/models"
+	"github.com/spf13/cobra"
+)
+
+// staleRefsCmd represents the stale-refs command
+var staleRefsCmd = &cobra.Command{
+	Use:   "stale-refs",
+	Short: "Report payment line items with out-of-date version references",
+	Long: `Lists the latest payment line items whose pinned references are out of date:
+
+- timelog_superseded:     the referenced timelog version is no longer latest
+- job_superseded:         the referenced job version is no longer latest
+- job_not_valid_at_start: the referenced job version was not the one valid at the timelog's start
+
+Each row shows the stored amount, the amount computed from the latest timelog and the
+job version valid at its start, and the delta. Nothing is modified.
+
+Examples:
+  demo stale-refs
+  demo stale-refs --contractor contractor-alice
+  demo stale-refs --payment payment-1 --payment payment-2 --json`,
+	Run: runStaleRefs,
+}
+
+var (
+	staleRefsJSONFlag       bool
+	staleRefsContractorFlag string
+	staleRefsPaymentsFlag   []string
+)
+
+func init() {
+	staleRefsCmd.Flags().BoolVar(&staleRefsJSONFlag, "json", false, "Print the report as JSON")
+	staleRefsCmd.Flags().StringVar(&staleRefsContractorFlag, "contractor", "", "Only check payments for jobs of this contractor")
+	staleRefsCmd.Flags().StringArrayVar(&staleRefsPaymentsFlag, "payment", nil, "Payment ID to check, repeatable (default: every current payment)")
+}
+
+func runStaleRefs(cmd *cobra.Command, args []string) {
+	fmt.Fprintln(os.Stderr, "🔎 Checking payment line item references...")
+
+	stale, err := models.StaleReferences(db, models.StaleReferenceFilter{
+		ContractorID: staleRefsContractorFlag,
+		PaymentIDs:   staleRefsPaymentsFlag,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to build stale reference report: %v\n", err)
+		os.Exit(1)
+	}
+
+	if staleRefsJSONFlag {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(stale); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to encode report: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if len(stale) == 0 {
+		fmt.Fprintln(os.Stderr, "✅ All payment line items reference current versions")
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PAYMENT\tSTATUS\tAMOUNT\tEXPECTED\tDELTA\tREASONS")
+	for _, ref := range stale {
+		delta := ref.Delta.String()
+		if !ref.Delta.IsNegative() {
+			delta = "+" + delta
+		}
+		fmt.Fprintf(w, "%s (v%d)\t%s\t%s\t%s\t%s\t%s\n",
+			ref.PaymentID, ref.PaymentVersion, ref.Status, ref.Amount, ref.ExpectedAmount, delta, strings.Join(ref.Reasons, ", "))
+	}
+	w.Flush()
+
+	fmt.Fprintf(os.Stderr, "\n📊 %d stale payment line item(s)\n", len(stale))
+}
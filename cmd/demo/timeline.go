@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/abhi14nexu/mercor-scd/internal/models"
+	"github.com/abhi14nexu/mercor-scd/internal/scd"
+	"github.com/spf13/cobra"
+)
+
+// timelineCmd represents the timeline command
+var timelineCmd = &cobra.Command{
+	Use:   "timeline",
+	Short: "Show the history of a job field as intervals",
+	Long: `Renders how one field of a job changed over time, as intervals [from, to)
+during which the value stayed constant.
+
+Consecutive versions with the same value are merged, so changing only the
+status does not split the rate history.
+
+Example:
+  demo timeline --job=job-7 --field=rate`,
+	Run: runTimeline,
+}
+
+var (
+	timelineJobFlag   string
+	timelineFieldFlag string
+)
+
+func init() {
+	timelineCmd.Flags().StringVar(&timelineJobFlag, "job", "", "Job business ID (required)")
+	timelineCmd.Flags().StringVar(&timelineFieldFlag, "field", "rate", "Column to follow, e.g. rate or status")
+	timelineCmd.MarkFlagRequired("job")
+}
+
+func runTimeline(cmd *cobra.Command, args []string) {
+	fmt.Fprintf(os.Stderr, "📈 %s timeline for job: %s\n", timelineFieldFlag, timelineJobFlag)
+
+	intervals, err := scd.Timeline[*models.Job](db, timelineJobFlag, timelineFieldFlag)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to build timeline: %v\n", err)
+		os.Exit(1)
+	}
+
+	if len(intervals) == 0 {
+		fmt.Fprintf(os.Stderr, "⚠️  No versions found for job: %s\n", timelineJobFlag)
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "FROM\tTO\tVERSIONS\tVALUE")
+	for _, interval := range intervals {
+		to := "now"
+		if interval.To != nil {
+			to = interval.To.Format(time.DateTime)
+		}
+
+		versions := fmt.Sprintf("v%d", interval.FromVersion)
+		if interval.ToVersion != interval.FromVersion {
+			versions = fmt.Sprintf("v%d-v%d", interval.FromVersion, interval.ToVersion)
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%v\n", interval.From.Format(time.DateTime), to, versions, interval.Value)
+	}
+	w.Flush()
+
+	fmt.Fprintf(os.Stderr, "\n📊 %d interval(s)\n", len(intervals))
+}
@@ -0,0 +1,119 @@
+package bankfile
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/abhi14nexu/mercor-scd/internal/money"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAccountChecks tests IBAN, BIC and routing number validation
+func TestAccountChecks(t *testing.T) {
+	assert.True(t, ValidIBAN("DE89370400440532013000"))
+	assert.True(t, ValidIBAN("GB82 WEST 1234 5698 7654 32"))
+	assert.False(t, ValidIBAN("DE89370400440532013001"), "Wrong check digits")
+	assert.False(t, ValidIBAN("de89370400440532013000"))
+	assert.False(t, ValidIBAN(""))
+
+	assert.True(t, ValidBIC("COBADEFFXXX"))
+	assert.True(t, ValidBIC("DEUTDEFF"))
+	assert.False(t, ValidBIC("COBADEFF1"))
+
+	assert.True(t, ValidRoutingNumber("021000021"))
+	assert.True(t, ValidRoutingNumber("011000015"))
+	assert.False(t, ValidRoutingNumber("021000022"))
+	assert.False(t, ValidRoutingNumber("02100002"))
+}
+
+// testOriginator has valid details for both formats
+var testOriginator = Originator{
+	Name:          "Acme Payroll",
+	IBAN:          "DE89370400440532013000",
+	BIC:           "COBADEFFXXX",
+	RoutingNumber: "021000021",
+	BankName:      "Chase",
+	CompanyID:     "1234567890",
+}
+
+// TestRenderPain001 tests that a pain.001 file totals its transfers and parses back
+func TestRenderPain001(t *testing.T) {
+	file := &File{
+		ID:         "pain-1",
+		Format:     Pain001,
+		Created:    time.Date(2025, 1, 13, 9, 30, 0, 0, time.UTC),
+		Originator: testOriginator,
+		Payments: []Payment{
+			{ID: "payment-1", Name: "Alice & Co", Amount: money.New(12345, money.EUR), IBAN: "GB82WEST12345698765432", BIC: "NWBKGB2L"},
+			{ID: "payment-2", Name: "Bob", Amount: money.New(5000, money.EUR), IBAN: "DE89370400440532013000"},
+		},
+	}
+	content, err := file.Render()
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(content), xml.Header))
+	assert.Contains(t, string(content), `xmlns="urn:iso:std:iso:20022:tech:xsd:pain.001.001.03"`)
+	assert.Contains(t, string(content), "Alice &amp; Co")
+
+	var doc pain001Document
+	require.NoError(t, xml.Unmarshal(content, &doc))
+	assert.Equal(t, "pain-1", doc.Init.GrpHdr.MsgId)
+	assert.Equal(t, 2, doc.Init.GrpHdr.NbOfTxs)
+	assert.Equal(t, "173.45", doc.Init.GrpHdr.CtrlSum)
+	assert.Equal(t, "2025-01-14", doc.Init.PmtInf.ReqdExctnDt)
+	require.Len(t, doc.Init.PmtInf.CdtTrfTxInf, 2)
+	assert.Equal(t, "payment-1", doc.Init.PmtInf.CdtTrfTxInf[0].EndToEndId)
+	assert.Equal(t, pain001Amount{Ccy: "EUR", Value: "123.45"}, doc.Init.PmtInf.CdtTrfTxInf[0].InstdAmt)
+	assert.Nil(t, doc.Init.PmtInf.CdtTrfTxInf[1].CdtrAgt, "The creditor BIC is optional")
+
+	// Every problem is reported at once
+	file.Payments[1].IBAN = "DE00370400440532013000"
+	file.Payments = append(file.Payments, Payment{ID: "payment-3", Name: "Carol", Amount: money.New(100, money.USD), IBAN: "DE89370400440532013000"})
+	_, err = file.Render()
+	var invalid *ValidationError
+	require.ErrorAs(t, err, &invalid)
+	assert.ErrorIs(t, err, ErrInvalidFile)
+	assert.Equal(t, []string{"payments must share one currency", "payment-2: IBAN is missing or invalid"}, invalid.Problems)
+}
+
+// TestRenderNACHA tests the record layout, control totals and block padding of an ACH file
+func TestRenderNACHA(t *testing.T) {
+	file := &File{
+		ID:            "nacha-1",
+		Format:        NACHA,
+		Created:       time.Date(2025, 1, 13, 9, 30, 0, 0, time.UTC),
+		ExecutionDate: time.Date(2025, 1, 15, 0, 0, 0, 0, time.UTC),
+		Originator:    testOriginator,
+		Payments: []Payment{
+			{ID: "payment-1", Name: "Alice Smith", Amount: money.New(12345, money.USD), RoutingNumber: "011000015", AccountNumber: "12345678"},
+			{ID: "payment-2", Name: "Bob Jones", Amount: money.New(5000, money.USD), RoutingNumber: "021000021", AccountNumber: "987654321", AccountType: "savings"},
+		},
+	}
+	content, err := file.Render()
+	require.NoError(t, err)
+
+	records := strings.Split(strings.TrimSuffix(string(content), "\r\n"), "\r\n")
+	require.Len(t, records, 10, "Six records padded to one block")
+	for _, record := range records {
+		assert.Len(t, record, 94)
+	}
+	assert.Equal(t, "101 0210000211234567890250113093", records[0][:32])
+	assert.Equal(t, "5220ACME PAYROLL", records[1][:16])
+	assert.Equal(t, "PPDPAYOUT    250113250115", records[1][50:75])
+	assert.Equal(t, "622011000015"+"12345678         "+"0000012345"+"PAYMENT-1      "+"ALICE SMITH           "+"  0"+"021000020000001", records[2])
+	assert.Equal(t, "632", records[3][:3], "Savings credit")
+	// Entry hash: 01100001 + 02100002
+	assert.Equal(t, "822000000200032000030000000000000000000173451234567890", records[4][:54])
+	assert.Equal(t, "9000001000001000000020003200003000000000000000000017345", records[5][:55])
+	assert.Equal(t, strings.Repeat("9", 94), records[9])
+
+	file.Payments[0].Amount = money.New(100, money.EUR)
+	file.Payments[1].RoutingNumber = "021000022"
+	_, err = file.Render()
+	var invalid *ValidationError
+	require.ErrorAs(t, err, &invalid)
+	assert.Contains(t, invalid.Problems, "ACH payments must be in USD, not EUR")
+	assert.Contains(t, invalid.Problems, "payment-2: routing number is missing or invalid")
+}
@@ -0,0 +1,53 @@
+package bankfile
+
+import (
+	"math/big"
+	"regexp"
+	"strings"
+)
+
+// bicPattern matches 8 or 11 character bank identifier codes (ISO 9362)
+var bicPattern = regexp.MustCompile(`^[A-Z]{6}[A-Z2-9][A-NP-Z0-9]([A-Z0-9]{3})?$`)
+
+// ValidIBAN reports whether iban is well formed and passes the ISO 13616 mod-97 check
+// Spaces are ignored, letters must be upper case.
+func ValidIBAN(iban string) bool {
+	iban = strings.ReplaceAll(iban, " ", "")
+	if len(iban) < 15 || len(iban) > 34 {
+		return false
+	}
+	var digits strings.Builder
+	for _, r := range iban[4:] + iban[:4] {
+		switch {
+		case r >= '0' && r <= '9':
+			digits.WriteRune(r)
+		case r >= 'A' && r <= 'Z':
+			digits.WriteString(big.NewInt(int64(r - 'A' + 10)).String())
+		default:
+			return false
+		}
+	}
+	n, ok := new(big.Int).SetString(digits.String(), 10)
+	return ok && new(big.Int).Mod(n, big.NewInt(97)).Int64() == 1
+}
+
+// ValidBIC reports whether bic is an 8 or 11 character bank identifier code
+func ValidBIC(bic string) bool {
+	return bicPattern.MatchString(bic)
+}
+
+// ValidRoutingNumber reports whether routing is a 9 digit ABA routing number with a valid check digit
+func ValidRoutingNumber(routing string) bool {
+	if len(routing) != 9 {
+		return false
+	}
+	weights := [9]int{3, 7, 1, 3, 7, 1, 3, 7, 1}
+	sum := 0
+	for i, r := range routing {
+		if r < '0' || r > '9' {
+			return false
+		}
+		sum += int(r-'0') * weights[i]
+	}
+	return sum%10 == 0
+}
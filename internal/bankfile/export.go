@@ -0,0 +1,167 @@
+package bankfile
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/abhi14nexu/mercor-scd/internal/models"
+	"github.com/abhi14nexu/mercor-scd/internal/money"
+	"github.com/abhi14nexu/mercor-scd/internal/scd"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrAlreadyExported is returned when a line item has already been written to a payment file
+	ErrAlreadyExported = errors.New("payment already exported")
+	// ErrNotExportable is returned for line items that are paid, failed or in a payout run
+	ErrNotExportable = errors.New("payment is not exportable")
+	// ErrNoBankAccount is returned when a contractor has no bank details
+	ErrNoBankAccount = errors.New("no bank account")
+	// ErrNothingToExport is returned when no line items are selected
+	ErrNothingToExport = errors.New("no payments to export")
+)
+
+// ExportRequest selects the line items to write to a payment file
+type ExportRequest struct {
+	FileID        string // defaults to ExportFileID
+	Format        Format
+	Currency      money.Currency // defaults to the items' currency, or USD for NACHA and EUR for pain.001 when selecting all
+	PaymentIDs    []string       // business IDs; when empty every exportable item in Currency is included
+	Originator    Originator
+	ExecutionDate time.Time // defaults to the day after the export
+}
+
+// Export is a rendered payment file and the line item versions that record it
+type Export struct {
+	FileID   string                    `json:"file_id"`
+	Format   Format                    `json:"format"`
+	Total    money.Money               `json:"total"`
+	Payments []*models.PaymentLineItem `json:"payments"`
+	Content  []byte                    `json:"-"`
+}
+
+// ExportFileID returns the default ID of a file exported at a time, e.g. "nacha-20261018T125100Z"
+func ExportFileID(format Format, at time.Time) string {
+	return fmt.Sprintf("%s-%s", format, at.UTC().Format("20060102T150405Z"))
+}
+
+// ExportPayments writes not-paid line items with their contractors' bank details to a payment file
+// Exportable items are current, not-paid, not in a payout run and not in another file. The file is
+// validated before anything is stored; each item then gets a new version recording the file ID, all
+// in one unit of work, so an item can never be exported twice.
+func ExportPayments(db *gorm.DB, req ExportRequest) (*Export, error) {
+	if req.Currency == "" && len(req.PaymentIDs) == 0 {
+		req.Currency = money.EUR
+		if req.Format == NACHA {
+			req.Currency = money.USD
+		}
+	}
+
+	var export *Export
+	err := scd.RunUnitOfWork(db, func(u *scd.UnitOfWork) error {
+		if req.FileID == "" {
+			req.FileID = ExportFileID(req.Format, u.Now())
+		}
+		items, err := exportableItems(u.DB(), req)
+		if err != nil {
+			return err
+		}
+
+		var used int64
+		if err := u.DB().Model(&models.PaymentLineItem{}).Where("export_file_id = ?", req.FileID).Count(&used).Error; err != nil {
+			return fmt.Errorf("failed to check payment file %s: %w", req.FileID, err)
+		}
+		if used > 0 {
+			return fmt.Errorf("failed to export payment file %s: %w", req.FileID, scd.ErrAlreadyExists)
+		}
+
+		file := &File{ID: req.FileID, Format: req.Format, Created: u.Now(), ExecutionDate: req.ExecutionDate, Originator: req.Originator}
+		accounts := make(map[string]*models.BankAccount)
+		for _, item := range items {
+			contractorID := item.Job.ContractorID
+			account, ok := accounts[contractorID]
+			if !ok {
+				if account, err = models.FindBankAccount(u.DB(), contractorID); err != nil {
+					return err
+				}
+				if account == nil {
+					return fmt.Errorf("failed to export payment %s: %w for %s", item.ID, ErrNoBankAccount, contractorID)
+				}
+				accounts[contractorID] = account
+			}
+			file.Payments = append(file.Payments, Payment{
+				ID:            item.ID,
+				Name:          account.HolderName,
+				Amount:        item.Amount,
+				IBAN:          account.IBAN,
+				BIC:           account.BIC,
+				RoutingNumber: account.RoutingNumber,
+				AccountNumber: account.AccountNumber,
+				AccountType:   account.AccountType,
+			})
+		}
+
+		content, err := file.Render()
+		if err != nil {
+			return err
+		}
+		total, err := file.Total(file.currency())
+		if err != nil {
+			return err
+		}
+
+		export = &Export{FileID: req.FileID, Format: req.Format, Total: total, Content: content}
+		for _, item := range items {
+			updated, err := scd.UpdateIn(u, item.ID, func(p *models.PaymentLineItem) { p.ExportFileID = req.FileID })
+			if err != nil {
+				return fmt.Errorf("failed to record payment file %s on %s: %w", req.FileID, item.ID, err)
+			}
+			export.Payments = append(export.Payments, updated)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return export, nil
+}
+
+// exportableItems loads the requested line items with their job versions, checking each can be exported
+func exportableItems(db *gorm.DB, req ExportRequest) ([]*models.PaymentLineItem, error) {
+	var items []*models.PaymentLineItem
+	query := db.Scopes(scd.Latest).Preload("Job").Order("id")
+	if len(req.PaymentIDs) > 0 {
+		query = query.Where("id IN ?", req.PaymentIDs)
+	} else {
+		query = query.Where("status = ? AND currency = ? AND payout_run_id = '' AND export_file_id = ''", "not-paid", req.Currency)
+	}
+	if err := query.Find(&items).Error; err != nil {
+		return nil, fmt.Errorf("failed to find payments to export: %w", err)
+	}
+	found := make(map[string]bool, len(items))
+	for _, item := range items {
+		found[item.ID] = true
+		switch {
+		case item.ExportFileID != "":
+			return nil, fmt.Errorf("failed to export payment %s: %w in %s", item.ID, ErrAlreadyExported, item.ExportFileID)
+		case !item.IsNotPaid():
+			return nil, fmt.Errorf("failed to export payment %s: %w (%s)", item.ID, ErrNotExportable, item.Status)
+		case item.PayoutRunID != "":
+			return nil, fmt.Errorf("failed to export payment %s: %w (in payout run %s)", item.ID, ErrNotExportable, item.PayoutRunID)
+		case req.Currency != "" && item.Currency != req.Currency:
+			return nil, fmt.Errorf("failed to export payment %s: %w (%s, file is %s)", item.ID, ErrNotExportable, item.Currency, req.Currency)
+		case item.Job == nil:
+			return nil, fmt.Errorf("failed to export payment %s: job version %s not found", item.ID, item.JobUID)
+		}
+	}
+	for _, id := range req.PaymentIDs {
+		if !found[id] {
+			return nil, fmt.Errorf("failed to find payment %s: %w", id, gorm.ErrRecordNotFound)
+		}
+	}
+	if len(items) == 0 {
+		return nil, fmt.Errorf("failed to export payment file %s: %w in %s", req.FileID, ErrNothingToExport, req.Currency)
+	}
+	return items, nil
+}
@@ -0,0 +1,133 @@
+package bankfile
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/abhi14nexu/mercor-scd/internal/models"
+	"github.com/abhi14nexu/mercor-scd/internal/money"
+	"github.com/abhi14nexu/mercor-scd/internal/scd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// setupExportTestDB creates two not-paid USD items for contractor-alice, who has a US bank account,
+// and one for contractor-bob, who has none
+func setupExportTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	require.NoError(t, models.Register(db))
+
+	monday := time.Date(2025, 1, 6, 9, 0, 0, 0, time.UTC)
+	for _, contractor := range []string{"alice", "bob"} {
+		job, err := scd.CreateNew(db, models.NewJob("job-"+contractor, "Engineer", "company-acme", "contractor-"+contractor, money.FromMajor(50, money.USD)))
+		require.NoError(t, err)
+		days := 2
+		if contractor == "bob" {
+			days = 1
+		}
+		for day := 0; day < days; day++ {
+			start := monday.AddDate(0, 0, day)
+			id := contractor + "-" + start.Format("0102")
+			timelog, err := scd.CreateNew(db, models.NewTimelog("timelog-"+id, job.UID, start, start.Add(time.Hour)))
+			require.NoError(t, err)
+			_, err = scd.CreateNew(db, models.NewCalculatedPaymentLineItem("payment-"+id, job, timelog))
+			require.NoError(t, err)
+		}
+	}
+
+	account := models.NewBankAccount("contractor-alice", "Alice Smith")
+	account.RoutingNumber = "011000015"
+	account.AccountNumber = "12345678"
+	_, err = scd.CreateNew(db, account)
+	require.NoError(t, err)
+	return db
+}
+
+// TestExportPayments tests that exported items record the file and cannot be exported or paid out again
+func TestExportPayments(t *testing.T) {
+	db := setupExportTestDB(t)
+	alice := []string{"payment-alice-0106", "payment-alice-0107"}
+
+	_, err := ExportPayments(db, ExportRequest{Format: NACHA, Originator: testOriginator})
+	assert.ErrorIs(t, err, ErrNoBankAccount, "Selecting every exportable item includes bob's")
+
+	export, err := ExportPayments(db, ExportRequest{FileID: "ach-1", Format: NACHA, PaymentIDs: alice, Originator: testOriginator})
+	require.NoError(t, err)
+	assert.Equal(t, money.FromMajor(100, money.USD), export.Total)
+	assert.Contains(t, string(export.Content), "PAYMENT-ALICE-0")
+	require.Len(t, export.Payments, 2)
+	for _, item := range export.Payments {
+		assert.Equal(t, "ach-1", item.ExportFileID)
+		assert.Equal(t, 2, item.Version)
+		assert.True(t, item.IsNotPaid(), "Items stay not-paid until the bank confirms")
+	}
+
+	_, err = ExportPayments(db, ExportRequest{FileID: "ach-2", Format: NACHA, PaymentIDs: alice[:1], Originator: testOriginator})
+	assert.ErrorIs(t, err, ErrAlreadyExported)
+	_, err = ExportPayments(db, ExportRequest{FileID: "ach-1", Format: NACHA, PaymentIDs: []string{"payment-bob-0106"}, Originator: testOriginator})
+	assert.ErrorIs(t, err, scd.ErrAlreadyExists, "File IDs are not reused")
+
+	period := models.PayPeriod{Start: time.Date(2025, 1, 6, 0, 0, 0, 0, time.UTC), End: time.Date(2025, 1, 13, 0, 0, 0, 0, time.UTC)}
+	_, err = models.CreatePayoutRun(db, "run-1", "contractor-alice", period, money.USD)
+	assert.ErrorIs(t, err, models.ErrEmptyPayoutRun, "Exported items are not paid out again")
+
+	_, err = models.CreatePayoutRun(db, "run-bob", "contractor-bob", period, money.USD)
+	require.NoError(t, err)
+	_, err = ExportPayments(db, ExportRequest{Format: NACHA, Originator: testOriginator})
+	assert.ErrorIs(t, err, ErrNothingToExport, "Items in payout runs are not exported")
+}
+
+// TestExportPaymentsValidatesFile tests that nothing is recorded when the file is invalid
+func TestExportPaymentsValidatesFile(t *testing.T) {
+	db := setupExportTestDB(t)
+
+	_, err := ExportPayments(db, ExportRequest{FileID: "pain-1", Format: Pain001, Currency: money.USD, PaymentIDs: []string{"payment-alice-0106"}, Originator: testOriginator})
+	var invalid *ValidationError
+	require.ErrorAs(t, err, &invalid)
+	assert.Equal(t, []string{"payment-alice-0106: IBAN is missing or invalid"}, invalid.Problems)
+
+	item, err := scd.GetLatest[*models.PaymentLineItem](db, "payment-alice-0106")
+	require.NoError(t, err)
+	assert.Empty(t, item.ExportFileID)
+	assert.Equal(t, 1, item.Version)
+
+	_, err = ExportPayments(db, ExportRequest{Format: NACHA, PaymentIDs: []string{"payment-404"}, Originator: testOriginator})
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	// Adding an IBAN writes a new account version that the next export uses
+	_, err = scd.Update(db, models.BankAccountID("contractor-alice"), func(a *models.BankAccount) { a.IBAN = "GB82WEST12345698765432" })
+	require.NoError(t, err)
+	export, err := ExportPayments(db, ExportRequest{Format: Pain001, PaymentIDs: []string{"payment-alice-0106"}, Originator: testOriginator})
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(export.FileID, "pain.001-"))
+	assert.Contains(t, string(export.Content), "<IBAN>GB82WEST12345698765432</IBAN>")
+	assert.Contains(t, string(export.Content), `<InstdAmt Ccy="USD">50.00</InstdAmt>`)
+}
+
+// TestRecalculateSkipsExportedPayments tests that adjusting a timelog does not re-price an item already sent to the bank
+func TestRecalculateSkipsExportedPayments(t *testing.T) {
+	db := setupExportTestDB(t)
+
+	_, err := ExportPayments(db, ExportRequest{FileID: "ach-1", Format: NACHA, PaymentIDs: []string{"payment-alice-0106"}, Originator: testOriginator})
+	require.NoError(t, err)
+
+	_, err = scd.Update(db, "timelog-alice-0106", func(tl *models.Timelog) { tl.UpdateDuration(180) })
+	require.NoError(t, err)
+	report, err := models.RecalculateForTimelog(db, "timelog-alice-0106")
+	require.NoError(t, err)
+	assert.Empty(t, report.Updated)
+	require.Len(t, report.Skipped, 1)
+	assert.Equal(t, "payment-alice-0106", report.Skipped[0].ID)
+
+	item, err := scd.GetLatest[*models.PaymentLineItem](db, "payment-alice-0106")
+	require.NoError(t, err)
+	assert.Equal(t, money.FromMajor(50, money.USD), item.Amount, "The exported amount is kept")
+	assert.Equal(t, "ach-1", item.ExportFileID)
+}
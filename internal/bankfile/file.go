@@ -0,0 +1,163 @@
+// Package bankfile writes bulk payment files for upload to a bank
+// It renders ISO 20022 pain.001 credit transfer XML and NACHA ACH files, validating every
+// field before anything is written, and exports not-paid line items into them exactly once.
+package bankfile
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/abhi14nexu/mercor-scd/internal/money"
+)
+
+// ErrInvalidFile is wrapped by the ValidationError returned when a file cannot be rendered
+var ErrInvalidFile = errors.New("invalid payment file")
+
+// Format is a bank payment file format
+type Format string
+
+const (
+	Pain001 Format = "pain.001" // ISO 20022 customer credit transfer initiation, version pain.001.001.03
+	NACHA   Format = "nacha"    // NACHA ACH file of PPD credits
+)
+
+// ParseFormat returns the format named by s
+func ParseFormat(s string) (Format, error) {
+	switch Format(strings.ToLower(s)) {
+	case Pain001, "pain001":
+		return Pain001, nil
+	case NACHA, "ach":
+		return NACHA, nil
+	}
+	return "", fmt.Errorf("unknown payment file format %q (use pain.001 or nacha)", s)
+}
+
+// Extension returns the file name extension for the format
+func (f Format) Extension() string {
+	if f == NACHA {
+		return ".ach"
+	}
+	return ".xml"
+}
+
+// ContentType returns the MIME type of files in the format
+func (f Format) ContentType() string {
+	if f == NACHA {
+		return "text/plain"
+	}
+	return "application/xml"
+}
+
+// Originator is the company sending the payments and its bank account
+// pain.001 files need Name, IBAN and BIC; NACHA files need Name, RoutingNumber and CompanyID.
+type Originator struct {
+	Name          string `json:"name"`
+	IBAN          string `json:"iban,omitempty"`
+	BIC           string `json:"bic,omitempty"`
+	RoutingNumber string `json:"routing_number,omitempty"` // ABA routing number of the originating bank
+	BankName      string `json:"bank_name,omitempty"`      // name of the originating bank, for the NACHA file header
+	CompanyID     string `json:"company_id,omitempty"`     // NACHA company identification, usually "1" and the EIN
+}
+
+// Payment is one credit transfer to a contractor
+type Payment struct {
+	ID            string      // end-to-end reference, the line item's business ID
+	Name          string      // account holder
+	Amount        money.Money // must be positive
+	IBAN          string
+	BIC           string
+	RoutingNumber string
+	AccountNumber string
+	AccountType   string // checking or savings
+}
+
+// File is a batch of payments to render in one format
+type File struct {
+	ID            string // message ID, at most 35 characters
+	Format        Format
+	Created       time.Time
+	ExecutionDate time.Time // requested payment date, defaults to the day after Created
+	Originator    Originator
+	Payments      []Payment
+}
+
+// ValidationError lists every problem that stopped a file from being rendered
+type ValidationError struct {
+	FileID   string   `json:"file_id"`
+	Problems []string `json:"problems"`
+}
+
+// Error implements error
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%s %s: %s", ErrInvalidFile, e.FileID, strings.Join(e.Problems, "; "))
+}
+
+// Unwrap lets errors.Is match ErrInvalidFile
+func (e *ValidationError) Unwrap() error {
+	return ErrInvalidFile
+}
+
+// Render validates the file and writes it in its format
+func (f *File) Render() ([]byte, error) {
+	switch f.Format {
+	case Pain001:
+		return f.renderPain001()
+	case NACHA:
+		return f.renderNACHA()
+	}
+	return nil, fmt.Errorf("failed to render %s: unknown format %q", f.ID, f.Format)
+}
+
+// Total returns the sum of the payment amounts in currency
+func (f *File) Total(currency money.Currency) (money.Money, error) {
+	total := money.New(0, currency)
+	for _, p := range f.Payments {
+		var err error
+		if total, err = total.Add(p.Amount); err != nil {
+			return money.Money{}, fmt.Errorf("failed to total %s: %w", f.ID, err)
+		}
+	}
+	return total, nil
+}
+
+// executionDate returns the requested payment date
+func (f *File) executionDate() time.Time {
+	if f.ExecutionDate.IsZero() {
+		return f.Created.UTC().AddDate(0, 0, 1)
+	}
+	return f.ExecutionDate.UTC()
+}
+
+// validateCommon checks what every format requires, returning the problems found
+func (f *File) validateCommon() []string {
+	var problems []string
+	if f.ID == "" || len(f.ID) > 35 {
+		problems = append(problems, "file ID must be 1 to 35 characters")
+	}
+	if f.Originator.Name == "" {
+		problems = append(problems, "originator name is required")
+	}
+	if len(f.Payments) == 0 {
+		problems = append(problems, "file has no payments")
+	}
+	for _, p := range f.Payments {
+		if !p.Amount.IsZero() && !p.Amount.IsNegative() {
+			continue
+		}
+		problems = append(problems, fmt.Sprintf("%s: amount must be positive", p.ID))
+	}
+	if _, err := f.Total(f.currency()); err != nil {
+		problems = append(problems, "payments must share one currency")
+	}
+	return problems
+}
+
+// currency returns the currency of the file's payments
+func (f *File) currency() money.Currency {
+	if len(f.Payments) == 0 {
+		return money.DefaultCurrency
+	}
+	return f.Payments[0].Amount.Currency()
+}
@@ -0,0 +1,150 @@
+package bankfile
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/abhi14nexu/mercor-scd/internal/money"
+)
+
+const (
+	nachaRecordLength   = 94
+	nachaBlockingFactor = 10
+	nachaMaxAmount      = 9999999999 // cents, the width of an entry amount
+)
+
+// renderNACHA validates the file for ACH and writes one PPD batch of credits
+// Records are 94 characters, CRLF terminated, and the file is padded to whole blocks of ten with rows of 9s.
+func (f *File) renderNACHA() ([]byte, error) {
+	problems := f.validateCommon()
+	if currency := f.currency(); currency != money.USD {
+		problems = append(problems, fmt.Sprintf("ACH payments must be in USD, not %s", currency))
+	}
+	if !ValidRoutingNumber(f.Originator.RoutingNumber) {
+		problems = append(problems, "originator routing number is missing or invalid")
+	}
+	if f.Originator.CompanyID == "" || len(f.Originator.CompanyID) > 10 {
+		problems = append(problems, "originator company ID must be 1 to 10 characters")
+	}
+	for _, p := range f.Payments {
+		if p.Name == "" {
+			problems = append(problems, fmt.Sprintf("%s: account holder name is required", p.ID))
+		}
+		if !ValidRoutingNumber(p.RoutingNumber) {
+			problems = append(problems, fmt.Sprintf("%s: routing number is missing or invalid", p.ID))
+		}
+		if p.AccountNumber == "" || len(p.AccountNumber) > 17 {
+			problems = append(problems, fmt.Sprintf("%s: account number must be 1 to 17 characters", p.ID))
+		}
+		if p.AccountType != "" && p.AccountType != "checking" && p.AccountType != "savings" {
+			problems = append(problems, fmt.Sprintf("%s: account type must be checking or savings", p.ID))
+		}
+		if p.Amount.Minor() > nachaMaxAmount {
+			problems = append(problems, fmt.Sprintf("%s: amount exceeds the ACH entry limit", p.ID))
+		}
+	}
+	if len(problems) > 0 {
+		return nil, &ValidationError{FileID: f.ID, Problems: problems}
+	}
+
+	created := f.Created.UTC()
+	odfi := f.Originator.RoutingNumber[:8]
+	companyID := alpha(f.Originator.CompanyID, 10)
+	var records []string
+
+	// File header
+	records = append(records, "1"+"01"+
+		" "+f.Originator.RoutingNumber+
+		companyID+
+		created.Format("060102")+created.Format("1504")+
+		"A"+"094"+"10"+"1"+
+		alpha(f.Originator.BankName, 23)+
+		alpha(f.Originator.Name, 23)+
+		alpha("", 8))
+
+	// Batch header: credits only (220), prearranged payments (PPD)
+	records = append(records, "5"+"220"+
+		alpha(f.Originator.Name, 16)+
+		alpha("", 20)+
+		companyID+
+		"PPD"+
+		alpha("PAYOUT", 10)+
+		created.Format("060102")+
+		f.executionDate().Format("060102")+
+		"   "+"1"+
+		odfi+
+		numeric(1, 7))
+
+	var hash, credits int64
+	for i, p := range f.Payments {
+		code := "22" // checking credit
+		if p.AccountType == "savings" {
+			code = "32"
+		}
+		rdfi, _ := strconv.ParseInt(p.RoutingNumber[:8], 10, 64)
+		hash += rdfi
+		credits += p.Amount.Minor()
+		records = append(records, "6"+code+
+			p.RoutingNumber+
+			alpha(p.AccountNumber, 17)+
+			numeric(p.Amount.Minor(), 10)+
+			alpha(p.ID, 15)+
+			alpha(p.Name, 22)+
+			"  "+"0"+
+			odfi+numeric(int64(i+1), 7))
+	}
+	hash %= 10000000000
+
+	// Batch control
+	records = append(records, "8"+"220"+
+		numeric(int64(len(f.Payments)), 6)+
+		numeric(hash, 10)+
+		numeric(0, 12)+
+		numeric(credits, 12)+
+		companyID+
+		alpha("", 19)+alpha("", 6)+
+		odfi+
+		numeric(1, 7))
+
+	// File control; the block count includes this record
+	blocks := (len(records) + nachaBlockingFactor) / nachaBlockingFactor
+	records = append(records, "9"+
+		numeric(1, 6)+
+		numeric(int64(blocks), 6)+
+		numeric(int64(len(f.Payments)), 8)+
+		numeric(hash, 10)+
+		numeric(0, 12)+
+		numeric(credits, 12)+
+		alpha("", 39))
+	for len(records)%nachaBlockingFactor != 0 {
+		records = append(records, strings.Repeat("9", nachaRecordLength))
+	}
+
+	for i, record := range records {
+		if len(record) != nachaRecordLength {
+			return nil, fmt.Errorf("failed to write %s: record %d is %d characters", f.ID, i+1, len(record))
+		}
+	}
+	return []byte(strings.Join(records, "\r\n") + "\r\n"), nil
+}
+
+// alpha returns s as an upper-case, space-padded NACHA alphanumeric field of width characters
+// Characters outside printable ASCII become spaces; longer values are truncated.
+func alpha(s string, width int) string {
+	field := []rune(strings.ToUpper(s))
+	for i, r := range field {
+		if r < ' ' || r > '~' {
+			field[i] = ' '
+		}
+	}
+	if len(field) > width {
+		field = field[:width]
+	}
+	return string(field) + strings.Repeat(" ", width-len(field))
+}
+
+// numeric returns n as a zero-padded NACHA numeric field of width digits
+func numeric(n int64, width int) string {
+	return fmt.Sprintf("%0*d", width, n)
+}
@@ -0,0 +1,134 @@
+package bankfile
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+)
+
+// pain001Namespace identifies the message version written by renderPain001
+const pain001Namespace = "urn:iso:std:iso:20022:tech:xsd:pain.001.001.03"
+
+// pain001Document is the subset of pain.001.001.03 needed for one batch of credit transfers
+type pain001Document struct {
+	XMLName xml.Name `xml:"Document"`
+	Xmlns   string   `xml:"xmlns,attr"`
+	Init    struct {
+		GrpHdr struct {
+			MsgId    string
+			CreDtTm  string
+			NbOfTxs  int
+			CtrlSum  string
+			InitgPty pain001Party
+		}
+		PmtInf struct {
+			PmtInfId    string
+			PmtMtd      string
+			NbOfTxs     int
+			CtrlSum     string
+			ReqdExctnDt string
+			Dbtr        pain001Party
+			DbtrAcct    pain001Account
+			DbtrAgt     pain001Agent
+			CdtTrfTxInf []pain001Transfer
+		}
+	} `xml:"CstmrCdtTrfInitn"`
+}
+
+type pain001Party struct {
+	Nm string
+}
+
+type pain001Account struct {
+	IBAN string `xml:"Id>IBAN"`
+}
+
+type pain001Agent struct {
+	BIC string `xml:"FinInstnId>BIC"`
+}
+
+type pain001Amount struct {
+	Ccy   string `xml:"Ccy,attr"`
+	Value string `xml:",chardata"`
+}
+
+type pain001Transfer struct {
+	EndToEndId string        `xml:"PmtId>EndToEndId"`
+	InstdAmt   pain001Amount `xml:"Amt>InstdAmt"`
+	CdtrAgt    *pain001Agent `xml:",omitempty"`
+	Cdtr       pain001Party
+	CdtrAcct   pain001Account
+	Ustrd      string `xml:"RmtInf>Ustrd"`
+}
+
+// renderPain001 validates the file for ISO 20022 and writes it as pain.001.001.03 XML
+func (f *File) renderPain001() ([]byte, error) {
+	problems := f.validateCommon()
+	if len(f.Originator.Name) > 140 {
+		problems = append(problems, "originator name must be at most 140 characters")
+	}
+	if !ValidIBAN(f.Originator.IBAN) {
+		problems = append(problems, "originator IBAN is missing or invalid")
+	}
+	if !ValidBIC(f.Originator.BIC) {
+		problems = append(problems, "originator BIC is missing or invalid")
+	}
+	for _, p := range f.Payments {
+		if len(p.ID) > 35 {
+			problems = append(problems, fmt.Sprintf("%s: ID must be at most 35 characters", p.ID))
+		}
+		if p.Name == "" || len(p.Name) > 140 {
+			problems = append(problems, fmt.Sprintf("%s: account holder name must be 1 to 140 characters", p.ID))
+		}
+		if !ValidIBAN(p.IBAN) {
+			problems = append(problems, fmt.Sprintf("%s: IBAN is missing or invalid", p.ID))
+		}
+		if p.BIC != "" && !ValidBIC(p.BIC) {
+			problems = append(problems, fmt.Sprintf("%s: BIC %q is invalid", p.ID, p.BIC))
+		}
+	}
+	if len(problems) > 0 {
+		return nil, &ValidationError{FileID: f.ID, Problems: problems}
+	}
+
+	total, err := f.Total(f.currency())
+	if err != nil {
+		return nil, err
+	}
+	doc := pain001Document{Xmlns: pain001Namespace}
+	header := &doc.Init.GrpHdr
+	header.MsgId = f.ID
+	header.CreDtTm = f.Created.UTC().Format("2006-01-02T15:04:05")
+	header.NbOfTxs = len(f.Payments)
+	header.CtrlSum = total.String()
+	header.InitgPty.Nm = f.Originator.Name
+
+	info := &doc.Init.PmtInf
+	info.PmtInfId = f.ID
+	info.PmtMtd = "TRF"
+	info.NbOfTxs = len(f.Payments)
+	info.CtrlSum = total.String()
+	info.ReqdExctnDt = f.executionDate().Format("2006-01-02")
+	info.Dbtr.Nm = f.Originator.Name
+	info.DbtrAcct.IBAN = strings.ReplaceAll(f.Originator.IBAN, " ", "")
+	info.DbtrAgt.BIC = f.Originator.BIC
+	for _, p := range f.Payments {
+		transfer := pain001Transfer{
+			EndToEndId: p.ID,
+			InstdAmt:   pain001Amount{Ccy: string(p.Amount.Currency()), Value: p.Amount.String()},
+			Cdtr:       pain001Party{Nm: p.Name},
+			CdtrAcct:   pain001Account{IBAN: strings.ReplaceAll(p.IBAN, " ", "")},
+			Ustrd:      p.ID,
+		}
+		if p.BIC != "" {
+			transfer.CdtrAgt = &pain001Agent{BIC: p.BIC}
+		}
+		info.CdtTrfTxInf = append(info.CdtTrfTxInf, transfer)
+	}
+
+	body, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to write %s: %w", f.ID, err)
+	}
+	return append([]byte(xml.Header), append(body, '\n')...), nil
+}
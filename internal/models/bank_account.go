@@ -0,0 +1,58 @@
+package models
+
+import (
+	"fmt"
+
+	"github.com/abhi14nexu/mercor-scd/internal/scd"
+	"gorm.io/gorm"
+)
+
+// BankAccount represents where a contractor is paid, with SCD versioning capabilities
+// Maps to the 'bank_accounts' table in the database. Each contractor has at most one account
+// entity (business ID "bank-<contractor id>") holding IBAN/BIC details for ISO 20022 transfers,
+// US routing and account numbers for ACH, or both; changing banks writes a new version.
+type BankAccount struct {
+	scd.Model `gorm:"embedded"` // Embeds UID, ID, Version, ValidFrom, ValidTo
+
+	// Business-specific fields
+	ContractorID  string `gorm:"type:text;not null" json:"contractor_id" validate:"required"`
+	HolderName    string `gorm:"type:text;not null" json:"holder_name" validate:"required,max=140"`                                                      // name on the account
+	IBAN          string `gorm:"column:iban;type:text" json:"iban,omitempty" validate:"required_without=RoutingNumber,omitempty,alphanum,min=15,max=34"` // international account number, without spaces
+	BIC           string `gorm:"column:bic;type:text" json:"bic,omitempty" validate:"omitempty,bic"`                                                     // bank identifier code of the IBAN's bank
+	RoutingNumber string `gorm:"type:text" json:"routing_number,omitempty" validate:"required_with=AccountNumber,omitempty,numeric,len=9"`               // US ABA routing number
+	AccountNumber string `gorm:"type:text" json:"account_number,omitempty" validate:"required_with=RoutingNumber,omitempty,max=17"`                      // US account number
+	AccountType   string `gorm:"type:text" json:"account_type,omitempty" validate:"omitempty,oneof=checking savings"`                                    // US account type, defaults to checking
+}
+
+// TableName specifies the table name for GORM
+func (BankAccount) TableName() string {
+	return "bank_accounts"
+}
+
+// BankAccountID returns the business ID of a contractor's bank account
+func BankAccountID(contractorID string) string {
+	return "bank-" + contractorID
+}
+
+// NewBankAccount creates an account entity for a contractor; set the IBAN or US account fields before saving
+func NewBankAccount(contractorID, holderName string) *BankAccount {
+	return &BankAccount{
+		Model: scd.Model{
+			ID: BankAccountID(contractorID),
+		},
+		ContractorID: contractorID,
+		HolderName:   holderName,
+	}
+}
+
+// FindBankAccount returns the current bank account of a contractor, or nil if the contractor has none
+func FindBankAccount(db *gorm.DB, contractorID string) (*BankAccount, error) {
+	var accounts []*BankAccount
+	if err := db.Scopes(scd.Latest, scd.ByBusinessID(BankAccountID(contractorID))).Limit(1).Find(&accounts).Error; err != nil {
+		return nil, fmt.Errorf("failed to find bank account of %s: %w", contractorID, err)
+	}
+	if len(accounts) == 0 {
+		return nil, nil
+	}
+	return accounts[0], nil
+}
@@ -0,0 +1,39 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/abhi14nexu/mercor-scd/internal/scd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestBankAccountValidation tests that an account needs an IBAN or complete US details
+func TestBankAccountValidation(t *testing.T) {
+	db := setupModelsTestDB(t)
+
+	_, err := scd.CreateNew(db, NewBankAccount("contractor-alice", "Alice Smith"))
+	assert.ErrorIs(t, err, scd.ErrValidation, "An account needs an IBAN or a routing number")
+
+	account := NewBankAccount("contractor-alice", "Alice Smith")
+	account.RoutingNumber = "011000015"
+	_, err = scd.CreateNew(db, account)
+	assert.ErrorIs(t, err, scd.ErrValidation, "A routing number needs an account number")
+
+	account.AccountNumber = "12345678"
+	account.BIC = "not-a-bic"
+	_, err = scd.CreateNew(db, account)
+	assert.ErrorIs(t, err, scd.ErrValidation)
+
+	account.BIC = ""
+	_, err = scd.CreateNew(db, account)
+	require.NoError(t, err)
+
+	found, err := FindBankAccount(db, "contractor-alice")
+	require.NoError(t, err)
+	require.NotNil(t, found)
+	assert.Equal(t, "bank-contractor-alice", found.ID)
+	missing, err := FindBankAccount(db, "contractor-bob")
+	require.NoError(t, err)
+	assert.Nil(t, missing)
+}
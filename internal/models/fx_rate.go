@@ -0,0 +1,73 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/abhi14nexu/mercor-scd/internal/money"
+	"github.com/abhi14nexu/mercor-scd/internal/scd"
+	"gorm.io/gorm"
+)
+
+// ErrNoFXRate is returned when no exchange rate version is valid for a currency pair at a given time
+var ErrNoFXRate = errors.New("no exchange rate")
+
+// FXRate represents an exchange rate between two currencies with SCD versioning capabilities
+// Maps to the 'fx_rates' table in the database. Each currency pair is one entity (business ID "EUR-USD"),
+// so the rate valid at any moment can be read with scd.AsOf.
+type FXRate struct {
+	scd.Model `gorm:"embedded"` // Embeds UID, ID, Version, ValidFrom, ValidTo
+
+	// Business-specific fields
+	Base  money.Currency     `gorm:"type:text;not null" json:"base" validate:"oneof=USD EUR GBP INR"`               // currency converted from
+	Quote money.Currency     `gorm:"type:text;not null" json:"quote" validate:"oneof=USD EUR GBP INR,nefield=Base"` // currency converted to
+	Rate  money.ExchangeRate `gorm:"type:decimal(18,8);not null" json:"rate" validate:"gt=0"`                       // units of Quote per unit of Base
+}
+
+// TableName specifies the table name for GORM
+func (FXRate) TableName() string {
+	return "fx_rates"
+}
+
+// FXRateID returns the business ID of a currency pair, e.g. "EUR-USD"
+func FXRateID(base, quote money.Currency) string {
+	return string(base) + "-" + string(quote)
+}
+
+// NewFXRate creates a new FXRate for a currency pair
+func NewFXRate(base, quote money.Currency, rate money.ExchangeRate) *FXRate {
+	return &FXRate{
+		Model: scd.Model{
+			ID: FXRateID(base, quote),
+		},
+		Base:  base,
+		Quote: quote,
+		Rate:  rate,
+	}
+}
+
+// UpdateRate updates the exchange rate
+func (r *FXRate) UpdateRate(rate money.ExchangeRate) {
+	r.Rate = rate
+}
+
+// Convert converts an amount in the base currency to the quote currency, rounding half up to a minor unit
+func (r *FXRate) Convert(amount money.Money) (money.Money, error) {
+	if amount.Currency() != r.Base {
+		return money.Money{}, fmt.Errorf("%w: %s rate cannot convert %s", money.ErrCurrencyMismatch, r.ID, amount.Currency())
+	}
+	return amount.Convert(r.Rate, r.Quote, money.HalfUp), nil
+}
+
+// FindFXRate returns the version of the base to quote rate that was valid at the given time
+func FindFXRate(db *gorm.DB, base, quote money.Currency, at time.Time) (*FXRate, error) {
+	var rates []*FXRate
+	if err := db.Scopes(scd.AsOf(at), scd.ByBusinessID(FXRateID(base, quote))).Limit(1).Find(&rates).Error; err != nil {
+		return nil, fmt.Errorf("failed to find %s rate: %w", FXRateID(base, quote), err)
+	}
+	if len(rates) == 0 {
+		return nil, fmt.Errorf("%w for %s at %s", ErrNoFXRate, FXRateID(base, quote), at.Format(time.RFC3339))
+	}
+	return rates[0], nil
+}
@@ -0,0 +1,110 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/abhi14nexu/mercor-scd/internal/money"
+	"github.com/abhi14nexu/mercor-scd/internal/scd"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestFXConversionAtCalculationTime tests that payments use and record the rate valid when they are calculated
+func TestFXConversionAtCalculationTime(t *testing.T) {
+	db := setupModelsTestDB(t)
+
+	// USD to EUR was 0.90 in January and 0.95 from February
+	feb := time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)
+	january := NewFXRate(money.USD, money.EUR, money.MustExchangeRate("0.90"))
+	january.ValidFrom = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	january.ValidTo = &feb
+	february := NewFXRate(money.USD, money.EUR, money.MustExchangeRate("0.95"))
+	february.ValidFrom = feb
+	_, err := scd.Import(db, []*FXRate{january, february})
+	require.NoError(t, err)
+
+	jan15 := time.Date(2025, 1, 15, 0, 0, 0, 0, time.UTC)
+	rate, err := FindFXRate(db, money.USD, money.EUR, jan15)
+	require.NoError(t, err)
+	assert.Equal(t, "0.9", rate.Rate.String())
+	_, err = FindFXRate(db, money.USD, money.EUR, jan15.AddDate(-1, 0, 0))
+	assert.ErrorIs(t, err, ErrNoFXRate)
+	_, err = FindFXRate(db, money.USD, money.INR, jan15)
+	assert.ErrorIs(t, err, ErrNoFXRate)
+
+	job, err := scd.CreateNew(db, NewJob("job-1", "Engineer", "company-acme", "contractor-alice", money.FromMajor(50, money.USD)))
+	require.NoError(t, err)
+	start := time.Date(2025, 1, 15, 9, 0, 0, 0, time.UTC)
+	timelog, err := scd.CreateNew(db, NewTimelog("timelog-1", job.UID, start, start.Add(2*time.Hour)))
+	require.NoError(t, err)
+
+	item, err := CalculatePaymentLineItem(db, "payment-eur", job, timelog, money.EUR, jan15)
+	require.NoError(t, err)
+	_, err = scd.CreateNew(db, item)
+	require.NoError(t, err)
+	usd, err := CalculatePaymentLineItem(db, "payment-usd", job, timelog, money.USD, jan15)
+	require.NoError(t, err)
+	assert.Nil(t, usd.FXRateUID, "No conversion within the job's currency")
+
+	stored, err := scd.GetLatest[*PaymentLineItem](db, "payment-eur")
+	require.NoError(t, err)
+	assert.Equal(t, money.EUR, stored.Currency)
+	assert.Equal(t, money.New(9000, money.EUR), stored.Amount, "$100.00 at 0.90")
+	assert.Equal(t, "€90.00", stored.GetFormattedAmount())
+	require.NotNil(t, stored.FXRateUID)
+	versions, err := scd.GetAllVersions[*FXRate](db, FXRateID(money.USD, money.EUR))
+	require.NoError(t, err)
+	assert.Equal(t, versions[0].UID, *stored.FXRateUID, "The January rate version is recorded")
+
+	// Recalculation keeps the recorded rate even though February's is newer
+	_, err = scd.Update(db, "timelog-1", func(tl *Timelog) { tl.UpdateDuration(180) })
+	require.NoError(t, err)
+	report, err := RecalculateForTimelog(db, "timelog-1")
+	require.NoError(t, err)
+	require.Len(t, report.Updated, 1)
+	assert.Equal(t, money.New(13500, money.EUR), report.Updated[0].Amount)
+	assert.Equal(t, versions[0].UID, *report.Updated[0].FXRateUID)
+}
+
+// TestJobCurrency tests that the currency column and the rate's currency stay in step
+func TestJobCurrency(t *testing.T) {
+	db := setupModelsTestDB(t)
+
+	job, err := scd.CreateNew(db, NewJob("job-1", "Engineer", "company-acme", "contractor-alice", money.FromMajor(40, money.GBP)))
+	require.NoError(t, err)
+	assert.Equal(t, money.GBP, job.Currency)
+
+	_, err = scd.Update(db, "job-1", func(j *Job) { j.Currency = money.INR })
+	require.NoError(t, err)
+	latest, err := scd.GetLatest[*Job](db, "job-1")
+	require.NoError(t, err)
+	assert.Equal(t, money.New(4000, money.INR), latest.Rate, "Changing the currency re-denominates the rate")
+
+	_, err = scd.Update(db, "job-1", func(j *Job) { j.Currency = "JPY" })
+	assert.ErrorIs(t, err, scd.ErrValidation)
+}
+
+// TestCurrencyHooksKeepModelDefaults tests that plain db.Create still assigns UIDs and versions and rejects empty IDs
+func TestCurrencyHooksKeepModelDefaults(t *testing.T) {
+	db := setupModelsTestDB(t)
+
+	job := NewJob("job-1", "Engineer", "company-acme", "contractor-alice", money.FromMajor(50, money.EUR))
+	require.NoError(t, db.Create(job).Error)
+	assert.NotEqual(t, uuid.Nil, job.UID)
+	assert.Equal(t, 1, job.Version)
+	assert.Equal(t, money.EUR, job.Currency)
+
+	start := time.Date(2025, 1, 6, 9, 0, 0, 0, time.UTC)
+	timelog, err := scd.CreateNew(db, NewTimelog("timelog-1", job.UID, start, start.Add(time.Hour)))
+	require.NoError(t, err)
+	item := NewCalculatedPaymentLineItem("payment-1", job, timelog)
+	require.NoError(t, db.Create(item).Error)
+	assert.NotEqual(t, uuid.Nil, item.UID)
+	assert.Equal(t, 1, item.Version)
+	assert.Equal(t, money.EUR, item.Currency)
+
+	assert.Error(t, db.Create(&Job{Title: "No ID", Rate: money.FromMajor(50, money.USD)}).Error)
+	assert.Error(t, db.Create(&PaymentLineItem{JobUID: job.UID, TimelogUID: timelog.UID}).Error)
+}
@@ -1,7 +1,9 @@
 package models
 
 import (
+	"github.com/abhi14nexu/mercor-scd/internal/money"
 	"github.com/abhi14nexu/mercor-scd/internal/scd"
+	"gorm.io/gorm"
 )
 
 // Job represents a job posting with SCD versioning capabilities
@@ -10,11 +12,21 @@ type Job struct {
 	scd.Model `gorm:"embedded"` // Embeds UID, ID, Version, ValidFrom, ValidTo
 
 	// Business-specific fields
-	Status       string  `gorm:"type:text;not null" json:"status" validate:"oneof=extended active paused completed"`
-	Rate         float64 `gorm:"type:decimal(10,2);not null" json:"rate" validate:"gte=0"`
-	Title        string  `gorm:"type:text;not null" json:"title" validate:"required,min=1,max=200"`
-	CompanyID    string  `gorm:"type:text;not null" json:"company_id" validate:"required"`
-	ContractorID string  `gorm:"type:text;not null" json:"contractor_id" validate:"required"`
+	Status       string         `gorm:"type:text;not null" json:"status" validate:"oneof=extended active paused completed"`
+	Rate         money.Money    `gorm:"type:decimal(10,2);not null" json:"rate" validate:"gte=0"`                                  // hourly rate, in Currency
+	Currency     money.Currency `gorm:"type:text;not null;default:USD" json:"currency" validate:"omitempty,oneof=USD EUR GBP INR"` // currency of the rate
+	Title        string         `gorm:"type:text;not null" json:"title" validate:"required,min=1,max=200"`
+	CompanyID    string         `gorm:"type:text;not null" json:"company_id" validate:"required"`
+	ContractorID string         `gorm:"type:text;not null" json:"contractor_id" validate:"required"`
+}
+
+// JobTransitions lists the statuses a job may move to from each status
+// Completed jobs are final.
+var JobTransitions = scd.Transitions{
+	"active":    {"paused", "extended", "completed"},
+	"extended":  {"paused", "completed"},
+	"paused":    {"active", "completed"},
+	"completed": {},
 }
 
 // TableName specifies the table name for GORM
@@ -23,32 +35,53 @@ func (Job) TableName() string {
 }
 
 // NewJob creates a new Job with the given business ID and initial values
-func NewJob(businessID, title, companyID, contractorID string, rate float64) *Job {
+func NewJob(businessID, title, companyID, contractorID string, rate money.Money) *Job {
 	return &Job{
 		Model: scd.Model{
 			ID: businessID,
 		},
 		Status:       "active",
 		Rate:         rate,
+		Currency:     rate.Currency(),
 		Title:        title,
 		CompanyID:    companyID,
 		ContractorID: contractorID,
 	}
 }
 
+// AfterFind expresses the loaded rate in the job's currency
+func (j *Job) AfterFind(tx *gorm.DB) error {
+	j.Rate = j.Rate.WithCurrency(j.Currency)
+	return nil
+}
+
+// BeforeCreate assigns the UID and version like scd.Model, then keeps Currency and the rate's currency in step
+// Currency wins when set, so a patch of {"currency":"EUR"} re-denominates the rate.
+func (j *Job) BeforeCreate(tx *gorm.DB) error {
+	if err := j.Model.BeforeCreate(tx); err != nil {
+		return err
+	}
+	if j.Currency == "" {
+		j.Currency = j.Rate.Currency()
+	}
+	j.Rate = j.Rate.WithCurrency(j.Currency)
+	return nil
+}
+
 // IsActive returns true if the job is currently active
 func (j *Job) IsActive() bool {
 	return j.Status == "active"
 }
 
 // GetHourlyRate returns the hourly rate for this job
-func (j *Job) GetHourlyRate() float64 {
+func (j *Job) GetHourlyRate() money.Money {
 	return j.Rate
 }
 
-// UpdateRate updates the job's hourly rate
-func (j *Job) UpdateRate(newRate float64) {
+// UpdateRate updates the job's hourly rate and currency
+func (j *Job) UpdateRate(newRate money.Money) {
 	j.Rate = newRate
+	j.Currency = newRate.Currency()
 }
 
 // Pause changes the job status to paused
@@ -61,7 +94,27 @@ func (j *Job) Resume() {
 	j.Status = "active"
 }
 
+// Extend marks the job as extended beyond its original term
+func (j *Job) Extend() {
+	j.Status = "extended"
+}
+
 // Complete marks the job as completed
 func (j *Job) Complete() {
 	j.Status = "completed"
 }
+
+// State returns the job status, checked against JobTransitions when a new version is written
+func (j *Job) State() string {
+	return j.Status
+}
+
+// Transitions returns the job status transition table
+func (j *Job) Transitions() scd.Transitions {
+	return JobTransitions
+}
+
+// NextStatuses returns the statuses the job may move to from its current status
+func (j *Job) NextStatuses() []string {
+	return JobTransitions.Next(j.Status)
+}
@@ -0,0 +1,233 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/abhi14nexu/mercor-scd/internal/money"
+	"github.com/abhi14nexu/mercor-scd/internal/scd"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// PayPeriod is the range of timelog start times [Start, End) covered by a pay-period calculation
+// Days and weeks for caps and overtime are UTC calendar days and ISO weeks starting on Monday,
+// so periods should start on a Monday for weekly overtime to see whole weeks.
+type PayPeriod struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+// PayExplanation breaks down how a rule-priced line item's amount was calculated
+// Durations are in milliseconds, like Timelog.Duration.
+type PayExplanation struct {
+	RateRuleUID     *uuid.UUID  `json:"rate_rule_uid,omitempty"` // rule version applied, nil without rules
+	Rate            money.Money `json:"rate"`                    // hourly rate of the job version
+	Worked          int64       `json:"worked"`                  // timelog duration
+	Rounded         int64       `json:"rounded"`                 // after rounding and the minimum
+	Billable        int64       `json:"billable"`                // after the daily cap
+	Regular         int64       `json:"regular"`                 // billable time paid at the rate
+	Overtime        int64       `json:"overtime"`                // billable time paid at OvertimePercent
+	OvertimePercent int         `json:"overtime_percent,omitempty"`
+	Steps           []string    `json:"steps"` // human-readable calculation, in order
+}
+
+// PayPeriodCalculation is the priced outcome of a pay period for one job; nothing is saved until Save
+type PayPeriodCalculation struct {
+	Job    *Job               `json:"job"`
+	Rule   *RateRule          `json:"rule,omitempty"` // nil when the job has no rules
+	Period PayPeriod          `json:"period"`
+	Items  []*PaymentLineItem `json:"items"`  // new line items, one per unbilled timelog
+	Billed []string           `json:"billed"` // timelogs that already have a line item; they still count toward caps and overtime
+	Total  money.Money        `json:"total"`  // sum of Items
+}
+
+// PayPeriodItemID returns the business ID of the line item created for a timelog by a pay-period calculation
+func PayPeriodItemID(timelogID string) string {
+	return "pay-" + timelogID
+}
+
+// CalculatePayPeriod prices the current timelogs of a job that started within period
+// The latest job version and rate rule version are applied; timelogs that already have a
+// current line item are listed in Billed instead of being priced again.
+func CalculatePayPeriod(db *gorm.DB, jobID string, period PayPeriod) (*PayPeriodCalculation, error) {
+	job, err := scd.GetLatest[*Job](db, jobID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find job %s: %w", jobID, err)
+	}
+	rule, err := FindRateRule(db, jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	versions := db.Model(&Job{}).Select("uid").Where("id = ?", jobID)
+	var timelogs []*Timelog
+	if err := db.Scopes(scd.Latest).
+		Where("job_uid IN (?) AND time_start >= ? AND time_start < ?", versions, period.Start.Unix(), period.End.Unix()).
+		Order("time_start, id").
+		Find(&timelogs).Error; err != nil {
+		return nil, fmt.Errorf("failed to find timelogs of %s: %w", jobID, err)
+	}
+
+	billed, err := billedTimelogs(db, timelogs)
+	if err != nil {
+		return nil, err
+	}
+
+	calc := &PayPeriodCalculation{Job: job, Rule: rule, Period: period, Items: []*PaymentLineItem{}, Billed: []string{}, Total: money.New(0, job.Currency)}
+	for i, item := range ApplyRateRule(job, rule, timelogs) {
+		if billed[timelogs[i].ID] {
+			calc.Billed = append(calc.Billed, timelogs[i].ID)
+			continue
+		}
+		if calc.Total, err = calc.Total.Add(item.Amount); err != nil {
+			return nil, fmt.Errorf("failed to total pay period of %s: %w", jobID, err)
+		}
+		calc.Items = append(calc.Items, item)
+	}
+	return calc, nil
+}
+
+// Save creates the calculated line items in one transaction
+// It fails with scd.ErrAlreadyExists if any of them was saved before.
+func (c *PayPeriodCalculation) Save(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, item := range c.Items {
+			if _, err := scd.CreateNew(tx, item); err != nil {
+				return fmt.Errorf("failed to save payment %s: %w", item.ID, err)
+			}
+		}
+		return nil
+	})
+}
+
+// billedTimelogs returns the business IDs of the timelogs referenced by a current line item
+func billedTimelogs(db *gorm.DB, timelogs []*Timelog) (map[string]bool, error) {
+	billed := map[string]bool{}
+	if len(timelogs) == 0 {
+		return billed, nil
+	}
+
+	ids := make([]string, len(timelogs))
+	for i, timelog := range timelogs {
+		ids[i] = timelog.ID
+	}
+	versions := db.Model(&Timelog{}).Select("uid").Where("id IN ?", ids)
+
+	var referenced []string
+	if err := db.Model(&Timelog{}).
+		Where("uid IN (?)", db.Model(&PaymentLineItem{}).Scopes(scd.Latest).Select("timelog_uid").Where("timelog_uid IN (?)", versions)).
+		Distinct().Pluck("id", &referenced).Error; err != nil {
+		return nil, fmt.Errorf("failed to find billed timelogs: %w", err)
+	}
+	for _, id := range referenced {
+		billed[id] = true
+	}
+	return billed, nil
+}
+
+// ApplyRateRule prices timelogs of job under rule and returns one unsaved line item per timelog
+// Timelogs are taken in the given order, which should be by start time. Each is rounded to the
+// nearest increment and raised to the minimum, then time beyond the daily cap is dropped and time
+// beyond the weekly overtime threshold is paid at OvertimePercent. A nil rule bills exact time.
+func ApplyRateRule(job *Job, rule *RateRule, timelogs []*Timelog) []*PaymentLineItem {
+	if rule == nil {
+		rule = &RateRule{}
+	}
+	const minute = int64(time.Minute / time.Millisecond)
+	increment := int64(rule.RoundingMinutes) * minute
+	minimum := int64(rule.MinimumMinutes) * minute
+	dailyCap := int64(rule.DailyCapMinutes) * minute
+	overtimeAfter := int64(rule.WeeklyOvertimeAfterMinutes) * minute
+	overtimePercent := int64(rule.OvertimePercent)
+	if overtimePercent == 0 {
+		overtimePercent = 100
+	}
+
+	perDay := map[string]int64{}
+	perWeek := map[string]int64{}
+	items := make([]*PaymentLineItem, 0, len(timelogs))
+	for _, timelog := range timelogs {
+		start := timelog.GetStartTime().UTC()
+		day := start.Format(time.DateOnly)
+		year, week := start.ISOWeek()
+		weekKey := fmt.Sprintf("%d-W%02d", year, week)
+
+		exp := &PayExplanation{Rate: job.Rate, Worked: timelog.Duration, Steps: []string{}}
+		if rule.UID != uuid.Nil {
+			uid := rule.UID
+			exp.RateRuleUID = &uid
+		}
+		exp.Steps = append(exp.Steps, "worked "+formatMillis(exp.Worked))
+
+		exp.Rounded = exp.Worked
+		if increment > 0 {
+			exp.Rounded = (exp.Worked + increment/2) / increment * increment
+			if exp.Rounded != exp.Worked {
+				exp.Steps = append(exp.Steps, fmt.Sprintf("rounded to %s (nearest %dm)", formatMillis(exp.Rounded), rule.RoundingMinutes))
+			}
+		}
+		if exp.Rounded < minimum {
+			exp.Rounded = minimum
+			exp.Steps = append(exp.Steps, fmt.Sprintf("raised to the %dm minimum", rule.MinimumMinutes))
+		}
+
+		exp.Billable = exp.Rounded
+		if dailyCap > 0 {
+			remaining := max(dailyCap-perDay[day], 0)
+			if exp.Billable > remaining {
+				exp.Steps = append(exp.Steps, fmt.Sprintf("daily cap of %s on %s: %s not billed",
+					formatMillis(dailyCap), day, formatMillis(exp.Billable-remaining)))
+				exp.Billable = remaining
+			}
+		}
+		perDay[day] += exp.Rounded
+
+		exp.Regular = exp.Billable
+		if overtimeAfter > 0 {
+			exp.Regular = min(exp.Billable, max(overtimeAfter-perWeek[weekKey], 0))
+			exp.Overtime = exp.Billable - exp.Regular
+			if exp.Overtime > 0 {
+				exp.OvertimePercent = int(overtimePercent)
+				exp.Steps = append(exp.Steps, fmt.Sprintf("weekly overtime after %s in %s: %s at %d%%",
+					formatMillis(overtimeAfter), weekKey, formatMillis(exp.Overtime), overtimePercent))
+			}
+		}
+		perWeek[weekKey] += exp.Billable
+
+		// One rounding per item: rate * (regular + overtime * percent/100) / hour
+		amount := job.Rate.MulRat(exp.Regular*100+exp.Overtime*overtimePercent, millisecondsPerHour*100, money.HalfUp)
+		if exp.Overtime > 0 {
+			exp.Steps = append(exp.Steps, fmt.Sprintf("%s at %s/h + %s at %d%% = %s",
+				formatMillis(exp.Regular), job.Rate.Format(), formatMillis(exp.Overtime), overtimePercent, amount.Format()))
+		} else {
+			exp.Steps = append(exp.Steps, fmt.Sprintf("%s at %s/h = %s", formatMillis(exp.Billable), job.Rate.Format(), amount.Format()))
+		}
+
+		item := NewPaymentLineItem(PayPeriodItemID(timelog.ID), job.GetUID(), timelog.GetUID(), amount)
+		item.RateRuleUID = exp.RateRuleUID
+		item.Explanation = exp
+		items = append(items, item)
+	}
+	return items
+}
+
+// formatMillis formats a duration in milliseconds as hours and minutes, e.g. "7h30m" or "45m"
+func formatMillis(ms int64) string {
+	d := time.Duration(ms) * time.Millisecond
+	var parts []string
+	if h := int64(d / time.Hour); h > 0 {
+		parts = append(parts, fmt.Sprintf("%dh", h))
+	}
+	if m := int64(d % time.Hour / time.Minute); m > 0 {
+		parts = append(parts, fmt.Sprintf("%dm", m))
+	}
+	if s := d % time.Minute; s > 0 {
+		parts = append(parts, s.String())
+	}
+	if len(parts) == 0 {
+		return "0m"
+	}
+	return strings.Join(parts, "")
+}
@@ -0,0 +1,130 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/abhi14nexu/mercor-scd/internal/money"
+	"github.com/abhi14nexu/mercor-scd/internal/scd"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestApplyRateRule tests rounding, minimums, daily caps and weekly overtime across a pay period
+func TestApplyRateRule(t *testing.T) {
+	job := NewJob("job-1", "Engineer", "company-acme", "contractor-alice", money.FromMajor(40, money.USD))
+	job.UID = uuid.New()
+	rule := NewRateRule("job-1")
+	rule.RoundingMinutes = 15
+	rule.MinimumMinutes = 30
+	rule.DailyCapMinutes = 8 * 60
+	rule.WeeklyOvertimeAfterMinutes = 40 * 60
+
+	// Monday 6 January 2025 starts an ISO week
+	monday := time.Date(2025, 1, 6, 9, 0, 0, 0, time.UTC)
+	logs := []struct {
+		id       string
+		start    time.Time
+		duration time.Duration
+	}{
+		{"monday-1", monday, 52 * time.Minute},
+		{"monday-2", monday.Add(2 * time.Hour), 10 * time.Minute},
+		{"tuesday", monday.AddDate(0, 0, 1), 9 * time.Hour},
+		{"wednesday", monday.AddDate(0, 0, 2), 8 * time.Hour},
+		{"thursday", monday.AddDate(0, 0, 3), 8 * time.Hour},
+		{"friday", monday.AddDate(0, 0, 4), 8 * time.Hour},
+		{"saturday", monday.AddDate(0, 0, 5), 8 * time.Hour},
+		{"next-monday", monday.AddDate(0, 0, 7), 8 * time.Hour},
+	}
+	var timelogs []*Timelog
+	for _, l := range logs {
+		timelog := NewTimelog(l.id, job.UID, l.start, l.start.Add(l.duration))
+		timelog.UID = uuid.New()
+		timelogs = append(timelogs, timelog)
+	}
+
+	items := ApplyRateRule(job, rule, timelogs)
+	require.Len(t, items, len(timelogs))
+	amounts := map[string]string{}
+	for i, item := range items {
+		assert.Equal(t, PayPeriodItemID(timelogs[i].ID), item.ID)
+		assert.Equal(t, timelogs[i].UID, item.TimelogUID)
+		require.NotNil(t, item.Explanation)
+		assert.True(t, item.IsRulePriced())
+		amounts[timelogs[i].ID] = item.Amount.String()
+	}
+
+	assert.Equal(t, "30.00", amounts["monday-1"], "52m rounds to 45m")
+	assert.Equal(t, "20.00", amounts["monday-2"], "10m rounds to 15m, raised to the 30m minimum")
+	assert.Equal(t, "320.00", amounts["tuesday"], "9h is capped at 8h")
+	assert.Equal(t, "345.00", amounts["saturday"], "6h45m regular + 1h15m at 150%")
+	assert.Equal(t, "320.00", amounts["next-monday"], "Overtime resets each week")
+
+	saturday := items[6].Explanation
+	assert.Equal(t, int64(8*time.Hour/time.Millisecond), saturday.Billable)
+	assert.Equal(t, int64(75*time.Minute/time.Millisecond), saturday.Overtime)
+	assert.Equal(t, 150, saturday.OvertimePercent)
+	assert.Contains(t, saturday.Steps, "weekly overtime after 40h in 2025-W02: 1h15m at 150%")
+	assert.Contains(t, items[2].Explanation.Steps, "daily cap of 8h on 2025-01-07: 1h not billed")
+
+	// Without rules time is billed exactly
+	plain := ApplyRateRule(job, nil, timelogs[:1])
+	assert.Equal(t, CalculateAmount(job, timelogs[0]), plain[0].Amount)
+	assert.Nil(t, plain[0].RateRuleUID)
+}
+
+// TestCalculatePayPeriod tests that calculations use the stored rules, skip billed timelogs and save once
+func TestCalculatePayPeriod(t *testing.T) {
+	db := setupModelsTestDB(t)
+
+	job, err := scd.CreateNew(db, NewJob("job-1", "Engineer", "company-acme", "contractor-alice", money.FromMajor(50, money.USD)))
+	require.NoError(t, err)
+	rule := NewRateRule("job-1")
+	rule.RoundingMinutes = 6
+	rule, err = scd.CreateNew(db, rule)
+	require.NoError(t, err)
+
+	monday := time.Date(2025, 1, 6, 9, 0, 0, 0, time.UTC)
+	billed, err := scd.CreateNew(db, NewTimelog("timelog-1", job.UID, monday, monday.Add(time.Hour)))
+	require.NoError(t, err)
+	_, err = scd.CreateNew(db, NewCalculatedPaymentLineItem("payment-1", job, billed))
+	require.NoError(t, err)
+	_, err = scd.CreateNew(db, NewTimelog("timelog-2", job.UID, monday.Add(2*time.Hour), monday.Add(2*time.Hour+52*time.Minute)))
+	require.NoError(t, err)
+	_, err = scd.CreateNew(db, NewTimelog("timelog-3", job.UID, monday.AddDate(0, 0, 7), monday.AddDate(0, 0, 7).Add(time.Hour)))
+	require.NoError(t, err)
+
+	period := PayPeriod{Start: time.Date(2025, 1, 6, 0, 0, 0, 0, time.UTC), End: time.Date(2025, 1, 13, 0, 0, 0, 0, time.UTC)}
+	calc, err := CalculatePayPeriod(db, "job-1", period)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"timelog-1"}, calc.Billed)
+	require.Len(t, calc.Items, 1, "timelog-3 is outside the period")
+	assert.Equal(t, "pay-timelog-2", calc.Items[0].ID)
+	assert.Equal(t, money.New(4500, money.USD), calc.Total, "52m rounds to 54m")
+
+	require.NoError(t, calc.Save(db))
+	assert.ErrorIs(t, calc.Save(db), scd.ErrAlreadyExists)
+
+	stored, err := scd.GetLatest[*PaymentLineItem](db, "pay-timelog-2")
+	require.NoError(t, err)
+	require.NotNil(t, stored.RateRuleUID)
+	assert.Equal(t, rule.UID, *stored.RateRuleUID)
+	require.NotNil(t, stored.Explanation)
+	assert.Equal(t, []string{"worked 52m", "rounded to 54m (nearest 6m)", "54m at $50.00/h = $45.00"}, stored.Explanation.Steps)
+
+	// A second run only sees the saved item as billed
+	again, err := CalculatePayPeriod(db, "job-1", period)
+	require.NoError(t, err)
+	assert.Empty(t, again.Items)
+	assert.Equal(t, []string{"timelog-1", "timelog-2"}, again.Billed)
+
+	// Rule-priced items are left alone by single-item recalculation
+	_, err = scd.Update(db, "timelog-2", func(tl *Timelog) { tl.UpdateDuration(90) })
+	require.NoError(t, err)
+	report, err := RecalculateForTimelog(db, "timelog-2")
+	require.NoError(t, err)
+	assert.Empty(t, report.Updated)
+	require.Len(t, report.Skipped, 1)
+	assert.Equal(t, "pay-timelog-2", report.Skipped[0].ID)
+}
@@ -1,10 +1,12 @@
 package models
 
 import (
-	"fmt"
+	"time"
 
+	"github.com/abhi14nexu/mercor-scd/internal/money"
 	"github.com/abhi14nexu/mercor-scd/internal/scd"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 // PaymentLineItem represents a payment calculation with SCD versioning capabilities
@@ -13,10 +15,42 @@ type PaymentLineItem struct {
 	scd.Model `gorm:"embedded"` // Embeds UID, ID, Version, ValidFrom, ValidTo
 
 	// Business-specific fields
-	JobUID     uuid.UUID `gorm:"type:uuid;not null" json:"job_uid" validate:"required"`                  // FK to specific job version
-	TimelogUID uuid.UUID `gorm:"type:uuid;not null" json:"timelog_uid" validate:"required"`              // FK to specific timelog version
-	Amount     float64   `gorm:"type:decimal(10,2);not null" json:"amount" validate:"gte=0"`             // calculated payment amount
-	Status     string    `gorm:"type:text;not null" json:"status" validate:"oneof=not-paid paid failed"` // not-paid, paid, failed
+	JobUID     uuid.UUID      `gorm:"type:uuid;not null" json:"job_uid" validate:"required"`                                     // FK to specific job version
+	TimelogUID uuid.UUID      `gorm:"type:uuid;not null" json:"timelog_uid" validate:"required"`                                 // FK to specific timelog version
+	Amount     money.Money    `gorm:"type:decimal(10,2);not null" json:"amount" validate:"gte=0"`                                // calculated payment amount
+	Status     string         `gorm:"type:text;not null" json:"status" validate:"oneof=not-paid paid failed"`                    // not-paid, paid, failed
+	Currency   money.Currency `gorm:"type:text;not null;default:USD" json:"currency" validate:"omitempty,oneof=USD EUR GBP INR"` // currency the contractor is paid in
+	FXRateUID  *uuid.UUID     `gorm:"column:fx_rate_uid;type:uuid" json:"fx_rate_uid,omitempty"`                                 // FK to the exchange rate version used, if converted
+
+	// Pay-period pricing, set by ApplyRateRule
+	RateRuleUID *uuid.UUID      `gorm:"type:uuid" json:"rate_rule_uid,omitempty"`               // FK to the rate rule version applied
+	Explanation *PayExplanation `gorm:"type:text;serializer:json" json:"explanation,omitempty"` // how the amount was calculated
+
+	// Payout, set while the item belongs to a payout run
+	PayoutRunID    string `gorm:"type:text;not null;default:''" json:"payout_run_id,omitempty"` // business ID of the run
+	PayoutProvider string `gorm:"type:text" json:"payout_provider,omitempty"`                   // provider the payout was submitted to
+	PayoutRef      string `gorm:"type:text" json:"payout_ref,omitempty"`                        // provider's reference for the payout
+	PayoutStatus   string `gorm:"type:text" json:"payout_status,omitempty"`                     // last status reported by the provider
+	PayoutResponse string `gorm:"type:text" json:"payout_response,omitempty"`                   // last provider response, as received
+
+	// Bank file export, set once the item has been written to a payment file
+	ExportFileID string `gorm:"type:text;not null;default:''" json:"export_file_id,omitempty"` // ID of the pain.001 or NACHA file
+
+	// Relations, loaded on demand
+	Job           *Job      `gorm:"foreignKey:JobUID;references:UID" json:"job,omitempty"`            // db.Preload("Job"): the referenced version
+	Timelog       *Timelog  `gorm:"foreignKey:TimelogUID;references:UID" json:"timelog,omitempty"`    // db.Preload("Timelog"): the referenced version
+	FXRate        *FXRate   `gorm:"foreignKey:FXRateUID;references:UID" json:"fx_rate,omitempty"`     // db.Preload("FXRate"): the exchange rate version used
+	RateRule      *RateRule `gorm:"foreignKey:RateRuleUID;references:UID" json:"rate_rule,omitempty"` // db.Preload("RateRule"): the rate rule version applied
+	LatestJob     *Job      `gorm:"-" scd:"latest:JobUID" json:"latest_job,omitempty"`                // scd.PreloadLatest("LatestJob"): the current version
+	LatestTimelog *Timelog  `gorm:"-" scd:"latest:TimelogUID" json:"latest_timelog,omitempty"`        // scd.PreloadLatest("LatestTimelog"): the current version
+}
+
+// PaymentTransitions lists the statuses a payment line item may move to from each status
+// Paid items are final; failed payments may be retried or reset.
+var PaymentTransitions = scd.Transitions{
+	"not-paid": {"paid", "failed"},
+	"failed":   {"not-paid", "paid"},
+	"paid":     {},
 }
 
 // TableName specifies the table name for GORM
@@ -25,7 +59,7 @@ func (PaymentLineItem) TableName() string {
 }
 
 // NewPaymentLineItem creates a new PaymentLineItem with the given business ID and calculation details
-func NewPaymentLineItem(businessID string, jobUID, timelogUID uuid.UUID, amount float64) *PaymentLineItem {
+func NewPaymentLineItem(businessID string, jobUID, timelogUID uuid.UUID, amount money.Money) *PaymentLineItem {
 	return &PaymentLineItem{
 		Model: scd.Model{
 			ID: businessID,
@@ -34,13 +68,14 @@ func NewPaymentLineItem(businessID string, jobUID, timelogUID uuid.UUID, amount
 		TimelogUID: timelogUID,
 		Amount:     amount,
 		Status:     "not-paid",
+		Currency:   amount.Currency(),
 	}
 }
 
 // CalculateAmount calculates the payment amount based on job rate and timelog duration
-func CalculateAmount(job *Job, timelog *Timelog) float64 {
-	hours := timelog.GetDurationHours()
-	return job.Rate * hours
+// The exact product of the hourly rate and the duration is rounded half up to a cent.
+func CalculateAmount(job *Job, timelog *Timelog) money.Money {
+	return job.Rate.MulRat(timelog.Duration, millisecondsPerHour, money.HalfUp)
 }
 
 // NewCalculatedPaymentLineItem creates a new PaymentLineItem with auto-calculated amount
@@ -55,7 +90,60 @@ func NewCalculatedPaymentLineItem(businessID string, job *Job, timelog *Timelog)
 		TimelogUID: timelog.GetUID(),
 		Amount:     amount,
 		Status:     "not-paid",
+		Currency:   amount.Currency(),
+	}
+}
+
+// NewConvertedPaymentLineItem creates a PaymentLineItem paid in the quote currency of an exchange rate
+// The amount is calculated in the job's currency, converted with fx, and the fx version is recorded.
+func NewConvertedPaymentLineItem(businessID string, job *Job, timelog *Timelog, fx *FXRate) (*PaymentLineItem, error) {
+	amount, err := fx.Convert(CalculateAmount(job, timelog))
+	if err != nil {
+		return nil, err
 	}
+
+	item := NewPaymentLineItem(businessID, job.GetUID(), timelog.GetUID(), amount)
+	fxRateUID := fx.GetUID()
+	item.FXRateUID = &fxRateUID
+	return item, nil
+}
+
+// CalculatePaymentLineItem creates a PaymentLineItem paid in currency
+// When currency differs from the job's, the amount is converted with the exchange rate valid at the
+// calculation time at, and that rate version is recorded on the item.
+func CalculatePaymentLineItem(db *gorm.DB, businessID string, job *Job, timelog *Timelog, currency money.Currency, at time.Time) (*PaymentLineItem, error) {
+	if currency == job.Currency {
+		return NewCalculatedPaymentLineItem(businessID, job, timelog), nil
+	}
+
+	fx, err := FindFXRate(db, job.Currency, currency, at)
+	if err != nil {
+		return nil, err
+	}
+	return NewConvertedPaymentLineItem(businessID, job, timelog, fx)
+}
+
+// AfterFind expresses the loaded amount in the item's currency
+func (p *PaymentLineItem) AfterFind(tx *gorm.DB) error {
+	p.Amount = p.Amount.WithCurrency(p.Currency)
+	return nil
+}
+
+// BeforeCreate assigns the UID and version like scd.Model, then keeps Currency and the amount's currency in step
+func (p *PaymentLineItem) BeforeCreate(tx *gorm.DB) error {
+	if err := p.Model.BeforeCreate(tx); err != nil {
+		return err
+	}
+	if p.Currency == "" {
+		p.Currency = p.Amount.Currency()
+	}
+	p.Amount = p.Amount.WithCurrency(p.Currency)
+	return nil
+}
+
+// IsRulePriced returns true if the amount was calculated by pay-period rules rather than CalculateAmount
+func (p *PaymentLineItem) IsRulePriced() bool {
+	return p.Explanation != nil
 }
 
 // IsNotPaid returns true if payment is pending
@@ -88,17 +176,46 @@ func (p *PaymentLineItem) MarkNotPaid() {
 	p.Status = "not-paid"
 }
 
+// State returns the payment status, checked against PaymentTransitions when a new version is written
+func (p *PaymentLineItem) State() string {
+	return p.Status
+}
+
+// Transitions returns the payment status transition table
+func (p *PaymentLineItem) Transitions() scd.Transitions {
+	return PaymentTransitions
+}
+
+// NextStatuses returns the statuses the payment may move to from its current status
+func (p *PaymentLineItem) NextStatuses() []string {
+	return PaymentTransitions.Next(p.Status)
+}
+
+// ReleaseFailed marks the payment as failed and removes it from its payout run so a new run can pick it up
+func (p *PaymentLineItem) ReleaseFailed() {
+	p.MarkFailed()
+	p.PayoutRunID = ""
+}
+
+// RecordPayout stores what a payout provider reported for the payment
+func (p *PaymentLineItem) RecordPayout(provider, ref, status, response string) {
+	p.PayoutProvider = provider
+	p.PayoutRef = ref
+	p.PayoutStatus = status
+	p.PayoutResponse = response
+}
+
 // UpdateAmount updates the payment amount (useful for adjustments)
-func (p *PaymentLineItem) UpdateAmount(newAmount float64) {
+func (p *PaymentLineItem) UpdateAmount(newAmount money.Money) {
 	p.Amount = newAmount
 }
 
 // GetAmountCents returns the amount in cents for precise currency handling
 func (p *PaymentLineItem) GetAmountCents() int64 {
-	return int64(p.Amount * 100)
+	return p.Amount.Minor()
 }
 
-// GetFormattedAmount returns the amount formatted as a currency string
+// GetFormattedAmount returns the amount formatted as a currency string, e.g. $12.34
 func (p *PaymentLineItem) GetFormattedAmount() string {
-	return fmt.Sprintf("$%.2f", p.Amount)
+	return p.Amount.Format()
 }
@@ -0,0 +1,213 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/abhi14nexu/mercor-scd/internal/money"
+	"github.com/abhi14nexu/mercor-scd/internal/scd"
+	"gorm.io/gorm"
+)
+
+// ErrEmptyPayoutRun is returned when a contractor has no payable line items in the period
+var ErrEmptyPayoutRun = errors.New("no payable line items")
+
+// PayoutRun groups the payable line items of one contractor and pay period into a single payout
+// Maps to the 'payout_runs' table in the database. Member items record the run's business ID in
+// PaymentLineItem.PayoutRunID; status changes of the run move all of them in one unit of work.
+type PayoutRun struct {
+	scd.Model `gorm:"embedded"` // Embeds UID, ID, Version, ValidFrom, ValidTo
+
+	// Business-specific fields
+	ContractorID string         `gorm:"type:text;not null" json:"contractor_id" validate:"required"`
+	PeriodStart  time.Time      `gorm:"not null" json:"period_start" validate:"required"`                             // first timelog start included
+	PeriodEnd    time.Time      `gorm:"not null" json:"period_end" validate:"required,gtfield=PeriodStart"`           // timelog starts before this are included
+	Currency     money.Currency `gorm:"type:text;not null" json:"currency" validate:"required,oneof=USD EUR GBP INR"` // currency of every member item
+	Total        money.Money    `gorm:"type:decimal(12,2);not null" json:"total" validate:"gte=0"`                    // sum of the member amounts
+	ItemCount    int            `gorm:"not null" json:"item_count" validate:"gte=0"`
+	Status       string         `gorm:"type:text;not null" json:"status" validate:"oneof=draft approved submitted settled failed"`
+}
+
+// PayoutRunTransitions lists the statuses a payout run may move to from each status
+// Settled and failed runs are final; the items of a failed run can join a new run.
+var PayoutRunTransitions = scd.Transitions{
+	"draft":     {"approved"},
+	"approved":  {"submitted"},
+	"submitted": {"settled", "failed"},
+	"settled":   {},
+	"failed":    {},
+}
+
+// TableName specifies the table name for GORM
+func (PayoutRun) TableName() string {
+	return "payout_runs"
+}
+
+// PayoutRunID returns the default business ID of a contractor's run starting on a day, e.g. "payout-contractor-alice-20250707-USD"
+func PayoutRunID(contractorID string, start time.Time, currency money.Currency) string {
+	return fmt.Sprintf("payout-%s-%s-%s", contractorID, start.UTC().Format("20060102"), currency)
+}
+
+// AfterFind expresses the loaded total in the run's currency
+func (r *PayoutRun) AfterFind(tx *gorm.DB) error {
+	r.Total = r.Total.WithCurrency(r.Currency)
+	return nil
+}
+
+// State returns the run status, checked against PayoutRunTransitions when a new version is written
+func (r *PayoutRun) State() string {
+	return r.Status
+}
+
+// Transitions returns the payout run status transition table
+func (r *PayoutRun) Transitions() scd.Transitions {
+	return PayoutRunTransitions
+}
+
+// NextStatuses returns the statuses the run may move to from its current status
+func (r *PayoutRun) NextStatuses() []string {
+	return PayoutRunTransitions.Next(r.Status)
+}
+
+// CreatePayoutRun creates a draft run of the contractor's payable line items in currency
+// Payable items are current, not-paid or failed, not in another run or a bank file, and reference a job version of
+// the contractor and a timelog that started within period. Each gets a new version joining the run.
+func CreatePayoutRun(db *gorm.DB, id, contractorID string, period PayPeriod, currency money.Currency) (*PayoutRun, error) {
+	var run *PayoutRun
+	err := scd.RunUnitOfWork(db, func(u *scd.UnitOfWork) error {
+		var items []*PaymentLineItem
+		if err := u.DB().Scopes(
+			scd.Latest,
+			scd.JoinSCD(&Job{}).As("job").ByUID("job_uid"),
+			scd.JoinSCD(&Timelog{}).As("timelog").ByUID("timelog_uid"),
+		).
+			Where("job.contractor_id = ? AND timelog.time_start >= ? AND timelog.time_start < ?", contractorID, period.Start.Unix(), period.End.Unix()).
+			Where("payment_line_items.status IN ? AND payment_line_items.currency = ? AND payment_line_items.payout_run_id = '' AND payment_line_items.export_file_id = ''",
+				[]string{"not-paid", "failed"}, currency).
+			Order("payment_line_items.id").
+			Find(&items).Error; err != nil {
+			return fmt.Errorf("failed to find payable line items: %w", err)
+		}
+		if len(items) == 0 {
+			return fmt.Errorf("failed to create payout run %s: %w for %s in %s", id, ErrEmptyPayoutRun, contractorID, currency)
+		}
+
+		total := money.New(0, currency)
+		for _, item := range items {
+			var err error
+			if total, err = total.Add(item.Amount); err != nil {
+				return fmt.Errorf("failed to total payout run %s: %w", id, err)
+			}
+		}
+
+		var err error
+		run, err = scd.CreateIn(u, &PayoutRun{
+			Model:        scd.Model{ID: id},
+			ContractorID: contractorID,
+			PeriodStart:  period.Start,
+			PeriodEnd:    period.End,
+			Currency:     currency,
+			Total:        total,
+			ItemCount:    len(items),
+			Status:       "draft",
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create payout run %s: %w", id, err)
+		}
+
+		for _, item := range items {
+			if _, err := scd.UpdateIn(u, item.ID, func(p *PaymentLineItem) { p.PayoutRunID = id }); err != nil {
+				return fmt.Errorf("failed to add payment %s to payout run %s: %w", item.ID, id, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return run, nil
+}
+
+// PayoutRunItems returns the current versions of a run's member line items
+func PayoutRunItems(db *gorm.DB, id string) ([]*PaymentLineItem, error) {
+	var items []*PaymentLineItem
+	if err := db.Scopes(scd.Latest).Where("payout_run_id = ?", id).Order("id").Find(&items).Error; err != nil {
+		return nil, fmt.Errorf("failed to find line items of payout run %s: %w", id, err)
+	}
+	return items, nil
+}
+
+// ApprovePayoutRun approves a draft run
+func ApprovePayoutRun(db *gorm.DB, id string) (*PayoutRun, error) {
+	return TransitionPayoutRun(db, id, "approved", nil)
+}
+
+// SubmitPayoutRun marks an approved run as sent for payment
+// See payout.Processor for submitting the items to a payout provider instead.
+func SubmitPayoutRun(db *gorm.DB, id string) (*PayoutRun, error) {
+	return TransitionPayoutRun(db, id, "submitted", nil)
+}
+
+// SettlePayoutRun marks a submitted run as settled and every member line item as paid
+func SettlePayoutRun(db *gorm.DB, id string) (*PayoutRun, error) {
+	return TransitionPayoutRun(db, id, "settled", func(p *PaymentLineItem) { p.MarkPaid() })
+}
+
+// FailPayoutRun marks a submitted run as failed and every member line item as failed
+// The items leave the run so they can be paid in a new one.
+func FailPayoutRun(db *gorm.DB, id string) (*PayoutRun, error) {
+	return TransitionPayoutRun(db, id, "failed", func(p *PaymentLineItem) { p.ReleaseFailed() })
+}
+
+// TransitionPayoutRun moves a run to status and applies mutate to each member item in one unit of work
+// A nil mutate leaves the items unchanged. The run's total and item count are refreshed from the items
+// still in the run afterwards; a failed run keeps the figures of the items it attempted.
+func TransitionPayoutRun(db *gorm.DB, id, status string, mutate func(*PaymentLineItem)) (*PayoutRun, error) {
+	var run *PayoutRun
+	err := scd.RunUnitOfWork(db, func(u *scd.UnitOfWork) error {
+		current, err := scd.GetLatest[*PayoutRun](u.DB(), id)
+		if err != nil {
+			return fmt.Errorf("failed to find payout run %s: %w", id, err)
+		}
+		items, err := PayoutRunItems(u.DB(), id)
+		if err != nil {
+			return err
+		}
+
+		members := items
+		if mutate != nil {
+			members = nil
+			for _, item := range items {
+				updated, err := scd.UpdateIn(u, item.ID, mutate)
+				if err != nil {
+					return fmt.Errorf("failed to update payment %s of payout run %s: %w", item.ID, id, err)
+				}
+				if updated.PayoutRunID == id || status == "failed" {
+					members = append(members, updated)
+				}
+			}
+		}
+
+		total := money.New(0, current.Currency)
+		for _, item := range members {
+			if total, err = total.Add(item.Amount); err != nil {
+				return fmt.Errorf("failed to total payout run %s: %w", id, err)
+			}
+		}
+
+		run, err = scd.UpdateIn(u, id, func(r *PayoutRun) {
+			r.Status = status
+			r.Total = total
+			r.ItemCount = len(members)
+		})
+		if err != nil {
+			return fmt.Errorf("failed to move payout run %s to %s: %w", id, status, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return run, nil
+}
@@ -0,0 +1,144 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/abhi14nexu/mercor-scd/internal/money"
+	"github.com/abhi14nexu/mercor-scd/internal/scd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// seedPayoutItems creates a job per contractor with one timelog and not-paid line item per day
+func seedPayoutItems(t *testing.T, db *gorm.DB, contractor string, days int, start time.Time) {
+	job, err := scd.CreateNew(db, NewJob("job-"+contractor, "Engineer", "company-acme", contractor, money.FromMajor(50, money.USD)))
+	require.NoError(t, err)
+	for day := 0; day < days; day++ {
+		begin := start.AddDate(0, 0, day)
+		id := contractor + "-" + begin.Format("0102")
+		timelog, err := scd.CreateNew(db, NewTimelog("timelog-"+id, job.UID, begin, begin.Add(time.Hour)))
+		require.NoError(t, err)
+		_, err = scd.CreateNew(db, NewCalculatedPaymentLineItem("payment-"+id, job, timelog))
+		require.NoError(t, err)
+	}
+}
+
+// TestPayoutRunLifecycle tests that a run groups a contractor's items and settles them all at once
+func TestPayoutRunLifecycle(t *testing.T) {
+	db := setupModelsTestDB(t)
+
+	monday := time.Date(2025, 1, 6, 9, 0, 0, 0, time.UTC)
+	seedPayoutItems(t, db, "alice", 8, monday) // the eighth day falls in the next week
+	seedPayoutItems(t, db, "bob", 2, monday)
+	period := PayPeriod{Start: time.Date(2025, 1, 6, 0, 0, 0, 0, time.UTC), End: time.Date(2025, 1, 13, 0, 0, 0, 0, time.UTC)}
+
+	id := PayoutRunID("alice", period.Start, money.USD)
+	assert.Equal(t, "payout-alice-20250106-USD", id)
+	run, err := CreatePayoutRun(db, id, "alice", period, money.USD)
+	require.NoError(t, err)
+	assert.Equal(t, "draft", run.Status)
+	assert.Equal(t, 7, run.ItemCount)
+	assert.Equal(t, money.New(35000, money.USD), run.Total)
+
+	items, err := PayoutRunItems(db, id)
+	require.NoError(t, err)
+	require.Len(t, items, 7)
+	for _, item := range items {
+		assert.Equal(t, 2, item.Version, "Joining the run writes a new version")
+		assert.True(t, item.ValidFrom.Equal(run.ValidFrom), "Items join at the run's creation time")
+	}
+
+	// Items already in a run are not picked up again
+	_, err = CreatePayoutRun(db, "payout-again", "alice", period, money.USD)
+	assert.ErrorIs(t, err, ErrEmptyPayoutRun)
+
+	_, err = SettlePayoutRun(db, id)
+	require.ErrorIs(t, err, scd.ErrInvalidTransition, "Drafts must be approved and submitted first")
+
+	_, err = ApprovePayoutRun(db, id)
+	require.NoError(t, err)
+	_, err = SubmitPayoutRun(db, id)
+	require.NoError(t, err)
+	settled, err := SettlePayoutRun(db, id)
+	require.NoError(t, err)
+	assert.Equal(t, "settled", settled.Status)
+	assert.Equal(t, 4, settled.Version)
+
+	items, err = PayoutRunItems(db, id)
+	require.NoError(t, err)
+	for _, item := range items {
+		assert.True(t, item.IsPaid())
+		assert.True(t, item.ValidFrom.Equal(settled.ValidFrom), "Items are paid at the run's settlement time")
+	}
+	bob, err := scd.GetLatest[*PaymentLineItem](db, "payment-bob-0106")
+	require.NoError(t, err)
+	assert.True(t, bob.IsNotPaid(), "Other contractors are untouched")
+
+	_, err = FailPayoutRun(db, id)
+	assert.ErrorIs(t, err, scd.ErrInvalidTransition, "Settled runs are final")
+}
+
+// TestFailedPayoutRunReleasesItems tests that the items of a failed run can join a new run
+func TestFailedPayoutRunReleasesItems(t *testing.T) {
+	db := setupModelsTestDB(t)
+
+	monday := time.Date(2025, 1, 6, 9, 0, 0, 0, time.UTC)
+	seedPayoutItems(t, db, "alice", 2, monday)
+	period := PayPeriod{Start: monday.Truncate(24 * time.Hour), End: monday.AddDate(0, 0, 7)}
+
+	_, err := CreatePayoutRun(db, "payout-1", "alice", period, money.USD)
+	require.NoError(t, err)
+	_, err = CreatePayoutRun(db, "payout-eur", "alice", period, money.EUR)
+	assert.ErrorIs(t, err, ErrEmptyPayoutRun, "Only items in the run's currency are included")
+	_, err = ApprovePayoutRun(db, "payout-1")
+	require.NoError(t, err)
+	_, err = SubmitPayoutRun(db, "payout-1")
+	require.NoError(t, err)
+	failed, err := FailPayoutRun(db, "payout-1")
+	require.NoError(t, err)
+	assert.Equal(t, 2, failed.ItemCount)
+
+	items, err := PayoutRunItems(db, "payout-1")
+	require.NoError(t, err)
+	assert.Empty(t, items)
+	item, err := scd.GetLatest[*PaymentLineItem](db, "payment-alice-0106")
+	require.NoError(t, err)
+	assert.True(t, item.IsFailed())
+
+	retry, err := CreatePayoutRun(db, "payout-2", "alice", period, money.USD)
+	require.NoError(t, err)
+	assert.Equal(t, 2, retry.ItemCount)
+}
+
+// TestRecalculateSkipsPayoutRunItems tests that adjusting a timelog does not re-price the members of an approved run
+func TestRecalculateSkipsPayoutRunItems(t *testing.T) {
+	db := setupModelsTestDB(t)
+
+	monday := time.Date(2025, 1, 6, 9, 0, 0, 0, time.UTC)
+	seedPayoutItems(t, db, "alice", 2, monday)
+	period := PayPeriod{Start: monday.Truncate(24 * time.Hour), End: monday.AddDate(0, 0, 7)}
+	_, err := CreatePayoutRun(db, "payout-1", "alice", period, money.USD)
+	require.NoError(t, err)
+	_, err = ApprovePayoutRun(db, "payout-1")
+	require.NoError(t, err)
+
+	_, err = scd.Update(db, "timelog-alice-0106", func(tl *Timelog) { tl.UpdateDuration(180) })
+	require.NoError(t, err)
+	report, err := RecalculateForTimelog(db, "timelog-alice-0106")
+	require.NoError(t, err)
+	assert.Empty(t, report.Updated)
+	require.Len(t, report.Skipped, 1)
+	assert.Equal(t, "payment-alice-0106", report.Skipped[0].ID)
+
+	item, err := scd.GetLatest[*PaymentLineItem](db, "payment-alice-0106")
+	require.NoError(t, err)
+	assert.Equal(t, money.FromMajor(50, money.USD), item.Amount, "The approved amount is kept")
+
+	_, err = SubmitPayoutRun(db, "payout-1")
+	require.NoError(t, err)
+	settled, err := SettlePayoutRun(db, "payout-1")
+	require.NoError(t, err)
+	assert.Equal(t, money.FromMajor(100, money.USD), settled.Total, "The run settles the total that was approved")
+}
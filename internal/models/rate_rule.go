@@ -0,0 +1,56 @@
+package models
+
+import (
+	"fmt"
+
+	"github.com/abhi14nexu/mercor-scd/internal/scd"
+	"gorm.io/gorm"
+)
+
+// RateRule represents the pay rules of a job's contract with SCD versioning capabilities
+// Maps to the 'rate_rules' table in the database. Each job has at most one rule entity
+// (business ID "rules-<job id>"); changing the contract terms writes a new version.
+type RateRule struct {
+	scd.Model `gorm:"embedded"` // Embeds UID, ID, Version, ValidFrom, ValidTo
+
+	// Business-specific fields
+	JobID                      string `gorm:"type:text;not null" json:"job_id" validate:"required"`                              // business ID of the job
+	RoundingMinutes            int    `gorm:"not null;default:0" json:"rounding_minutes" validate:"gte=0,lte=60"`                // round each timelog to the nearest increment, 0 = exact
+	MinimumMinutes             int    `gorm:"not null;default:0" json:"minimum_minutes" validate:"gte=0"`                        // minimum billable time per timelog
+	DailyCapMinutes            int    `gorm:"not null;default:0" json:"daily_cap_minutes" validate:"gte=0"`                      // maximum billable time per day, 0 = no cap
+	WeeklyOvertimeAfterMinutes int    `gorm:"not null;default:0" json:"weekly_overtime_after_minutes" validate:"gte=0"`          // overtime threshold per week, 0 = no overtime
+	OvertimePercent            int    `gorm:"not null;default:150" json:"overtime_percent" validate:"omitempty,gte=100,lte=500"` // overtime pay as a percentage of the rate
+}
+
+// TableName specifies the table name for GORM
+func (RateRule) TableName() string {
+	return "rate_rules"
+}
+
+// RateRuleID returns the business ID of a job's rules
+func RateRuleID(jobID string) string {
+	return "rules-" + jobID
+}
+
+// NewRateRule creates rules for a job that bill exact time without caps or overtime
+func NewRateRule(jobID string) *RateRule {
+	return &RateRule{
+		Model: scd.Model{
+			ID: RateRuleID(jobID),
+		},
+		JobID:           jobID,
+		OvertimePercent: 150,
+	}
+}
+
+// FindRateRule returns the current rules of a job, or nil if the job has none
+func FindRateRule(db *gorm.DB, jobID string) (*RateRule, error) {
+	var rules []*RateRule
+	if err := db.Scopes(scd.Latest, scd.ByBusinessID(RateRuleID(jobID))).Limit(1).Find(&rules).Error; err != nil {
+		return nil, fmt.Errorf("failed to find rate rules of %s: %w", jobID, err)
+	}
+	if len(rules) == 0 {
+		return nil, nil
+	}
+	return rules[0], nil
+}
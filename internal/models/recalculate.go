@@ -0,0 +1,221 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"reflect"
+
+	"github.com/abhi14nexu/mercor-scd/internal/money"
+	"github.com/abhi14nexu/mercor-scd/internal/scd"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ErrRulePriced is returned when re-pricing a line item whose amount came from pay-period rules
+// Such amounts depend on the other timelogs of the period, so the item alone cannot be re-priced.
+var ErrRulePriced = errors.New("priced by pay-period rules")
+
+// PaidDrift describes a paid line item whose job or timelog has changed since it was calculated
+// Paid items are never re-versioned; the drift is reported so it can be settled by hand.
+type PaidDrift struct {
+	Item       *PaymentLineItem `json:"item"`        // paid version, left untouched
+	JobUID     uuid.UUID        `json:"job_uid"`     // current job version
+	TimelogUID uuid.UUID        `json:"timelog_uid"` // current timelog version
+	Amount     money.Money      `json:"amount"`      // amount for the current versions
+}
+
+// RecalculationReport is the outcome of a recalculation
+type RecalculationReport struct {
+	Updated []*PaymentLineItem `json:"updated"` // new versions of unpaid line items
+	Paid    []PaidDrift        `json:"paid"`    // paid line items that were not touched
+	Skipped []*PaymentLineItem `json:"skipped"` // rule-priced or committed line items that were not touched, see ErrRulePriced and isCommitted
+}
+
+// RecalculateForTimelog re-prices the line items of a timelog after a new timelog version was written
+// Unpaid items (not-paid or failed) get a new version pointing at the current job and timelog
+// versions with an amount from CalculateAmount; paid items are only reported. Converted items keep
+// the exchange rate version they were priced with. Rule-priced items and unpaid items in a payout
+// run or a bank payment file are only reported as skipped.
+func RecalculateForTimelog(db *gorm.DB, timelogID string) (*RecalculationReport, error) {
+	return recalculate(db, &Timelog{}, "timelog_uid", timelogID)
+}
+
+// RecalculateForJob re-prices the line items of a job after a new job version was written, e.g. a rate change
+// See RecalculateForTimelog for how paid and unpaid items are treated.
+func RecalculateForJob(db *gorm.DB, jobID string) (*RecalculationReport, error) {
+	return recalculate(db, &Job{}, "job_uid", jobID)
+}
+
+// UpdateJob writes a new job version and re-prices its line items in the same transaction
+func UpdateJob(db *gorm.DB, jobID string, mutator func(*Job)) (*Job, *RecalculationReport, error) {
+	return updateAndRecalculate(db, jobID, mutator, RecalculateForJob)
+}
+
+// UpdateTimelog writes a new timelog version and re-prices its line items in the same transaction
+func UpdateTimelog(db *gorm.DB, timelogID string, mutator func(*Timelog)) (*Timelog, *RecalculationReport, error) {
+	return updateAndRecalculate(db, timelogID, mutator, RecalculateForTimelog)
+}
+
+// updateAndRecalculate runs scd.Update followed by a recalculation of the entity's line items
+func updateAndRecalculate[T scd.SCDModel](db *gorm.DB, businessID string, mutator func(T),
+	recalc func(*gorm.DB, string) (*RecalculationReport, error)) (T, *RecalculationReport, error) {
+	var updated T
+	var report *RecalculationReport
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if updated, err = scd.Update(tx, businessID, mutator); err != nil {
+			return err
+		}
+		report, err = recalc(tx, businessID)
+		return err
+	})
+	if err != nil {
+		var zero T
+		return zero, nil, err
+	}
+	return updated, report, nil
+}
+
+// RecalculateAfterVersion re-prices line items after the versioning plugin writes a job or timelog version
+//
+//	db.Use(&scd.VersioningPlugin{AfterVersion: models.RecalculateAfterVersion})
+//
+// Line items that could not be re-priced are logged.
+func RecalculateAfterVersion(tx *gorm.DB, version scd.SCDModel) error {
+	var report *RecalculationReport
+	var err error
+	switch v := version.(type) {
+	case *Job:
+		report, err = RecalculateForJob(tx, v.ID)
+	case *Timelog:
+		report, err = RecalculateForTimelog(tx, v.ID)
+	default:
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if len(report.Paid) > 0 || len(report.Skipped) > 0 {
+		log.Printf("recalculated %s %s: %d updated, %d paid left as is, %d skipped",
+			reflect.TypeOf(version).Elem().Name(), version.GetBusinessID(), len(report.Updated), len(report.Paid), len(report.Skipped))
+	}
+	return nil
+}
+
+// recalculate re-prices the current line items referencing any version of the given entity
+func recalculate(db *gorm.DB, referenced interface{}, foreignKey, businessID string) (*RecalculationReport, error) {
+	report := &RecalculationReport{}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		versions := tx.Model(referenced).Select("uid").Where("id = ?", businessID)
+
+		var items []*PaymentLineItem
+		if err := tx.Scopes(scd.Latest).Where(foreignKey+" IN (?)", versions).Order("id").Find(&items).Error; err != nil {
+			return fmt.Errorf("failed to find payment line items: %w", err)
+		}
+
+		for _, item := range items {
+			job, err := currentVersion[*Job](tx, item.JobUID)
+			if err != nil {
+				return err
+			}
+			timelog, err := currentVersion[*Timelog](tx, item.TimelogUID)
+			if err != nil {
+				return err
+			}
+			if job.UID == item.JobUID && timelog.UID == item.TimelogUID {
+				continue // Already calculated from the current versions
+			}
+			if isCommitted(item) {
+				report.Skipped = append(report.Skipped, item)
+				continue
+			}
+
+			amount, err := priceItem(tx, item, job, timelog)
+			if errors.Is(err, ErrRulePriced) {
+				report.Skipped = append(report.Skipped, item)
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			if item.IsPaid() {
+				report.Paid = append(report.Paid, PaidDrift{Item: item, JobUID: job.UID, TimelogUID: timelog.UID, Amount: amount})
+				continue
+			}
+
+			updated, err := scd.Update(tx, item.ID, func(p *PaymentLineItem) {
+				p.JobUID = job.UID
+				p.TimelogUID = timelog.UID
+				p.UpdateAmount(amount)
+			})
+			if err != nil {
+				return fmt.Errorf("failed to recalculate payment %s: %w", item.ID, err)
+			}
+			report.Updated = append(report.Updated, updated)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// isCommitted reports whether an unpaid item's amount has been committed to a payout and must not change
+// The total of an approved or submitted run covers the amounts its members had when they joined, and
+// an exported item's amount has already gone to the bank.
+func isCommitted(item *PaymentLineItem) bool {
+	return !item.IsPaid() && (item.PayoutRunID != "" || item.ExportFileID != "")
+}
+
+// priceItem calculates what a line item should be paid for the given job and timelog versions
+// Items converted from the job's currency are converted again with the exchange rate version they recorded;
+// rule-priced items return ErrRulePriced.
+func priceItem(db *gorm.DB, item *PaymentLineItem, job *Job, timelog *Timelog) (money.Money, error) {
+	var fx *FXRate
+	if item.FXRateUID != nil && !item.IsRulePriced() {
+		fx = &FXRate{}
+		if err := db.Where("uid = ?", *item.FXRateUID).First(fx).Error; err != nil {
+			return money.Money{}, fmt.Errorf("failed to find exchange rate of payment %s: %w", item.ID, err)
+		}
+	}
+	return priceWith(item, job, timelog, fx)
+}
+
+// priceWith prices a line item like priceItem, with its exchange rate version already loaded
+// fx must be set exactly when the item has an FXRateUID.
+func priceWith(item *PaymentLineItem, job *Job, timelog *Timelog, fx *FXRate) (money.Money, error) {
+	if item.IsRulePriced() {
+		return money.Money{}, fmt.Errorf("failed to price payment %s: %w", item.ID, ErrRulePriced)
+	}
+	amount := CalculateAmount(job, timelog)
+	if fx == nil {
+		if amount.Currency() != item.Amount.Currency() {
+			return money.Money{}, fmt.Errorf("failed to price payment %s: %w: job rate is in %s, payment in %s",
+				item.ID, money.ErrCurrencyMismatch, amount.Currency(), item.Amount.Currency())
+		}
+		return amount, nil
+	}
+
+	converted, err := fx.Convert(amount)
+	if err != nil {
+		return money.Money{}, fmt.Errorf("failed to price payment %s: %w", item.ID, err)
+	}
+	return converted, nil
+}
+
+// currentVersion returns the latest version of the entity that the given version belongs to
+// Deleted entities have no latest version, so the given version is returned unchanged.
+func currentVersion[T scd.SCDModel](tx *gorm.DB, uid uuid.UUID) (T, error) {
+	var pinned T
+	if err := tx.Where("uid = ?", uid).First(&pinned).Error; err != nil {
+		return pinned, fmt.Errorf("failed to find version %s: %w", uid, err)
+	}
+
+	latest, err := scd.GetLatest[T](tx, pinned.GetBusinessID())
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return pinned, nil
+	}
+	return latest, err
+}
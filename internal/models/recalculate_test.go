@@ -0,0 +1,137 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/abhi14nexu/mercor-scd/internal/money"
+	"github.com/abhi14nexu/mercor-scd/internal/scd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// setupModelsTestDB opens an in-memory SQLite database with every model migrated
+func setupModelsTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	require.NoError(t, Register(db))
+	return db
+}
+
+// TestRecalculateForTimelog tests that unpaid items are re-versioned and paid ones only reported
+func TestRecalculateForTimelog(t *testing.T) {
+	db := setupModelsTestDB(t)
+
+	job, err := scd.CreateNew(db, NewJob("job-1", "Engineer", "company-acme", "contractor-alice", money.FromMajor(50, money.USD)))
+	require.NoError(t, err)
+	start := time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+	timelog, err := scd.CreateNew(db, NewTimelog("timelog-1", job.UID, start, start.Add(2*time.Hour)))
+	require.NoError(t, err)
+
+	_, err = scd.CreateNew(db, NewCalculatedPaymentLineItem("payment-open", job, timelog))
+	require.NoError(t, err)
+	paid := NewCalculatedPaymentLineItem("payment-paid", job, timelog)
+	paid.MarkPaid()
+	_, err = scd.CreateNew(db, paid)
+	require.NoError(t, err)
+
+	adjusted, err := scd.Update(db, "timelog-1", func(tl *Timelog) { tl.UpdateDuration(180) })
+	require.NoError(t, err)
+
+	report, err := RecalculateForTimelog(db, "timelog-1")
+	require.NoError(t, err)
+	require.Len(t, report.Updated, 1)
+	updated := report.Updated[0]
+	assert.Equal(t, "payment-open", updated.ID)
+	assert.Equal(t, 2, updated.Version)
+	assert.Equal(t, adjusted.UID, updated.TimelogUID)
+	assert.Equal(t, job.UID, updated.JobUID)
+	assert.Equal(t, "150.00", updated.Amount.String())
+
+	require.Len(t, report.Paid, 1)
+	drift := report.Paid[0]
+	assert.Equal(t, "payment-paid", drift.Item.ID)
+	assert.Equal(t, adjusted.UID, drift.TimelogUID)
+	assert.Equal(t, "150.00", drift.Amount.String())
+	versions, err := scd.GetAllVersions[*PaymentLineItem](db, "payment-paid")
+	require.NoError(t, err)
+	require.Len(t, versions, 1, "Paid items are never re-versioned")
+	assert.Equal(t, "100.00", versions[0].Amount.String())
+
+	report, err = RecalculateForTimelog(db, "timelog-1")
+	require.NoError(t, err)
+	assert.Empty(t, report.Updated, "Items already on the current versions are left alone")
+	assert.Len(t, report.Paid, 1)
+}
+
+// TestRecalculateForJob tests that a rate change re-prices unpaid items against the new job version
+func TestRecalculateForJob(t *testing.T) {
+	db := setupModelsTestDB(t)
+
+	job, err := scd.CreateNew(db, NewJob("job-1", "Engineer", "company-acme", "contractor-alice", money.FromMajor(50, money.USD)))
+	require.NoError(t, err)
+	start := time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+	timelog, err := scd.CreateNew(db, NewTimelog("timelog-1", job.UID, start, start.Add(2*time.Hour)))
+	require.NoError(t, err)
+	failed := NewCalculatedPaymentLineItem("payment-failed", job, timelog)
+	failed.MarkFailed()
+	_, err = scd.CreateNew(db, failed)
+	require.NoError(t, err)
+
+	raised, err := scd.Update(db, "job-1", func(j *Job) { j.UpdateRate(money.FromMajor(60, money.USD)) })
+	require.NoError(t, err)
+
+	report, err := RecalculateForJob(db, "job-1")
+	require.NoError(t, err)
+	require.Len(t, report.Updated, 1)
+	assert.Equal(t, raised.UID, report.Updated[0].JobUID)
+	assert.Equal(t, timelog.UID, report.Updated[0].TimelogUID)
+	assert.Equal(t, "120.00", report.Updated[0].Amount.String())
+	assert.Equal(t, "failed", report.Updated[0].Status, "The status is kept")
+	assert.Empty(t, report.Paid)
+
+	report, err = RecalculateForJob(db, "job-404")
+	require.NoError(t, err)
+	assert.Empty(t, report.Updated)
+}
+
+// TestUpdateJobRecalculates tests that UpdateJob and the versioning plugin hook re-price unpaid items
+func TestUpdateJobRecalculates(t *testing.T) {
+	db := setupModelsTestDB(t)
+
+	job, err := scd.CreateNew(db, NewJob("job-1", "Engineer", "company-acme", "contractor-alice", money.FromMajor(50, money.USD)))
+	require.NoError(t, err)
+	start := time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+	timelog, err := scd.CreateNew(db, NewTimelog("timelog-1", job.UID, start, start.Add(2*time.Hour)))
+	require.NoError(t, err)
+	_, err = scd.CreateNew(db, NewCalculatedPaymentLineItem("payment-1", job, timelog))
+	require.NoError(t, err)
+
+	updated, report, err := UpdateJob(db, "job-1", func(j *Job) { j.UpdateRate(money.FromMajor(60, money.USD)) })
+	require.NoError(t, err)
+	require.Len(t, report.Updated, 1)
+	assert.Equal(t, updated.UID, report.Updated[0].JobUID)
+	assert.Equal(t, "120.00", report.Updated[0].Amount.String())
+
+	_, report, err = UpdateTimelog(db, "timelog-1", func(tl *Timelog) { tl.UpdateDuration(180) })
+	require.NoError(t, err)
+	require.Len(t, report.Updated, 1)
+	assert.Equal(t, "180.00", report.Updated[0].Amount.String())
+
+	require.NoError(t, db.Use(&scd.VersioningPlugin{AfterVersion: RecalculateAfterVersion}))
+	latest, err := scd.GetLatest[*Job](db, "job-1")
+	require.NoError(t, err)
+	latest.UpdateRate(money.FromMajor(70, money.USD))
+	require.NoError(t, db.Save(latest).Error)
+
+	payment, err := scd.GetLatest[*PaymentLineItem](db, "payment-1")
+	require.NoError(t, err)
+	assert.Equal(t, 4, payment.Version)
+	assert.Equal(t, latest.UID, payment.JobUID, "Saving through the plugin re-prices against the new version")
+	assert.Equal(t, "210.00", payment.Amount.String())
+}
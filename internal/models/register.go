@@ -2,10 +2,24 @@ package models
 
 import (
 	"fmt"
+	"reflect"
 
+	"github.com/abhi14nexu/mercor-scd/internal/money"
+	"github.com/abhi14nexu/mercor-scd/internal/scd"
 	"gorm.io/gorm"
 )
 
+func init() {
+	// Money fields are validated by their minor units, so `validate:"gte=0"` rejects negative amounts
+	scd.RegisterValidationType(func(field reflect.Value) interface{} {
+		return field.Interface().(money.Money).Minor()
+	}, money.Money{})
+	// Exchange rates are validated by their sign, e.g. `validate:"gt=0"`
+	scd.RegisterValidationType(func(field reflect.Value) interface{} {
+		return field.Interface().(money.ExchangeRate).Sign()
+	}, money.ExchangeRate{})
+}
+
 // Register sets up database models for the given GORM DB instance
 // For SQLite (tests): runs AutoMigrate to create tables
 // For PostgreSQL (production): relies on migration files
@@ -27,7 +41,11 @@ func autoMigrateModels(db *gorm.DB) error {
 	models := []interface{}{
 		&Job{},
 		&Timelog{},
+		&FXRate{},
+		&RateRule{},
 		&PaymentLineItem{},
+		&PayoutRun{},
+		&BankAccount{},
 	}
 
 	for _, model := range models {
@@ -51,7 +69,11 @@ func validateModels(db *gorm.DB) error {
 	models := []interface{}{
 		&Job{},
 		&Timelog{},
+		&FXRate{},
+		&RateRule{},
 		&PaymentLineItem{},
+		&PayoutRun{},
+		&BankAccount{},
 	}
 
 	for _, model := range models {
@@ -69,7 +91,11 @@ func GetAllModels() []interface{} {
 	return []interface{}{
 		&Job{},
 		&Timelog{},
+		&FXRate{},
+		&RateRule{},
 		&PaymentLineItem{},
+		&PayoutRun{},
+		&BankAccount{},
 	}
 }
 
@@ -78,6 +104,10 @@ func TableNames() []string {
 	return []string{
 		Job{}.TableName(),
 		Timelog{}.TableName(),
+		FXRate{}.TableName(),
+		RateRule{}.TableName(),
 		PaymentLineItem{}.TableName(),
+		PayoutRun{}.TableName(),
+		BankAccount{}.TableName(),
 	}
 }
@@ -0,0 +1,254 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/abhi14nexu/mercor-scd/internal/money"
+	"github.com/abhi14nexu/mercor-scd/internal/scd"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Reasons a payment line item's pinned references are reported as stale
+const (
+	StaleTimelogSuperseded  = "timelog_superseded"     // the referenced timelog version is no longer latest
+	StaleJobSuperseded      = "job_superseded"         // the referenced job version is no longer latest
+	StaleJobNotValidAtStart = "job_not_valid_at_start" // the referenced job version was not valid at the timelog's TimeStart
+)
+
+// StaleReference describes a current payment line item whose pinned versions are out of date
+// ExpectedAmount uses the latest timelog version and the job version valid at its TimeStart,
+// or the latest job version when the job did not exist yet at that time. Rule-priced items
+// report their stored amount as expected, see ErrRulePriced.
+type StaleReference struct {
+	PaymentID        string      `json:"payment_id"`
+	PaymentVersion   int         `json:"payment_version"`
+	Status           string      `json:"status"`
+	Reasons          []string    `json:"reasons"`
+	JobUID           uuid.UUID   `json:"job_uid"`                 // referenced job version
+	LatestJobUID     uuid.UUID   `json:"latest_job_uid"`          // current job version
+	ValidJobUID      *uuid.UUID  `json:"valid_job_uid,omitempty"` // job version valid at the timelog's TimeStart
+	TimelogUID       uuid.UUID   `json:"timelog_uid"`             // referenced timelog version
+	LatestTimelogUID uuid.UUID   `json:"latest_timelog_uid"`      // current timelog version
+	Amount           money.Money `json:"amount"`
+	ExpectedAmount   money.Money `json:"expected_amount"`
+	Delta            money.Money `json:"delta"` // ExpectedAmount - Amount
+}
+
+// StaleReferenceFilter narrows a StaleReferences report; empty fields match every item
+type StaleReferenceFilter struct {
+	ContractorID string   // only items whose pinned job version belongs to the contractor
+	PaymentIDs   []string // only these payment business IDs
+}
+
+// staleRefsBatchSize is how many line items StaleReferences checks per round of queries
+var staleRefsBatchSize = 500
+
+// StaleReferences lists the current payment line items with superseded or mistimed job/timelog references
+// Items are ordered by business ID; nothing is modified. See RecalculateForTimelog and RecalculateForJob to fix them.
+func StaleReferences(db *gorm.DB, filter StaleReferenceFilter) ([]StaleReference, error) {
+	itemID := clause.Column{Table: clause.CurrentTable, Name: "id"}
+	query := db.Scopes(scd.Latest).Order(clause.OrderByColumn{Column: itemID}).Limit(staleRefsBatchSize)
+	if filter.ContractorID != "" {
+		query = query.Joins("JOIN jobs ON jobs.uid = payment_line_items.job_uid").
+			Where("jobs.contractor_id = ?", filter.ContractorID)
+	}
+	if len(filter.PaymentIDs) > 0 {
+		query = query.Where("? IN ?", itemID, filter.PaymentIDs)
+	}
+	query = query.Session(&gorm.Session{}) // reused for every batch
+
+	stale := []StaleReference{}
+	after := ""
+	for {
+		var items []*PaymentLineItem
+		if err := query.Where("? > ?", itemID, after).Find(&items).Error; err != nil {
+			return nil, fmt.Errorf("failed to find payment line items: %w", err)
+		}
+		if len(items) == 0 {
+			return stale, nil
+		}
+
+		refs, err := loadReferences(db, items)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range items {
+			ref, err := checkReferences(refs, item)
+			if err != nil {
+				return nil, err
+			}
+			if len(ref.Reasons) > 0 {
+				stale = append(stale, ref)
+			}
+		}
+
+		if len(items) < staleRefsBatchSize {
+			return stale, nil
+		}
+		after = items[len(items)-1].ID
+	}
+}
+
+// itemReferences holds the versions referenced by a batch of line items, loaded together
+type itemReferences struct {
+	jobVersions map[string][]*Job      // every version of each referenced job, by business ID
+	pinnedJobs  map[uuid.UUID]*Job     // referenced job versions, by UID
+	timelogs    map[uuid.UUID]*Timelog // current version of each referenced timelog, by pinned UID
+	fxRates     map[uuid.UUID]*FXRate  // referenced exchange rate versions, by UID
+}
+
+// loadReferences batch-loads the job, timelog and exchange rate versions a set of line items needs
+func loadReferences(db *gorm.DB, items []*PaymentLineItem) (itemReferences, error) {
+	refs := itemReferences{
+		jobVersions: map[string][]*Job{},
+		pinnedJobs:  map[uuid.UUID]*Job{},
+		timelogs:    map[uuid.UUID]*Timelog{},
+		fxRates:     map[uuid.UUID]*FXRate{},
+	}
+
+	var jobUIDs, timelogUIDs, fxUIDs []uuid.UUID
+	for _, item := range items {
+		jobUIDs = append(jobUIDs, item.JobUID)
+		timelogUIDs = append(timelogUIDs, item.TimelogUID)
+		if item.FXRateUID != nil {
+			fxUIDs = append(fxUIDs, *item.FXRateUID)
+		}
+	}
+
+	var pinnedJobs []*Job
+	if err := db.Where("uid IN ?", jobUIDs).Find(&pinnedJobs).Error; err != nil {
+		return refs, fmt.Errorf("failed to find job versions: %w", err)
+	}
+	var jobIDs []string
+	for _, job := range pinnedJobs {
+		refs.pinnedJobs[job.UID] = job
+		jobIDs = append(jobIDs, job.ID)
+	}
+	var jobs []*Job
+	if err := db.Where("id IN ?", jobIDs).Order("version").Find(&jobs).Error; err != nil {
+		return refs, fmt.Errorf("failed to find jobs: %w", err)
+	}
+	for _, job := range jobs {
+		refs.jobVersions[job.ID] = append(refs.jobVersions[job.ID], job)
+	}
+
+	var pinnedTimelogs []*Timelog
+	if err := db.Where("uid IN ?", timelogUIDs).Find(&pinnedTimelogs).Error; err != nil {
+		return refs, fmt.Errorf("failed to find timelog versions: %w", err)
+	}
+	var timelogIDs []string
+	for _, timelog := range pinnedTimelogs {
+		timelogIDs = append(timelogIDs, timelog.ID)
+	}
+	var latestTimelogs []*Timelog
+	if err := db.Scopes(scd.Latest).Where("id IN ?", timelogIDs).Find(&latestTimelogs).Error; err != nil {
+		return refs, fmt.Errorf("failed to find timelogs: %w", err)
+	}
+	latestByID := map[string]*Timelog{}
+	for _, timelog := range latestTimelogs {
+		latestByID[timelog.ID] = timelog
+	}
+	for _, pinned := range pinnedTimelogs {
+		// Deleted timelogs have no latest version, so the pinned one stands in like in currentVersion
+		if latest, ok := latestByID[pinned.ID]; ok {
+			refs.timelogs[pinned.UID] = latest
+		} else {
+			refs.timelogs[pinned.UID] = pinned
+		}
+	}
+
+	if len(fxUIDs) > 0 {
+		var rates []*FXRate
+		if err := db.Where("uid IN ?", fxUIDs).Find(&rates).Error; err != nil {
+			return refs, fmt.Errorf("failed to find exchange rates: %w", err)
+		}
+		for _, rate := range rates {
+			refs.fxRates[rate.UID] = rate
+		}
+	}
+
+	return refs, nil
+}
+
+// currentJob returns the latest version of the job a version belongs to, or the pinned version if it was deleted
+func (r itemReferences) currentJob(pinned *Job) *Job {
+	versions := r.jobVersions[pinned.ID]
+	if latest := versions[len(versions)-1]; latest.ValidTo == nil {
+		return latest
+	}
+	return pinned
+}
+
+// jobAsOf returns the version of a job valid at t, or nil if the job had no version then
+func (r itemReferences) jobAsOf(jobID string, t time.Time) *Job {
+	for _, version := range r.jobVersions[jobID] {
+		if !version.ValidFrom.After(t) && (version.ValidTo == nil || version.ValidTo.After(t)) {
+			return version
+		}
+	}
+	return nil
+}
+
+// checkReferences compares the versions a line item references with the current ones
+func checkReferences(refs itemReferences, item *PaymentLineItem) (StaleReference, error) {
+	ref := StaleReference{
+		PaymentID:      item.ID,
+		PaymentVersion: item.Version,
+		Status:         item.Status,
+		Reasons:        []string{},
+		JobUID:         item.JobUID,
+		TimelogUID:     item.TimelogUID,
+		Amount:         item.Amount,
+	}
+
+	pinned, ok := refs.pinnedJobs[item.JobUID]
+	if !ok {
+		return ref, fmt.Errorf("failed to find version %s: %w", item.JobUID, gorm.ErrRecordNotFound)
+	}
+	timelog, ok := refs.timelogs[item.TimelogUID]
+	if !ok {
+		return ref, fmt.Errorf("failed to find version %s: %w", item.TimelogUID, gorm.ErrRecordNotFound)
+	}
+	job := refs.currentJob(pinned)
+	ref.LatestJobUID, ref.LatestTimelogUID = job.UID, timelog.UID
+
+	if timelog.UID != item.TimelogUID {
+		ref.Reasons = append(ref.Reasons, StaleTimelogSuperseded)
+	}
+	if job.UID != item.JobUID {
+		ref.Reasons = append(ref.Reasons, StaleJobSuperseded)
+	}
+
+	// The job version valid when the work started is the one the payment should be priced with
+	pricedWith := job
+	if valid := refs.jobAsOf(job.ID, timelog.GetStartTime()); valid != nil {
+		ref.ValidJobUID = &valid.UID
+		pricedWith = valid
+		if valid.UID != item.JobUID {
+			ref.Reasons = append(ref.Reasons, StaleJobNotValidAtStart)
+		}
+	}
+
+	var fx *FXRate
+	if item.FXRateUID != nil {
+		if fx, ok = refs.fxRates[*item.FXRateUID]; !ok {
+			return ref, fmt.Errorf("failed to find exchange rate of payment %s: %w", item.ID, gorm.ErrRecordNotFound)
+		}
+	}
+
+	var err error
+	ref.ExpectedAmount, err = priceWith(item, pricedWith, timelog, fx)
+	if errors.Is(err, ErrRulePriced) {
+		ref.ExpectedAmount = item.Amount // depends on the whole pay period, so no delta is reported
+	} else if err != nil {
+		return ref, err
+	}
+	if ref.Delta, err = ref.ExpectedAmount.Sub(item.Amount); err != nil {
+		return ref, fmt.Errorf("failed to compare amounts of payment %s: %w", item.ID, err)
+	}
+	return ref, nil
+}
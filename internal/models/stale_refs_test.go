@@ -0,0 +1,110 @@
+package models
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/abhi14nexu/mercor-scd/internal/money"
+	"github.com/abhi14nexu/mercor-scd/internal/scd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestStaleReferences tests superseded and mistimed references and the amount delta
+func TestStaleReferences(t *testing.T) {
+	db := setupModelsTestDB(t)
+
+	// Rate 50 in January, 60 from February
+	feb := time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)
+	january := NewJob("job-1", "Engineer", "company-acme", "contractor-alice", money.FromMajor(50, money.USD))
+	january.ValidFrom = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	january.ValidTo = &feb
+	february := NewJob("job-1", "Engineer", "company-acme", "contractor-alice", money.FromMajor(60, money.USD))
+	february.ValidFrom = feb
+	_, err := scd.Import(db, []*Job{january, february})
+	require.NoError(t, err)
+	versions, err := scd.GetAllVersions[*Job](db, "job-1")
+	require.NoError(t, err)
+	v1, v2 := versions[0], versions[1]
+
+	jan15 := time.Date(2025, 1, 15, 9, 0, 0, 0, time.UTC)
+	janLog, err := scd.CreateNew(db, NewTimelog("timelog-jan", v1.UID, jan15, jan15.Add(2*time.Hour)))
+	require.NoError(t, err)
+	feb10 := time.Date(2025, 2, 10, 9, 0, 0, 0, time.UTC)
+	febLog, err := scd.CreateNew(db, NewTimelog("timelog-feb", v2.UID, feb10, feb10.Add(2*time.Hour)))
+	require.NoError(t, err)
+
+	for _, item := range []*PaymentLineItem{
+		NewCalculatedPaymentLineItem("payment-current", v2, febLog),    // up to date
+		NewCalculatedPaymentLineItem("payment-mistimed", v2, janLog),   // February rate for January work
+		NewCalculatedPaymentLineItem("payment-superseded", v1, janLog), // timelog adjusted below
+	} {
+		_, err := scd.CreateNew(db, item)
+		require.NoError(t, err)
+	}
+	adjusted, err := scd.Update(db, "timelog-jan", func(tl *Timelog) { tl.UpdateDuration(180) })
+	require.NoError(t, err)
+
+	stale, err := StaleReferences(db, StaleReferenceFilter{})
+	require.NoError(t, err)
+	require.Len(t, stale, 2)
+
+	mistimed := stale[0]
+	assert.Equal(t, "payment-mistimed", mistimed.PaymentID)
+	assert.Equal(t, []string{StaleTimelogSuperseded, StaleJobNotValidAtStart}, mistimed.Reasons)
+	assert.Equal(t, v1.UID, *mistimed.ValidJobUID)
+	assert.Equal(t, "120.00", mistimed.Amount.String())
+	assert.Equal(t, "150.00", mistimed.ExpectedAmount.String(), "Priced with the January rate and the adjusted timelog")
+	assert.Equal(t, "30.00", mistimed.Delta.String())
+
+	superseded := stale[1]
+	assert.Equal(t, "payment-superseded", superseded.PaymentID)
+	assert.Equal(t, []string{StaleTimelogSuperseded, StaleJobSuperseded}, superseded.Reasons)
+	assert.Equal(t, adjusted.UID, superseded.LatestTimelogUID)
+	assert.Equal(t, v2.UID, superseded.LatestJobUID)
+	assert.Equal(t, "50.00", superseded.Delta.String())
+}
+
+// TestStaleReferencesFiltersAndBatches tests the contractor and payment filters across several batches
+func TestStaleReferencesFiltersAndBatches(t *testing.T) {
+	db := setupModelsTestDB(t)
+	defer func(size int) { staleRefsBatchSize = size }(staleRefsBatchSize)
+	staleRefsBatchSize = 2
+
+	start := time.Date(2025, 1, 6, 9, 0, 0, 0, time.UTC)
+	for _, contractor := range []string{"alice", "bob"} {
+		job, err := scd.CreateNew(db, NewJob("job-"+contractor, "Engineer", "company-acme", "contractor-"+contractor, money.FromMajor(50, money.USD)))
+		require.NoError(t, err)
+		for i := 1; i <= 3; i++ {
+			id := fmt.Sprintf("%s-%d", contractor, i)
+			timelog, err := scd.CreateNew(db, NewTimelog("timelog-"+id, job.UID, start, start.Add(time.Hour)))
+			require.NoError(t, err)
+			_, err = scd.CreateNew(db, NewCalculatedPaymentLineItem("payment-"+id, job, timelog))
+			require.NoError(t, err)
+		}
+		_, err = scd.Update(db, "job-"+contractor, func(j *Job) { j.Title = "Senior Engineer" })
+		require.NoError(t, err)
+	}
+
+	paymentIDs := func(stale []StaleReference) []string {
+		ids := []string{}
+		for _, ref := range stale {
+			ids = append(ids, ref.PaymentID)
+		}
+		return ids
+	}
+
+	stale, err := StaleReferences(db, StaleReferenceFilter{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"payment-alice-1", "payment-alice-2", "payment-alice-3", "payment-bob-1", "payment-bob-2", "payment-bob-3"},
+		paymentIDs(stale), "Every batch is checked, in business ID order")
+
+	stale, err = StaleReferences(db, StaleReferenceFilter{ContractorID: "contractor-bob"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"payment-bob-1", "payment-bob-2", "payment-bob-3"}, paymentIDs(stale))
+
+	stale, err = StaleReferences(db, StaleReferenceFilter{ContractorID: "contractor-alice", PaymentIDs: []string{"payment-alice-3", "payment-bob-1"}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"payment-alice-3"}, paymentIDs(stale))
+}
@@ -18,8 +18,15 @@ type Timelog struct {
 	TimeEnd   int64     `gorm:"type:bigint;not null" json:"time_end" validate:"required,gtfield=TimeStart"` // Unix timestamp
 	Type      string    `gorm:"type:text;not null" json:"type" validate:"oneof=captured adjusted"`          // captured, adjusted
 	JobUID    uuid.UUID `gorm:"type:uuid;not null" json:"job_uid" validate:"required"`                      // FK to specific job version
+
+	// Relations, loaded on demand
+	Job       *Job `gorm:"foreignKey:JobUID;references:UID" json:"job,omitempty"` // db.Preload("Job"): the referenced version
+	LatestJob *Job `gorm:"-" scd:"latest:JobUID" json:"latest_job,omitempty"`     // scd.PreloadLatest("LatestJob"): the current version
 }
 
+// millisecondsPerHour converts Duration to hours
+const millisecondsPerHour = 60 * 60 * 1000
+
 // TableName specifies the table name for GORM
 func (Timelog) TableName() string {
 	return "timelogs"
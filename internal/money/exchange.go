@@ -0,0 +1,140 @@
+package money
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// MaxRateDecimals is the number of decimals an ExchangeRate can hold, matching NUMERIC(18,8) columns
+const MaxRateDecimals = 8
+
+// ExchangeRate is an exact conversion factor: one unit of the base currency buys Rate units of the quote currency
+// e.g. 1.0834 for EUR to USD. Like Money it is encoded in JSON as a decimal string.
+type ExchangeRate struct {
+	coef  int64 // rate * 10^scale
+	scale int
+}
+
+// ParseExchangeRate reads a decimal string such as "1.0834" exactly
+// It returns ErrPrecision for more than MaxRateDecimals decimals.
+func ParseExchangeRate(value string) (ExchangeRate, error) {
+	rat, ok := new(big.Rat).SetString(strings.TrimSpace(value))
+	if !ok {
+		return ExchangeRate{}, fmt.Errorf("%w: %q", ErrInvalidAmount, value)
+	}
+	for scale := 0; scale <= MaxRateDecimals; scale++ {
+		coef, exact := divRound(new(big.Int).Mul(rat.Num(), pow10(scale)), rat.Denom(), Down)
+		if !exact {
+			continue
+		}
+		if !coef.IsInt64() {
+			return ExchangeRate{}, fmt.Errorf("%w: %s is out of range", ErrInvalidAmount, value)
+		}
+		return ExchangeRate{coef: coef.Int64(), scale: scale}, nil
+	}
+	return ExchangeRate{}, fmt.Errorf("%w for an exchange rate (max %d): %s", ErrPrecision, MaxRateDecimals, value)
+}
+
+// MustExchangeRate is like ParseExchangeRate but panics on error, for constants and tests
+func MustExchangeRate(value string) ExchangeRate {
+	rate, err := ParseExchangeRate(value)
+	if err != nil {
+		panic(err)
+	}
+	return rate
+}
+
+// Sign returns -1, 0 or 1 depending on the sign of the rate
+func (r ExchangeRate) Sign() int {
+	switch {
+	case r.coef < 0:
+		return -1
+	case r.coef > 0:
+		return 1
+	}
+	return 0
+}
+
+// String returns the rate as a decimal string, e.g. "1.0834"
+func (r ExchangeRate) String() string {
+	return formatDecimal(r.coef, r.scale)
+}
+
+// Convert returns the amount in another currency, m × rate rounded to a minor unit of to with mode
+// The rate must quote to per unit of m's currency.
+func (m Money) Convert(rate ExchangeRate, to Currency, mode RoundingMode) Money {
+	num := new(big.Int).Mul(big.NewInt(m.minor), big.NewInt(rate.coef))
+	den := pow10(rate.scale)
+	if shift := to.Exponent() - m.Currency().Exponent(); shift > 0 {
+		num.Mul(num, pow10(shift))
+	} else {
+		den.Mul(den, pow10(-shift))
+	}
+
+	minor, _ := divRound(num, den, mode)
+	if !minor.IsInt64() {
+		panic("money: amount out of range")
+	}
+	return Money{minor: minor.Int64(), currency: to}
+}
+
+// Value implements driver.Valuer, storing the decimal string in a NUMERIC column
+func (r ExchangeRate) Value() (driver.Value, error) {
+	return r.String(), nil
+}
+
+// Scan implements sql.Scanner for NUMERIC columns
+func (r *ExchangeRate) Scan(src interface{}) error {
+	var text string
+	switch v := src.(type) {
+	case nil:
+		*r = ExchangeRate{}
+		return nil
+	case []byte:
+		text = string(v)
+	case string:
+		text = v
+	case int64:
+		text = strconv.FormatInt(v, 10)
+	case float64:
+		text = strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Errorf("failed to scan %T into exchange rate: %w", src, ErrInvalidAmount)
+	}
+
+	rate, err := ParseExchangeRate(text)
+	if err != nil {
+		return fmt.Errorf("failed to scan exchange rate: %w", err)
+	}
+	*r = rate
+	return nil
+}
+
+// MarshalJSON encodes the rate as a decimal string, e.g. "1.0834"
+func (r ExchangeRate) MarshalJSON() ([]byte, error) {
+	return json.Marshal(r.String())
+}
+
+// UnmarshalJSON accepts a decimal string or a JSON number
+func (r *ExchangeRate) UnmarshalJSON(data []byte) error {
+	text := string(data)
+	if text == "null" {
+		return nil
+	}
+	if strings.HasPrefix(text, `"`) {
+		if err := json.Unmarshal(data, &text); err != nil {
+			return err
+		}
+	}
+
+	rate, err := ParseExchangeRate(text)
+	if err != nil {
+		return err
+	}
+	*r = rate
+	return nil
+}
@@ -0,0 +1,56 @@
+package money
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestParseExchangeRate tests exact parsing and the decimal limit
+func TestParseExchangeRate(t *testing.T) {
+	rate, err := ParseExchangeRate("1.0834")
+	require.NoError(t, err)
+	assert.Equal(t, "1.0834", rate.String())
+	assert.Equal(t, 1, rate.Sign())
+	assert.Equal(t, "83", MustExchangeRate("83").String())
+
+	_, err = ParseExchangeRate("0.123456789")
+	assert.ErrorIs(t, err, ErrPrecision)
+	_, err = ParseExchangeRate("one")
+	assert.ErrorIs(t, err, ErrInvalidAmount)
+}
+
+// TestConvert tests conversion between currencies with explicit rounding
+func TestConvert(t *testing.T) {
+	eur := New(10000, EUR) // €100.00
+	usd := eur.Convert(MustExchangeRate("1.0834"), USD, HalfUp)
+	assert.Equal(t, New(10834, USD), usd)
+
+	inr := New(1, USD).Convert(MustExchangeRate("83.125"), INR, HalfUp) // $0.01 -> ₹0.83125
+	assert.Equal(t, "0.83", inr.String())
+	assert.Equal(t, "0.84", New(1, USD).Convert(MustExchangeRate("83.125"), INR, Up).String())
+	assert.Equal(t, "₹0.83", inr.Format())
+	assert.Equal(t, "£1.00", New(100, GBP).Format())
+}
+
+// TestExchangeRateSQLAndJSON tests the NUMERIC valuer and scanner and the string JSON encoding
+func TestExchangeRateSQLAndJSON(t *testing.T) {
+	value, err := MustExchangeRate("0.00012").Value()
+	require.NoError(t, err)
+	assert.Equal(t, "0.00012", value)
+
+	for _, src := range []interface{}{"1.08340000", []byte("1.0834"), 1.0834} {
+		var rate ExchangeRate
+		require.NoError(t, rate.Scan(src))
+		assert.Equal(t, "1.0834", rate.String(), "%T", src)
+	}
+
+	data, err := json.Marshal(MustExchangeRate("1.25"))
+	require.NoError(t, err)
+	assert.Equal(t, `"1.25"`, string(data))
+	var rate ExchangeRate
+	require.NoError(t, json.Unmarshal([]byte(`0.79`), &rate))
+	assert.Equal(t, "0.79", rate.String())
+}
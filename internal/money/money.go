@@ -0,0 +1,354 @@
+// Package money represents monetary amounts exactly, as integer minor units with a currency code
+// Amounts are stored in NUMERIC columns and encoded in JSON as decimal strings, e.g. "12.34".
+package money
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrCurrencyMismatch is returned when combining amounts in different currencies
+	ErrCurrencyMismatch = errors.New("currency mismatch")
+	// ErrPrecision is returned by Parse when an amount has more decimals than its currency allows
+	ErrPrecision = errors.New("too many decimal places")
+	// ErrInvalidAmount is returned for values that are not decimal numbers
+	ErrInvalidAmount = errors.New("invalid amount")
+)
+
+// Currency is an ISO 4217 currency code
+type Currency string
+
+// Supported currencies
+const (
+	USD Currency = "USD" // US dollar
+	EUR Currency = "EUR" // euro
+	GBP Currency = "GBP" // pound sterling
+	INR Currency = "INR" // Indian rupee
+)
+
+// DefaultCurrency is assumed for amounts without a currency, e.g. values scanned from a NUMERIC column
+const DefaultCurrency = USD
+
+// exponents holds the number of minor-unit decimals of each known currency
+var exponents = map[Currency]int{
+	USD: 2,
+	EUR: 2,
+	GBP: 2,
+	INR: 2,
+}
+
+// symbols holds the display symbol of each known currency
+var symbols = map[Currency]string{
+	USD: "$",
+	EUR: "€",
+	GBP: "£",
+	INR: "₹",
+}
+
+// Exponent returns the number of decimals of the currency's minor unit, 2 if unknown
+func (c Currency) Exponent() int {
+	if exp, ok := exponents[c.orDefault()]; ok {
+		return exp
+	}
+	return 2
+}
+
+// orDefault returns DefaultCurrency for the empty currency
+func (c Currency) orDefault() Currency {
+	if c == "" {
+		return DefaultCurrency
+	}
+	return c
+}
+
+// RoundingMode selects how amounts between two minor units are rounded
+type RoundingMode int
+
+const (
+	// HalfUp rounds to the nearest minor unit, ties away from zero
+	HalfUp RoundingMode = iota
+	// HalfEven rounds to the nearest minor unit, ties to the even one (banker's rounding)
+	HalfEven
+	// Down rounds toward zero (truncates)
+	Down
+	// Up rounds away from zero
+	Up
+)
+
+// Money is an exact amount in the minor units of a currency, e.g. 1234 cents for $12.34
+// The zero Money has no currency; it reads as DefaultCurrency and can be added to any amount.
+type Money struct {
+	minor    int64
+	currency Currency
+}
+
+// New returns an amount of minor units, e.g. New(1234, USD) is $12.34
+func New(minor int64, currency Currency) Money {
+	return Money{minor: minor, currency: currency}
+}
+
+// FromMajor returns a whole amount of major units, e.g. FromMajor(50, USD) is $50.00
+func FromMajor(units int64, currency Currency) Money {
+	return Money{minor: units * pow10(currency.Exponent()).Int64(), currency: currency}
+}
+
+// Parse reads a decimal string such as "12.34" exactly
+// It returns ErrPrecision if the amount has more decimals than the currency allows.
+func Parse(value string, currency Currency) (Money, error) {
+	amount, exact, err := parse(value, currency, Down)
+	if err != nil {
+		return Money{}, err
+	}
+	if !exact {
+		return Money{}, fmt.Errorf("%w for %s: %s", ErrPrecision, currency.orDefault(), value)
+	}
+	return amount, nil
+}
+
+// ParseRound reads a decimal string, rounding extra decimals with mode
+func ParseRound(value string, currency Currency, mode RoundingMode) (Money, error) {
+	amount, _, err := parse(value, currency, mode)
+	return amount, err
+}
+
+// FromFloat converts a float using its shortest decimal representation, so 0.29 is 29 cents
+// Only meant for values that were floats to begin with; prefer Parse and New.
+func FromFloat(value float64, currency Currency, mode RoundingMode) Money {
+	amount, _, _ := parse(strconv.FormatFloat(value, 'f', -1, 64), currency, mode)
+	return amount
+}
+
+// parse converts a decimal string to minor units and reports whether no rounding was needed
+func parse(value string, currency Currency, mode RoundingMode) (Money, bool, error) {
+	rat, ok := new(big.Rat).SetString(strings.TrimSpace(value))
+	if !ok {
+		return Money{}, false, fmt.Errorf("%w: %q", ErrInvalidAmount, value)
+	}
+	num := new(big.Int).Mul(rat.Num(), pow10(currency.Exponent()))
+	minor, exact := divRound(num, rat.Denom(), mode)
+	if !minor.IsInt64() {
+		return Money{}, false, fmt.Errorf("%w: %s is out of range", ErrInvalidAmount, value)
+	}
+	return Money{minor: minor.Int64(), currency: currency}, exact, nil
+}
+
+// Minor returns the amount in minor units, e.g. cents
+func (m Money) Minor() int64 {
+	return m.minor
+}
+
+// Currency returns the currency of the amount, DefaultCurrency if none was set
+func (m Money) Currency() Currency {
+	return m.currency.orDefault()
+}
+
+// WithCurrency returns the same number of minor units in another currency
+func (m Money) WithCurrency(currency Currency) Money {
+	m.currency = currency
+	return m
+}
+
+// IsZero reports whether the amount is zero
+func (m Money) IsZero() bool {
+	return m.minor == 0
+}
+
+// IsNegative reports whether the amount is below zero
+func (m Money) IsNegative() bool {
+	return m.minor < 0
+}
+
+// Add returns m + other, or ErrCurrencyMismatch
+func (m Money) Add(other Money) (Money, error) {
+	currency, err := m.common(other)
+	if err != nil {
+		return Money{}, err
+	}
+	return Money{minor: m.minor + other.minor, currency: currency}, nil
+}
+
+// Sub returns m - other, or ErrCurrencyMismatch
+func (m Money) Sub(other Money) (Money, error) {
+	currency, err := m.common(other)
+	if err != nil {
+		return Money{}, err
+	}
+	return Money{minor: m.minor - other.minor, currency: currency}, nil
+}
+
+// common returns the currency shared by two amounts; the zero Money takes the other's currency
+func (m Money) common(other Money) (Currency, error) {
+	switch {
+	case m.currency == other.currency:
+		return m.currency, nil
+	case m.currency == "" && m.minor == 0:
+		return other.currency, nil
+	case other.currency == "" && other.minor == 0:
+		return m.currency, nil
+	case m.Currency() == other.Currency():
+		return m.Currency(), nil
+	}
+	return "", fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.Currency(), other.Currency())
+}
+
+// Mul returns the amount multiplied by a whole quantity
+func (m Money) Mul(quantity int64) Money {
+	return m.MulRat(quantity, 1, HalfUp)
+}
+
+// MulRat returns the amount multiplied by num/den, rounded to a minor unit with mode
+// e.g. an hourly rate times a duration in milliseconds: rate.MulRat(ms, 3600000, money.HalfUp).
+// It panics if den is zero or the result does not fit in int64 minor units.
+func (m Money) MulRat(num, den int64, mode RoundingMode) Money {
+	if den == 0 {
+		panic("money: division by zero")
+	}
+	product := new(big.Int).Mul(big.NewInt(m.minor), big.NewInt(num))
+	minor, _ := divRound(product, big.NewInt(den), mode)
+	if !minor.IsInt64() {
+		panic("money: amount out of range")
+	}
+	return Money{minor: minor.Int64(), currency: m.currency}
+}
+
+// Div returns the amount divided by n, rounded with mode, e.g. for averages
+func (m Money) Div(n int64, mode RoundingMode) Money {
+	return m.MulRat(1, n, mode)
+}
+
+// Float64 returns the amount in major units as a float, for display and charts only
+func (m Money) Float64() float64 {
+	f, _ := strconv.ParseFloat(m.String(), 64)
+	return f
+}
+
+// String returns the amount as a decimal string in major units, e.g. "12.34"
+func (m Money) String() string {
+	return formatDecimal(m.minor, m.Currency().Exponent())
+}
+
+// Format returns the amount for display, e.g. "$12.34", or "12.34 EUR" without a known symbol
+func (m Money) Format() string {
+	if symbol, ok := symbols[m.Currency()]; ok {
+		if m.minor < 0 {
+			return "-" + symbol + m.String()[1:]
+		}
+		return symbol + m.String()
+	}
+	return m.String() + " " + string(m.Currency())
+}
+
+// Value implements driver.Valuer, storing the decimal string in a NUMERIC column
+func (m Money) Value() (driver.Value, error) {
+	return m.String(), nil
+}
+
+// Scan implements sql.Scanner for NUMERIC columns
+// Postgres returns the exact decimal text; SQLite may return an integer or a float, which is read
+// through its shortest decimal representation. The currency of m is kept, DefaultCurrency if none.
+func (m *Money) Scan(src interface{}) error {
+	var text string
+	switch v := src.(type) {
+	case nil:
+		*m = Money{currency: m.currency}
+		return nil
+	case []byte:
+		text = string(v)
+	case string:
+		text = v
+	case int64:
+		text = strconv.FormatInt(v, 10)
+	case float64:
+		text = strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Errorf("failed to scan %T into money: %w", src, ErrInvalidAmount)
+	}
+
+	amount, err := ParseRound(text, m.Currency(), HalfUp)
+	if err != nil {
+		return fmt.Errorf("failed to scan money: %w", err)
+	}
+	*m = amount
+	return nil
+}
+
+// MarshalJSON encodes the amount as a decimal string, e.g. "12.34"
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(m.String())
+}
+
+// UnmarshalJSON accepts a decimal string or a JSON number; extra decimals are rejected
+// The currency of m is kept, DefaultCurrency if none.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	text := string(data)
+	if text == "null" {
+		return nil
+	}
+	if strings.HasPrefix(text, `"`) {
+		if err := json.Unmarshal(data, &text); err != nil {
+			return err
+		}
+	}
+
+	amount, err := Parse(text, m.Currency())
+	if err != nil {
+		return err
+	}
+	*m = amount
+	return nil
+}
+
+// divRound divides num by den, rounding with mode, and reports whether the division was exact
+func divRound(num, den *big.Int, mode RoundingMode) (*big.Int, bool) {
+	quo, rem := new(big.Int).QuoRem(num, den, new(big.Int))
+	if rem.Sign() == 0 {
+		return quo, true
+	}
+
+	// Direction away from zero
+	step := big.NewInt(int64(num.Sign() * den.Sign()))
+	// Compare the remainder with half the divisor: 2|rem| vs |den|
+	half := new(big.Int).Abs(rem)
+	cmp := half.Lsh(half, 1).Cmp(new(big.Int).Abs(den))
+
+	switch mode {
+	case Up:
+		quo.Add(quo, step)
+	case HalfUp:
+		if cmp >= 0 {
+			quo.Add(quo, step)
+		}
+	case HalfEven:
+		if cmp > 0 || (cmp == 0 && quo.Bit(0) == 1) {
+			quo.Add(quo, step)
+		}
+	}
+	return quo, false
+}
+
+// formatDecimal writes value / 10^exp with exactly exp decimals
+func formatDecimal(value int64, exp int) string {
+	digits := strconv.FormatInt(value, 10)
+	sign := ""
+	if value < 0 {
+		sign, digits = "-", digits[1:]
+	}
+	if exp == 0 {
+		return sign + digits
+	}
+	if len(digits) <= exp {
+		digits = strings.Repeat("0", exp-len(digits)+1) + digits
+	}
+	return sign + digits[:len(digits)-exp] + "." + digits[len(digits)-exp:]
+}
+
+// pow10 returns 10^exp
+func pow10(exp int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(exp)), nil)
+}
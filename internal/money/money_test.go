@@ -0,0 +1,111 @@
+package money
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestParseAndString tests exact decimal parsing and formatting
+func TestParseAndString(t *testing.T) {
+	for input, minor := range map[string]int64{"0.29": 29, "12.34": 1234, "50": 5000, "-0.05": -5, "1e2": 10000, " 7.1 ": 710} {
+		amount, err := Parse(input, USD)
+		require.NoError(t, err, input)
+		assert.Equal(t, minor, amount.Minor(), input)
+	}
+
+	_, err := Parse("0.291", USD)
+	assert.ErrorIs(t, err, ErrPrecision)
+	_, err = Parse("abc", USD)
+	assert.ErrorIs(t, err, ErrInvalidAmount)
+
+	assert.Equal(t, "0.29", New(29, USD).String())
+	assert.Equal(t, "-0.05", New(-5, USD).String())
+	assert.Equal(t, "1234.00", FromMajor(1234, USD).String())
+	assert.Equal(t, "$12.34", New(1234, USD).Format())
+	assert.Equal(t, "-$0.05", New(-5, USD).Format())
+	assert.Equal(t, "12.34 CHF", New(1234, "CHF").Format())
+	assert.Equal(t, USD, Money{}.Currency(), "The zero Money reads as the default currency")
+}
+
+// TestRoundingModes tests every rounding mode on ties, non-ties and negative amounts
+func TestRoundingModes(t *testing.T) {
+	cases := []struct {
+		input string
+		mode  RoundingMode
+		minor int64
+	}{
+		{"0.125", HalfUp, 13},
+		{"-0.125", HalfUp, -13},
+		{"0.125", HalfEven, 12},
+		{"0.135", HalfEven, 14},
+		{"-0.125", HalfEven, -12},
+		{"0.126", HalfEven, 13},
+		{"0.129", Down, 12},
+		{"-0.129", Down, -12},
+		{"0.121", Up, 13},
+		{"-0.121", Up, -13},
+	}
+	for _, c := range cases {
+		amount, err := ParseRound(c.input, USD, c.mode)
+		require.NoError(t, err)
+		assert.Equal(t, c.minor, amount.Minor(), "%s with mode %d", c.input, c.mode)
+	}
+
+	assert.Equal(t, int64(29), FromFloat(0.29, USD, Down).Minor(), "Floats are read through their shortest representation")
+}
+
+// TestArithmetic tests currency checks and exact multiplication
+func TestArithmetic(t *testing.T) {
+	sum, err := New(150, USD).Add(New(275, USD))
+	require.NoError(t, err)
+	assert.Equal(t, int64(425), sum.Minor())
+
+	total := Money{}
+	total, err = total.Add(New(100, "EUR"))
+	require.NoError(t, err, "The zero Money adopts the other currency")
+	assert.Equal(t, Currency("EUR"), total.Currency())
+
+	_, err = New(100, USD).Sub(New(100, "EUR"))
+	assert.ErrorIs(t, err, ErrCurrencyMismatch)
+
+	rate := New(5000, USD) // $50.00 per hour
+	assert.Equal(t, int64(12500), rate.MulRat(9000000, 3600000, HalfUp).Minor(), "2.5 hours")
+	assert.Equal(t, int64(1667), New(5000, USD).Div(3, HalfUp).Minor())
+	assert.Equal(t, int64(1666), New(5000, USD).Div(3, Down).Minor())
+	assert.Equal(t, int64(15000), rate.Mul(3).Minor())
+}
+
+// TestSQLAndJSON tests the NUMERIC valuer and scanner and the string JSON encoding
+func TestSQLAndJSON(t *testing.T) {
+	value, err := New(29, USD).Value()
+	require.NoError(t, err)
+	assert.Equal(t, "0.29", value)
+
+	for _, src := range []interface{}{"0.29", []byte("0.29"), 0.29} {
+		var amount Money
+		require.NoError(t, amount.Scan(src))
+		assert.Equal(t, int64(29), amount.Minor(), "%T", src)
+	}
+	var whole Money
+	require.NoError(t, whole.Scan(int64(50)))
+	assert.Equal(t, int64(5000), whole.Minor())
+	assert.Error(t, whole.Scan(true))
+
+	data, err := json.Marshal(struct {
+		Amount Money `json:"amount"`
+	}{New(1234, USD)})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"amount":"12.34"}`, string(data))
+
+	var decoded struct {
+		Amount Money `json:"amount"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(`{"amount":"0.29"}`), &decoded))
+	assert.Equal(t, int64(29), decoded.Amount.Minor())
+	require.NoError(t, json.Unmarshal([]byte(`{"amount":65}`), &decoded))
+	assert.Equal(t, int64(6500), decoded.Amount.Minor(), "JSON numbers are accepted")
+	assert.Error(t, json.Unmarshal([]byte(`{"amount":"1.001"}`), &decoded))
+}
@@ -0,0 +1,138 @@
+package payout
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// FakeOptions configures the behaviour of a Fake provider
+type FakeOptions struct {
+	Delay       time.Duration        // time from submission until a payout succeeds or fails
+	Fail        func(Request) string // returns a failure reason for payouts that should fail, or ""
+	SubmitError func(Request) error  // returns an error for submissions that should be rejected outright
+	Now         func() time.Time     // clock, defaults to time.Now
+}
+
+// Fake is an in-process Provider for tests and demos
+// Payouts stay pending for Delay after submission and then succeed, or fail with the reason
+// returned by Fail. Pending payouts can be cancelled.
+type Fake struct {
+	opts FakeOptions
+
+	mu       sync.Mutex
+	payouts  map[string]*fakePayout // by reference
+	byKey    map[string]string      // idempotency key to reference
+	sequence int
+}
+
+// fakePayout is one payout held by a Fake
+type fakePayout struct {
+	request     Request
+	submittedAt time.Time
+	cancelled   bool
+}
+
+// NewFake creates a fake provider
+func NewFake(opts FakeOptions) *Fake {
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+	return &Fake{opts: opts, payouts: make(map[string]*fakePayout), byKey: make(map[string]string)}
+}
+
+// Name returns "fake"
+func (f *Fake) Name() string {
+	return "fake"
+}
+
+// Submit creates a payout, or returns the existing one for a repeated idempotency key
+func (f *Fake) Submit(ctx context.Context, req Request) (*Response, error) {
+	if req.IdempotencyKey == "" {
+		return nil, fmt.Errorf("failed to submit payout for %s: idempotency key is required", req.PaymentID)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if ref, ok := f.byKey[req.IdempotencyKey]; ok {
+		return f.respond(ref), nil
+	}
+	if f.opts.SubmitError != nil {
+		if err := f.opts.SubmitError(req); err != nil {
+			return nil, err
+		}
+	}
+
+	f.sequence++
+	ref := fmt.Sprintf("fake_%06d", f.sequence)
+	f.payouts[ref] = &fakePayout{request: req, submittedAt: f.opts.Now()}
+	f.byKey[req.IdempotencyKey] = ref
+	return f.respond(ref), nil
+}
+
+// Status returns the current state of a payout
+func (f *Fake) Status(ctx context.Context, reference string) (*Response, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.payouts[reference]; !ok {
+		return nil, fmt.Errorf("failed to get payout %s: %w", reference, ErrUnknownPayout)
+	}
+	return f.respond(reference), nil
+}
+
+// Cancel withdraws a pending payout
+func (f *Fake) Cancel(ctx context.Context, reference string) (*Response, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	p, ok := f.payouts[reference]
+	if !ok {
+		return nil, fmt.Errorf("failed to cancel payout %s: %w", reference, ErrUnknownPayout)
+	}
+	if status, _ := f.status(p); status != StatusPending && status != StatusCancelled {
+		return nil, fmt.Errorf("failed to cancel payout %s: %w (%s)", reference, ErrNotCancellable, status)
+	}
+	p.cancelled = true
+	return f.respond(reference), nil
+}
+
+// Submissions returns the number of distinct payouts created
+func (f *Fake) Submissions() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.payouts)
+}
+
+// status derives a payout's state from the clock; callers hold f.mu
+func (f *Fake) status(p *fakePayout) (Status, string) {
+	switch {
+	case p.cancelled:
+		return StatusCancelled, ""
+	case f.opts.Now().Sub(p.submittedAt) < f.opts.Delay:
+		return StatusPending, ""
+	}
+	if f.opts.Fail != nil {
+		if reason := f.opts.Fail(p.request); reason != "" {
+			return StatusFailed, reason
+		}
+	}
+	return StatusSucceeded, ""
+}
+
+// respond builds the response for a payout, with a JSON body as a real provider would send; callers hold f.mu
+func (f *Fake) respond(reference string) *Response {
+	p := f.payouts[reference]
+	status, reason := f.status(p)
+	resp := &Response{Reference: reference, Status: status, FailureReason: reason}
+	raw, _ := json.Marshal(struct {
+		ID             string `json:"id"`
+		IdempotencyKey string `json:"idempotency_key"`
+		Amount         string `json:"amount"`
+		Currency       string `json:"currency"`
+		Status         Status `json:"status"`
+		FailureReason  string `json:"failure_reason,omitempty"`
+	}{reference, p.request.IdempotencyKey, p.request.Amount.String(), string(p.request.Amount.Currency()), status, reason})
+	resp.Raw = string(raw)
+	return resp
+}
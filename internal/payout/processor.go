@@ -0,0 +1,200 @@
+package payout
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/abhi14nexu/mercor-scd/internal/models"
+	"github.com/abhi14nexu/mercor-scd/internal/scd"
+	"gorm.io/gorm"
+)
+
+// ErrNotSubmitted is returned when polling or cancelling a run that was never submitted to a provider
+var ErrNotSubmitted = errors.New("payout run is not submitted")
+
+// Processor pays the line items of payout runs through a Provider
+// Every provider response is recorded on a new version of the line item it concerns. Once all
+// of a run's payouts are final the run is settled, or failed if none succeeded; succeeded items
+// are marked paid and the rest are released as failed so a new run can pick them up.
+type Processor struct {
+	db       *gorm.DB
+	provider Provider
+}
+
+// NewProcessor creates a processor that pays through provider
+func NewProcessor(db *gorm.DB, provider Provider) *Processor {
+	return &Processor{db: db, provider: provider}
+}
+
+// Submit moves an approved run to submitted and sends each of its items to the provider
+// Calling it again on a submitted run resumes an interrupted submission: items that already have
+// a provider reference are skipped and idempotency keys stop the rest from being paid twice.
+func (p *Processor) Submit(ctx context.Context, runID string) (*models.PayoutRun, error) {
+	run, err := scd.GetLatest[*models.PayoutRun](p.db, runID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find payout run %s: %w", runID, err)
+	}
+	if run.Status != "submitted" {
+		if run, err = models.SubmitPayoutRun(p.db, runID); err != nil {
+			return nil, err
+		}
+	}
+	items, err := models.PayoutRunItems(p.db, runID)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make(map[string]*Response)
+	var submitErr error
+	for _, item := range items {
+		if item.PayoutRef != "" {
+			continue
+		}
+		resp, err := p.provider.Submit(ctx, Request{
+			IdempotencyKey: IdempotencyKey(runID, item.ID),
+			PaymentID:      item.ID,
+			ContractorID:   run.ContractorID,
+			Amount:         item.Amount,
+		})
+		if err != nil {
+			submitErr = fmt.Errorf("failed to submit payment %s to %s: %w", item.ID, p.provider.Name(), err)
+			break
+		}
+		responses[item.ID] = resp
+	}
+
+	// Record what the provider accepted even if a later submission failed
+	run, err = p.record(run, items, responses)
+	if err != nil {
+		return nil, err
+	}
+	return run, submitErr
+}
+
+// Poll asks the provider about every unfinished payout of a submitted run and records changes
+// The run is settled or failed once every payout is final; polling a finished run does nothing.
+func (p *Processor) Poll(ctx context.Context, runID string) (*models.PayoutRun, error) {
+	run, items, err := p.load(runID)
+	if err != nil || run.Status != "submitted" {
+		return run, err
+	}
+
+	responses := make(map[string]*Response)
+	for _, item := range items {
+		if item.PayoutRef == "" || Status(item.PayoutStatus).Final() {
+			continue
+		}
+		resp, err := p.provider.Status(ctx, item.PayoutRef)
+		if err != nil {
+			return nil, fmt.Errorf("failed to poll payment %s at %s: %w", item.ID, p.provider.Name(), err)
+		}
+		responses[item.ID] = resp
+	}
+	return p.record(run, items, responses)
+}
+
+// Cancel withdraws every pending payout of a submitted run and finishes the run
+// Payouts the provider can no longer cancel keep their outcome; items never submitted count as cancelled.
+func (p *Processor) Cancel(ctx context.Context, runID string) (*models.PayoutRun, error) {
+	run, items, err := p.load(runID)
+	if err != nil || run.Status != "submitted" {
+		return run, err
+	}
+
+	responses := make(map[string]*Response)
+	for _, item := range items {
+		switch {
+		case item.PayoutRef == "":
+			responses[item.ID] = &Response{Status: StatusCancelled, FailureReason: "never submitted"}
+		case !Status(item.PayoutStatus).Final():
+			resp, err := p.provider.Cancel(ctx, item.PayoutRef)
+			if errors.Is(err, ErrNotCancellable) {
+				resp, err = p.provider.Status(ctx, item.PayoutRef)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("failed to cancel payment %s at %s: %w", item.ID, p.provider.Name(), err)
+			}
+			responses[item.ID] = resp
+		}
+	}
+	run, err = p.record(run, items, responses)
+	if err == nil && run.Status == "submitted" {
+		err = fmt.Errorf("failed to cancel payout run %s: some payouts are still pending", runID)
+	}
+	return run, err
+}
+
+// load returns a run and its items, or ErrNotSubmitted if it has not reached the provider yet
+func (p *Processor) load(runID string) (*models.PayoutRun, []*models.PaymentLineItem, error) {
+	run, err := scd.GetLatest[*models.PayoutRun](p.db, runID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to find payout run %s: %w", runID, err)
+	}
+	if run.Status == "draft" || run.Status == "approved" {
+		return nil, nil, fmt.Errorf("failed to process payout run %s (%s): %w", runID, run.Status, ErrNotSubmitted)
+	}
+	items, err := models.PayoutRunItems(p.db, runID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return run, items, nil
+}
+
+// record writes a new version of each item whose provider response changed
+// When every item of the run has a final status the run is finished in the same unit of work.
+func (p *Processor) record(run *models.PayoutRun, items []*models.PaymentLineItem, responses map[string]*Response) (*models.PayoutRun, error) {
+	changed := make(map[string]*Response)
+	final, succeeded := len(items) > 0, false
+	for _, item := range items {
+		status := Status(item.PayoutStatus)
+		if resp, ok := responses[item.ID]; ok {
+			status = resp.Status
+			if string(resp.Status) != item.PayoutStatus || resp.Raw != item.PayoutResponse || resp.Reference != item.PayoutRef {
+				changed[item.ID] = resp
+			}
+		}
+		final = final && status.Final()
+		succeeded = succeeded || status == StatusSucceeded
+	}
+
+	apply := func(item *models.PaymentLineItem) {
+		if resp, ok := changed[item.ID]; ok {
+			item.RecordPayout(p.provider.Name(), resp.Reference, string(resp.Status), resp.Raw)
+		}
+	}
+
+	if final {
+		status := "failed"
+		if succeeded {
+			status = "settled"
+		}
+		return models.TransitionPayoutRun(p.db, run.ID, status, func(item *models.PaymentLineItem) {
+			apply(item)
+			if Status(item.PayoutStatus) == StatusSucceeded {
+				item.MarkPaid()
+			} else {
+				item.ReleaseFailed()
+			}
+		})
+	}
+
+	if len(changed) == 0 {
+		return run, nil
+	}
+	err := scd.RunUnitOfWork(p.db, func(u *scd.UnitOfWork) error {
+		for _, item := range items {
+			if _, ok := changed[item.ID]; !ok {
+				continue
+			}
+			if _, err := scd.UpdateIn(u, item.ID, apply); err != nil {
+				return fmt.Errorf("failed to record payout of payment %s: %w", item.ID, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return run, nil
+}
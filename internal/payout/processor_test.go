@@ -0,0 +1,181 @@
+package payout
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/abhi14nexu/mercor-scd/internal/models"
+	"github.com/abhi14nexu/mercor-scd/internal/money"
+	"github.com/abhi14nexu/mercor-scd/internal/scd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// setupPayoutTestDB creates an approved run "run-1" of three one-hour items for contractor-alice
+func setupPayoutTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	require.NoError(t, models.Register(db))
+
+	job, err := scd.CreateNew(db, models.NewJob("job-1", "Engineer", "company-acme", "contractor-alice", money.FromMajor(50, money.USD)))
+	require.NoError(t, err)
+	monday := time.Date(2025, 1, 6, 9, 0, 0, 0, time.UTC)
+	for day, id := range []string{"payment-1", "payment-2", "payment-3"} {
+		start := monday.AddDate(0, 0, day)
+		timelog, err := scd.CreateNew(db, models.NewTimelog("timelog-"+id, job.UID, start, start.Add(time.Hour)))
+		require.NoError(t, err)
+		_, err = scd.CreateNew(db, models.NewCalculatedPaymentLineItem(id, job, timelog))
+		require.NoError(t, err)
+	}
+
+	period := models.PayPeriod{Start: monday.Truncate(24 * time.Hour), End: monday.AddDate(0, 0, 7)}
+	_, err = models.CreatePayoutRun(db, "run-1", "contractor-alice", period, money.USD)
+	require.NoError(t, err)
+	_, err = models.ApprovePayoutRun(db, "run-1")
+	require.NoError(t, err)
+	return db
+}
+
+// fakeClock is a settable clock for the fake provider
+type fakeClock struct{ now time.Time }
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+// TestProcessorSettlesRun tests submitting a run, polling pending payouts and settling once they succeed
+func TestProcessorSettlesRun(t *testing.T) {
+	db := setupPayoutTestDB(t)
+	ctx := context.Background()
+	clock := &fakeClock{now: time.Date(2025, 1, 13, 9, 0, 0, 0, time.UTC)}
+	provider := NewFake(FakeOptions{Delay: time.Minute, Now: clock.Now})
+	processor := NewProcessor(db, provider)
+
+	_, err := processor.Poll(ctx, "run-1")
+	assert.ErrorIs(t, err, ErrNotSubmitted)
+
+	run, err := processor.Submit(ctx, "run-1")
+	require.NoError(t, err)
+	assert.Equal(t, "submitted", run.Status)
+	assert.Equal(t, 3, provider.Submissions())
+
+	items, err := models.PayoutRunItems(db, "run-1")
+	require.NoError(t, err)
+	for _, item := range items {
+		assert.Equal(t, "fake", item.PayoutProvider)
+		assert.True(t, strings.HasPrefix(item.PayoutRef, "fake_"))
+		assert.Equal(t, string(StatusPending), item.PayoutStatus)
+		assert.Contains(t, item.PayoutResponse, `"idempotency_key":"run-1/`+item.ID+`"`)
+		assert.True(t, item.IsNotPaid())
+	}
+
+	// Nothing changed at the provider, so no new versions
+	_, err = processor.Poll(ctx, "run-1")
+	require.NoError(t, err)
+	versions, err := scd.GetAllVersions[*models.PaymentLineItem](db, "payment-1")
+	require.NoError(t, err)
+	assert.Len(t, versions, 3, "Created, joined the run, submitted")
+
+	// Submitting again does not pay twice
+	_, err = processor.Submit(ctx, "run-1")
+	require.NoError(t, err)
+	assert.Equal(t, 3, provider.Submissions())
+
+	clock.now = clock.now.Add(time.Minute)
+	run, err = processor.Poll(ctx, "run-1")
+	require.NoError(t, err)
+	assert.Equal(t, "settled", run.Status)
+	assert.Equal(t, 3, run.ItemCount)
+	assert.Equal(t, money.FromMajor(150, money.USD), run.Total)
+
+	items, err = models.PayoutRunItems(db, "run-1")
+	require.NoError(t, err)
+	require.Len(t, items, 3)
+	for _, item := range items {
+		assert.True(t, item.IsPaid())
+		assert.Equal(t, string(StatusSucceeded), item.PayoutStatus)
+		assert.True(t, item.ValidFrom.Equal(run.ValidFrom), "The final response and payment land with the settlement")
+	}
+}
+
+// TestProcessorPartialFailure tests that failed payouts are released while the rest of the run settles
+func TestProcessorPartialFailure(t *testing.T) {
+	db := setupPayoutTestDB(t)
+	ctx := context.Background()
+	provider := NewFake(FakeOptions{Fail: func(req Request) string {
+		if req.PaymentID == "payment-2" {
+			return "account closed"
+		}
+		return ""
+	}})
+
+	run, err := NewProcessor(db, provider).Submit(ctx, "run-1")
+	require.NoError(t, err)
+	assert.Equal(t, "settled", run.Status, "Payouts without a delay are final on submission")
+	assert.Equal(t, 2, run.ItemCount)
+	assert.Equal(t, money.FromMajor(100, money.USD), run.Total)
+
+	failed, err := scd.GetLatest[*models.PaymentLineItem](db, "payment-2")
+	require.NoError(t, err)
+	assert.True(t, failed.IsFailed())
+	assert.Empty(t, failed.PayoutRunID, "Failed items can join a new run")
+	assert.Equal(t, string(StatusFailed), failed.PayoutStatus)
+	assert.Contains(t, failed.PayoutResponse, "account closed")
+}
+
+// TestProcessorResumesAfterSubmitError tests that a rejected submission is recorded up to the error and can be retried
+func TestProcessorResumesAfterSubmitError(t *testing.T) {
+	db := setupPayoutTestDB(t)
+	ctx := context.Background()
+	outage := true
+	provider := NewFake(FakeOptions{Delay: time.Hour, SubmitError: func(req Request) error {
+		if outage && req.PaymentID == "payment-2" {
+			return errors.New("provider unavailable")
+		}
+		return nil
+	}})
+	processor := NewProcessor(db, provider)
+
+	_, err := processor.Submit(ctx, "run-1")
+	require.Error(t, err)
+	first, err := scd.GetLatest[*models.PaymentLineItem](db, "payment-1")
+	require.NoError(t, err)
+	assert.NotEmpty(t, first.PayoutRef, "Accepted payouts are recorded")
+
+	outage = false
+	_, err = processor.Submit(ctx, "run-1")
+	require.NoError(t, err)
+	assert.Equal(t, 3, provider.Submissions())
+
+	// Pending payouts can be withdrawn; with none succeeded the run fails and releases its items
+	run, err := processor.Cancel(ctx, "run-1")
+	require.NoError(t, err)
+	assert.Equal(t, "failed", run.Status)
+	assert.Equal(t, 3, run.ItemCount)
+	item, err := scd.GetLatest[*models.PaymentLineItem](db, "payment-3")
+	require.NoError(t, err)
+	assert.True(t, item.IsFailed())
+	assert.Equal(t, string(StatusCancelled), item.PayoutStatus)
+}
+
+// TestFakeCancel tests that only pending fake payouts can be cancelled
+func TestFakeCancel(t *testing.T) {
+	ctx := context.Background()
+	provider := NewFake(FakeOptions{})
+	resp, err := provider.Submit(ctx, Request{IdempotencyKey: "run-1/payment-1", PaymentID: "payment-1", Amount: money.FromMajor(5, money.USD)})
+	require.NoError(t, err)
+	assert.Equal(t, StatusSucceeded, resp.Status)
+
+	_, err = provider.Cancel(ctx, resp.Reference)
+	assert.ErrorIs(t, err, ErrNotCancellable)
+	_, err = provider.Status(ctx, "fake_999999")
+	assert.ErrorIs(t, err, ErrUnknownPayout)
+	_, err = provider.Submit(ctx, Request{PaymentID: "payment-2"})
+	assert.Error(t, err, "Idempotency keys are required")
+}
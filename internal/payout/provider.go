@@ -0,0 +1,68 @@
+// Package payout sends the line items of a payout run to a payout provider and records the outcome
+package payout
+
+import (
+	"context"
+	"errors"
+
+	"github.com/abhi14nexu/mercor-scd/internal/money"
+)
+
+// ErrUnknownPayout is returned by a provider for a reference it did not issue
+var ErrUnknownPayout = errors.New("unknown payout")
+
+// ErrNotCancellable is returned by a provider when a payout has progressed too far to cancel
+var ErrNotCancellable = errors.New("payout cannot be cancelled")
+
+// Status is the provider-side state of a single payout
+type Status string
+
+const (
+	StatusPending   Status = "pending"   // accepted, money not yet delivered
+	StatusSucceeded Status = "succeeded" // delivered to the contractor
+	StatusFailed    Status = "failed"    // rejected or returned by the provider
+	StatusCancelled Status = "cancelled" // withdrawn before delivery
+)
+
+// Final reports whether the payout can no longer change status
+func (s Status) Final() bool {
+	return s == StatusSucceeded || s == StatusFailed || s == StatusCancelled
+}
+
+// Request asks a provider to pay one line item
+// Providers must treat requests with the same IdempotencyKey as one payout and answer
+// repeats with the original reference, so a retried submission never pays twice.
+type Request struct {
+	IdempotencyKey string      `json:"idempotency_key"`
+	PaymentID      string      `json:"payment_id"`
+	ContractorID   string      `json:"contractor_id"`
+	Amount         money.Money `json:"amount"`
+}
+
+// Response is what a provider reports about a payout
+// Raw holds the provider's response as received and is stored on the payment version.
+type Response struct {
+	Reference     string `json:"reference"`
+	Status        Status `json:"status"`
+	FailureReason string `json:"failure_reason,omitempty"`
+	Raw           string `json:"-"`
+}
+
+// Provider sends money to contractors
+// Implementations must be safe for concurrent use.
+type Provider interface {
+	// Name identifies the provider on recorded payment versions
+	Name() string
+	// Submit creates a payout, or returns the existing one for a repeated idempotency key
+	Submit(ctx context.Context, req Request) (*Response, error)
+	// Status returns the current state of a payout
+	Status(ctx context.Context, reference string) (*Response, error)
+	// Cancel withdraws a pending payout, or returns ErrNotCancellable
+	Cancel(ctx context.Context, reference string) (*Response, error)
+}
+
+// IdempotencyKey returns the key for paying an item within a run, e.g. "payout-alice-20250106-USD/payment-1"
+// A failed item released into a new run gets a new key and so a new payout.
+func IdempotencyKey(runID, paymentID string) string {
+	return runID + "/" + paymentID
+}
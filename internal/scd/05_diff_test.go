@@ -0,0 +1,52 @@
+package scd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestDiffBetweenVersions tests that Diff reports only changed business fields
+func TestDiffBetweenVersions(t *testing.T) {
+	db := setupTestDB(t)
+
+	_, err := CreateNew(db, &TestJob{
+		Model:  Model{ID: "diff-job"},
+		Status: "active",
+		Rate:   50.0,
+		Title:  "Engineer",
+	})
+	require.NoError(t, err)
+
+	_, err = Update(db, "diff-job", func(j *TestJob) {
+		j.Status = "paused"
+		j.Rate = 55.0
+	})
+	require.NoError(t, err)
+
+	v1, err := GetVersion[*TestJob](db, "diff-job", 1)
+	require.NoError(t, err)
+	v2, err := GetVersion[*TestJob](db, "diff-job", 2)
+	require.NoError(t, err)
+
+	changes, err := Diff(v1, v2)
+	require.NoError(t, err, "Diff should succeed")
+
+	// UID, Version and ValidFrom differ too, but bookkeeping fields are excluded
+	require.Len(t, changes, 2, "Only status and rate should be reported")
+	assert.Equal(t, Change{Field: "status", Old: "active", New: "paused"}, changes[0])
+	assert.Equal(t, Change{Field: "rate", Old: 50.0, New: 55.0}, changes[1])
+}
+
+// TestDiffAgainstNil tests that a nil previous version reports every populated field
+func TestDiffAgainstNil(t *testing.T) {
+	job := &TestJob{Model: Model{ID: "diff-new"}, Status: "active", Title: "Engineer"}
+
+	changes, err := Diff((*TestJob)(nil), job)
+	require.NoError(t, err)
+	assert.Len(t, changes, 2, "Status and title should be reported, zero rate should not")
+
+	_, err = Diff(&TestJob{}, &TestTimelog{})
+	assert.Error(t, err, "Diffing different types should fail")
+}
@@ -0,0 +1,157 @@
+package scd
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// setupPluginTestDB creates a test database with the versioning plugin registered
+func setupPluginTestDB(t *testing.T) *gorm.DB {
+	db := setupTestDB(t)
+	require.NoError(t, db.Use(&VersioningPlugin{}), "Plugin should register")
+	return db
+}
+
+// TestPluginSaveCreatesVersion tests that db.Save on a loaded entity creates a new version
+func TestPluginSaveCreatesVersion(t *testing.T) {
+	db := setupPluginTestDB(t)
+
+	job, err := CreateNew(db, &TestJob{Model: Model{ID: "plugin-save"}, Status: "active", Rate: 50.0})
+	require.NoError(t, err)
+	originalUID := job.GetUID()
+
+	job.Rate = 65.0
+	require.NoError(t, db.Save(job).Error, "Save should be rewritten into a versioned update")
+
+	// The caller's struct now holds the new version
+	assert.Equal(t, 2, job.GetVersion())
+	assert.NotEqual(t, originalUID, job.GetUID())
+	assert.True(t, job.IsLatest())
+
+	versions, err := GetAllVersions[*TestJob](db, "plugin-save")
+	require.NoError(t, err)
+	require.Len(t, versions, 2, "History should be preserved")
+	assert.Equal(t, 50.0, versions[0].Rate, "Version 1 must keep the original rate")
+	assert.NotNil(t, versions[0].ValidTo, "Version 1 should be closed")
+	assert.Equal(t, 65.0, versions[1].Rate)
+	assert.Equal(t, versions[0].ValidTo.UnixNano(), versions[1].ValidFrom.UnixNano(), "Validity windows should touch")
+}
+
+// TestPluginUpdatesCreatesVersion tests Model(...).Updates and batch updates across entities
+func TestPluginUpdatesCreatesVersion(t *testing.T) {
+	db := setupPluginTestDB(t)
+
+	job, err := CreateNew(db, &TestJob{Model: Model{ID: "plugin-updates"}, Status: "active", Title: "Engineer"})
+	require.NoError(t, err)
+	_, err = CreateNew(db, &TestJob{Model: Model{ID: "plugin-batch"}, Status: "active", Title: "Engineer"})
+	require.NoError(t, err)
+
+	require.NoError(t, db.Model(job).Updates(map[string]interface{}{"status": "paused"}).Error)
+	latest, err := GetLatest[*TestJob](db, "plugin-updates")
+	require.NoError(t, err)
+	assert.Equal(t, 2, latest.GetVersion())
+	assert.Equal(t, "paused", latest.Status)
+	assert.Equal(t, "Engineer", latest.Title, "Untouched fields carry over")
+
+	// A batch update versions every matching latest entity and skips history
+	result := db.Model(&TestJob{}).Where("title = ?", "Engineer").Update("title", "Senior Engineer")
+	require.NoError(t, result.Error)
+	assert.Equal(t, int64(2), result.RowsAffected, "Only the two latest versions should be rewritten")
+
+	var count int64
+	require.NoError(t, db.Model(&TestJob{}).Count(&count).Error)
+	assert.Equal(t, int64(5), count, "2 creates + 1 update + 2 batch versions")
+
+	// Updating an explicit historical version is rejected
+	v1, err := GetVersion[*TestJob](db, "plugin-updates", 1)
+	require.NoError(t, err)
+	err = db.Model(v1).Update("status", "completed").Error
+	assert.ErrorIs(t, err, ErrHistoricalVersion)
+
+	// Missing WHERE is still guarded
+	err = db.Model(&TestJob{}).Update("status", "completed").Error
+	assert.ErrorIs(t, err, gorm.ErrMissingWhereClause)
+}
+
+// TestPluginDeleteIsSoft tests that Delete soft deletes and only Unscoped removes rows
+func TestPluginDeleteIsSoft(t *testing.T) {
+	db := setupPluginTestDB(t)
+
+	job, err := CreateNew(db, &TestJob{Model: Model{ID: "plugin-delete"}, Status: "active"})
+	require.NoError(t, err)
+
+	require.NoError(t, db.Delete(job).Error, "Delete should be rewritten into a soft delete")
+
+	hasLatest, err := HasLatestVersion[*TestJob](db, "plugin-delete")
+	require.NoError(t, err)
+	assert.False(t, hasLatest, "Entity should no longer have a latest version")
+
+	exists, err := Exists[*TestJob](db, "plugin-delete")
+	require.NoError(t, err)
+	assert.True(t, exists, "The closed version must still exist")
+
+	// Deleting the now-historical version again is a hard delete of history and is rejected
+	err = db.Delete(job).Error
+	assert.ErrorIs(t, err, ErrHistoricalVersion)
+
+	// Unscoped deletes physically
+	require.NoError(t, db.Unscoped().Delete(job).Error)
+	exists, err = Exists[*TestJob](db, "plugin-delete")
+	require.NoError(t, err)
+	assert.False(t, exists, "Unscoped delete should remove the row")
+}
+
+// TestPluginLeavesLibraryWritesAlone tests that Update and SoftDelete still work with the plugin installed
+func TestPluginLeavesLibraryWritesAlone(t *testing.T) {
+	db := setupPluginTestDB(t)
+
+	_, err := CreateNew(db, &TestJob{Model: Model{ID: "plugin-lib"}, Status: "active"})
+	require.NoError(t, err)
+
+	updated, err := Update(db, "plugin-lib", func(j *TestJob) { j.Status = "paused" })
+	require.NoError(t, err)
+	assert.Equal(t, 2, updated.GetVersion())
+
+	require.NoError(t, SoftDelete[*TestJob](db, "plugin-lib"))
+
+	versions, err := GetAllVersions[*TestJob](db, "plugin-lib")
+	require.NoError(t, err)
+	assert.Len(t, versions, 2, "Library writes must not be versioned twice")
+}
+
+// TestPluginAfterVersion tests that AfterVersion sees each new version in the write's transaction
+func TestPluginAfterVersion(t *testing.T) {
+	db := setupTestDB(t)
+	var seen []SCDModel
+	fail := false
+	require.NoError(t, db.Use(&VersioningPlugin{AfterVersion: func(tx *gorm.DB, version SCDModel) error {
+		if fail {
+			return errors.New("hook failed")
+		}
+		latest, err := GetLatest[*TestJob](tx, version.GetBusinessID())
+		require.NoError(t, err)
+		assert.Equal(t, version.GetUID(), latest.GetUID(), "The previous version is already closed")
+		seen = append(seen, version)
+		return nil
+	}}))
+
+	job, err := CreateNew(db, &TestJob{Model: Model{ID: "plugin-hook"}, Status: "active", Rate: 50.0})
+	require.NoError(t, err)
+	assert.Empty(t, seen, "Creating the first version is not a plugin write")
+
+	job.Rate = 65.0
+	require.NoError(t, db.Save(job).Error)
+	require.Len(t, seen, 1)
+	assert.Equal(t, 2, seen[0].GetVersion())
+
+	fail = true
+	job.Rate = 80.0
+	require.Error(t, db.Save(job).Error)
+	versions, err := GetAllVersions[*TestJob](db, "plugin-hook")
+	require.NoError(t, err)
+	assert.Len(t, versions, 2, "A failing hook rolls the new version back")
+}
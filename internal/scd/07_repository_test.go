@@ -0,0 +1,110 @@
+package scd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// TestRepositoryLifecycle tests create, update, reads and delete through a Repository
+func TestRepositoryLifecycle(t *testing.T) {
+	db := setupTestDB(t)
+
+	// Fixed clock so validity windows are predictable
+	now := time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+	repo, err := NewRepository[*TestJob](db, WithClock(func() time.Time { return now }))
+	require.NoError(t, err)
+	assert.Equal(t, "test_jobs", repo.Table())
+
+	created, err := repo.Create(&TestJob{Model: Model{ID: "repo-job"}, Status: "active", Rate: 40})
+	require.NoError(t, err)
+	assert.Equal(t, 1, created.GetVersion())
+	assert.True(t, created.ValidFrom.Equal(now), "Create should use the repository clock")
+
+	now = now.Add(24 * time.Hour)
+	updated, err := repo.Update("repo-job", func(j *TestJob) { j.Rate = 45 })
+	require.NoError(t, err)
+	assert.Equal(t, 2, updated.GetVersion())
+	assert.True(t, updated.ValidFrom.Equal(now))
+
+	latest, err := repo.Latest("repo-job")
+	require.NoError(t, err)
+	assert.Equal(t, 45.0, latest.Rate)
+
+	asOf, err := repo.AsOf("repo-job", now.Add(-time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, 1, asOf.GetVersion(), "AsOf before the update should return version 1")
+
+	v1, err := repo.Version("repo-job", 1)
+	require.NoError(t, err)
+	assert.Equal(t, 40.0, v1.Rate)
+
+	history, err := repo.History("repo-job")
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+	assert.Equal(t, 1, history[0].GetVersion())
+
+	now = now.Add(time.Hour)
+	require.NoError(t, repo.Delete("repo-job"))
+	_, err = repo.Latest("repo-job")
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound, "Deleted entity should have no latest version")
+
+	closed, err := repo.Version("repo-job", 2)
+	require.NoError(t, err)
+	require.NotNil(t, closed.ValidTo)
+	assert.True(t, closed.ValidTo.Equal(now), "Delete should use the repository clock")
+}
+
+// TestRepositoryList tests filters, as-of listing and default scopes
+func TestRepositoryList(t *testing.T) {
+	db := setupTestDB(t)
+
+	repo, err := NewRepository[*TestJob](db)
+	require.NoError(t, err)
+
+	for _, j := range []*TestJob{
+		{Model: Model{ID: "list-1"}, Status: "active", Title: "A"},
+		{Model: Model{ID: "list-2"}, Status: "active", Title: "B"},
+		{Model: Model{ID: "list-3"}, Status: "paused", Title: "C"},
+	} {
+		_, err := repo.Create(j)
+		require.NoError(t, err)
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	beforeUpdate := time.Now()
+	time.Sleep(10 * time.Millisecond)
+
+	_, err = repo.Update("list-1", func(j *TestJob) { j.Status = "paused" })
+	require.NoError(t, err)
+
+	active, err := repo.List(ListOptions{Filters: map[string]interface{}{"status": "active"}})
+	require.NoError(t, err)
+	require.Len(t, active, 1, "Only list-2 is still active")
+	assert.Equal(t, "list-2", active[0].GetBusinessID())
+
+	activeThen, err := repo.List(ListOptions{
+		Filters: map[string]interface{}{"status": "active"},
+		AsOf:    &beforeUpdate,
+		Scopes:  []func(*gorm.DB) *gorm.DB{OrderByTime(false)},
+	})
+	require.NoError(t, err)
+	assert.Len(t, activeThen, 2, "Before the update list-1 was still active")
+
+	_, err = repo.List(ListOptions{Filters: map[string]interface{}{"status; DROP TABLE test_jobs": "x"}})
+	assert.Error(t, err, "Unknown filter columns should be rejected")
+
+	// Default scopes apply to every read
+	pausedOnly, err := NewRepository[*TestJob](db, WithDefaultScopes(func(db *gorm.DB) *gorm.DB {
+		return db.Where("status = ?", "paused")
+	}))
+	require.NoError(t, err)
+	all, err := pausedOnly.List(ListOptions{})
+	require.NoError(t, err)
+	assert.Len(t, all, 2)
+	_, err = pausedOnly.Latest("list-2")
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
@@ -0,0 +1,172 @@
+package scd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// LegacyRate maps an existing table whose SCD columns use other names, declared with tags
+type LegacyRate struct {
+	UID            uuid.UUID  `gorm:"primaryKey"`
+	RateKey        string     `gorm:"not null" scd:"business_id"`
+	Revision       int        `gorm:"not null" scd:"version"`
+	EffectiveStart time.Time  `gorm:"not null" scd:"valid_from"`
+	EffectiveEnd   *time.Time `scd:"valid_to"`
+	Amount         float64
+}
+
+func (r *LegacyRate) GetUID() uuid.UUID        { return r.UID }
+func (r *LegacyRate) GetBusinessID() string    { return r.RateKey }
+func (r *LegacyRate) GetVersion() int          { return r.Revision }
+func (r *LegacyRate) SetUID(uid uuid.UUID)     { r.UID = uid }
+func (r *LegacyRate) SetBusinessID(id string)  { r.RateKey = id }
+func (r *LegacyRate) SetVersion(version int)   { r.Revision = version }
+func (r *LegacyRate) SetValidFrom(t time.Time) { r.EffectiveStart = t }
+
+// LegacyPlan maps an existing table without scd tags; its columns are registered instead
+type LegacyPlan struct {
+	UID     uuid.UUID `gorm:"primaryKey"`
+	PlanID  string
+	Rev     int
+	StartAt time.Time
+	EndAt   *time.Time
+	Name    string
+}
+
+func (p *LegacyPlan) GetUID() uuid.UUID        { return p.UID }
+func (p *LegacyPlan) GetBusinessID() string    { return p.PlanID }
+func (p *LegacyPlan) GetVersion() int          { return p.Rev }
+func (p *LegacyPlan) SetUID(uid uuid.UUID)     { p.UID = uid }
+func (p *LegacyPlan) SetBusinessID(id string)  { p.PlanID = id }
+func (p *LegacyPlan) SetVersion(version int)   { p.Rev = version }
+func (p *LegacyPlan) SetValidFrom(t time.Time) { p.StartAt = t }
+
+// PrefixedJob embeds Model under prefixed column names, registered below
+type PrefixedJob struct {
+	Model `gorm:"embedded;embeddedPrefix:scd_"`
+	Title string
+}
+
+func init() {
+	RegisterColumns(&LegacyPlan{}, Columns{BusinessID: "plan_id", Version: "rev", ValidFrom: "start_at", ValidTo: "end_at"})
+	RegisterColumns(&PrefixedJob{}, Columns{BusinessID: "scd_id", Version: "scd_version", ValidFrom: "scd_valid_from", ValidTo: "scd_valid_to"})
+}
+
+// setupLegacyTestDB creates the legacy tables next to the regular test tables
+func setupLegacyTestDB(t *testing.T) *gorm.DB {
+	db := setupTestDB(t)
+	require.NoError(t, db.AutoMigrate(&LegacyRate{}, &LegacyPlan{}, &PrefixedJob{}))
+	return db
+}
+
+// TestColumnsOf tests column resolution from tags, registration and defaults
+func TestColumnsOf(t *testing.T) {
+	db := setupLegacyTestDB(t)
+
+	cols, err := ColumnsOf(db, &LegacyRate{})
+	require.NoError(t, err)
+	assert.Equal(t, Columns{BusinessID: "rate_key", Version: "revision", ValidFrom: "effective_start", ValidTo: "effective_end"}, cols)
+
+	cols, err = ColumnsOf(db, &LegacyPlan{})
+	require.NoError(t, err)
+	assert.Equal(t, Columns{BusinessID: "plan_id", Version: "rev", ValidFrom: "start_at", ValidTo: "end_at"}, cols)
+
+	cols, err = ColumnsOf(db, &TestJob{})
+	require.NoError(t, err)
+	assert.Equal(t, DefaultColumns, cols, "Models embedding Model use the default columns")
+}
+
+// TestCustomColumnsLifecycle tests that operations and scopes use the declared columns
+func TestCustomColumnsLifecycle(t *testing.T) {
+	db := setupLegacyTestDB(t)
+
+	_, err := CreateNew(db, &LegacyRate{RateKey: "rate-1", Amount: 10})
+	require.NoError(t, err)
+	time.Sleep(10 * time.Millisecond)
+	beforeUpdate := time.Now()
+	time.Sleep(10 * time.Millisecond)
+
+	updated, err := Update(db, "rate-1", func(r *LegacyRate) { r.Amount = 12 })
+	require.NoError(t, err)
+	assert.Equal(t, 2, updated.GetVersion())
+
+	latest, err := GetLatest[*LegacyRate](db, "rate-1")
+	require.NoError(t, err)
+	assert.Equal(t, 12.0, latest.Amount)
+
+	var then LegacyRate
+	require.NoError(t, db.Scopes(AsOf(beforeUpdate), ByBusinessID("rate-1")).First(&then).Error)
+	assert.Equal(t, 10.0, then.Amount, "AsOf should read effective_start/effective_end")
+
+	var historical []LegacyRate
+	require.NoError(t, db.Scopes(Historical).Find(&historical).Error)
+	require.Len(t, historical, 1)
+	assert.NotNil(t, historical[0].EffectiveEnd)
+
+	require.NoError(t, SoftDelete[*LegacyRate](db, "rate-1"))
+	hasLatest, err := HasLatestVersion[*LegacyRate](db, "rate-1")
+	require.NoError(t, err)
+	assert.False(t, hasLatest)
+
+	// Registered columns work through the repository and the plugin as well
+	require.NoError(t, db.Use(&VersioningPlugin{}))
+	plans, err := NewRepository[*LegacyPlan](db)
+	require.NoError(t, err)
+
+	plan, err := plans.Create(&LegacyPlan{PlanID: "plan-1", Name: "Basic"})
+	require.NoError(t, err)
+	plan.Name = "Pro"
+	require.NoError(t, db.Save(plan).Error)
+	assert.Equal(t, 2, plan.Rev)
+
+	current, err := plans.List(ListOptions{Filters: map[string]interface{}{"name": "Pro"}})
+	require.NoError(t, err)
+	require.Len(t, current, 1)
+	assert.Nil(t, current[0].EndAt)
+
+	history, err := plans.History("plan-1")
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+	assert.Equal(t, "Basic", history[0].Name)
+	assert.NotNil(t, history[0].EndAt)
+}
+
+// TestModelBeforeCreateUsesDeclaredColumns tests that Model's version numbering reads the registered columns
+func TestModelBeforeCreateUsesDeclaredColumns(t *testing.T) {
+	db := setupLegacyTestDB(t)
+
+	first := &PrefixedJob{Model: Model{ID: "prefixed-1", ValidFrom: time.Now()}, Title: "Engineer"}
+	require.NoError(t, db.Create(first).Error)
+	assert.Equal(t, 1, first.Version)
+	assert.NotEqual(t, uuid.Nil, first.UID)
+
+	second := &PrefixedJob{Model: Model{ID: "prefixed-1", ValidFrom: time.Now()}, Title: "Senior Engineer"}
+	require.NoError(t, db.Create(second).Error)
+	assert.Equal(t, 2, second.Version, "The next version is read from scd_version by scd_id")
+
+	other := &PrefixedJob{Model: Model{ID: "prefixed-2", ValidFrom: time.Now()}}
+	require.NoError(t, db.Create(other).Error)
+	assert.Equal(t, 1, other.Version)
+}
+
+// TestScopesAreTableQualified tests that scopes stay unambiguous when joined tables share column names
+func TestScopesAreTableQualified(t *testing.T) {
+	db := setupTestDB(t)
+
+	job, err := CreateNew(db, &TestJob{Model: Model{ID: "qualified-job"}, Status: "active"})
+	require.NoError(t, err)
+	_, err = CreateNew(db, &TestTimelog{Model: Model{ID: "qualified-log"}, JobUID: job.UID})
+	require.NoError(t, err)
+
+	var timelogs []TestTimelog
+	err = db.Scopes(Latest, ByBusinessID("qualified-log"), OrderByVersion(true)).
+		Joins("JOIN test_jobs ON test_timelogs.job_uid = test_jobs.uid").
+		Find(&timelogs).Error
+	require.NoError(t, err, "Both tables have id/version/valid_to; the scopes must qualify them")
+	assert.Len(t, timelogs, 1)
+}
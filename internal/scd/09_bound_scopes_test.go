@@ -0,0 +1,65 @@
+package scd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// TestBoundScopesAcrossJoin tests filtering both sides of a join between two SCD tables
+func TestBoundScopesAcrossJoin(t *testing.T) {
+	db := setupTestDB(t)
+
+	job, err := CreateNew(db, &TestJob{Model: Model{ID: "bound-job"}, Status: "active"})
+	require.NoError(t, err)
+	_, err = CreateNew(db, &TestTimelog{Model: Model{ID: "bound-log"}, JobUID: job.UID, Duration: 100})
+	require.NoError(t, err)
+
+	// The job moves on; the timelog still points at version 1
+	_, err = Update(db, "bound-job", func(j *TestJob) { j.Status = "paused" })
+	require.NoError(t, err)
+
+	jobs := On(&TestJob{})
+	query := db.Scopes(Latest).Joins("JOIN test_jobs ON test_timelogs.job_uid = test_jobs.uid").Session(&gorm.Session{})
+
+	var pinnedToLatest []TestTimelog
+	require.NoError(t, query.Scopes(jobs.Latest).Find(&pinnedToLatest).Error)
+	assert.Empty(t, pinnedToLatest, "The referenced job version is no longer latest")
+
+	var pinnedToHistory []TestTimelog
+	require.NoError(t, query.Scopes(jobs.Historical, jobs.ByBusinessID("bound-job"), jobs.ByVersion(1)).
+		Find(&pinnedToHistory).Error)
+	require.Len(t, pinnedToHistory, 1)
+	assert.Equal(t, "bound-log", pinnedToHistory[0].ID)
+}
+
+// TestBoundScopesWithAlias tests a self join where each side needs its own validity conditions
+func TestBoundScopesWithAlias(t *testing.T) {
+	db := setupTestDB(t)
+
+	_, err := CreateNew(db, &TestJob{Model: Model{ID: "alias-job"}, Status: "active", Rate: 10})
+	require.NoError(t, err)
+	time.Sleep(10 * time.Millisecond)
+	_, err = Update(db, "alias-job", func(j *TestJob) { j.Rate = 20 })
+	require.NoError(t, err)
+
+	// Pair each version with its predecessor
+	prev := On(&TestJob{}).As("prev")
+	var pairs []struct {
+		ID       string
+		Rate     float64
+		PrevRate float64
+	}
+	err = db.Table("test_jobs AS cur").
+		Select("cur.id, cur.rate, prev.rate AS prev_rate").
+		Joins("JOIN test_jobs AS prev ON prev.id = cur.id AND prev.version = cur.version - 1").
+		Scopes(On(&TestJob{}).As("cur").Latest, prev.Historical, prev.OrderByVersion(false)).
+		Scan(&pairs).Error
+	require.NoError(t, err, "Aliased scopes must not collide on id/version/valid_to")
+	require.Len(t, pairs, 1)
+	assert.Equal(t, 20.0, pairs[0].Rate)
+	assert.Equal(t, 10.0, pairs[0].PrevRate)
+}
@@ -0,0 +1,99 @@
+package scd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestShift references a job by business ID and records its start as unix seconds
+type TestShift struct {
+	Model
+	JobID     string `json:"job_id"`
+	StartedAt int64  `json:"started_at"`
+}
+
+// jobRates is the projection used to read joined job versions
+type jobRates struct {
+	ID         string
+	PinnedRate float64
+	LatestRate float64
+}
+
+// TestTemporalJoins tests pinned, latest and as-of joins between SCD tables
+func TestTemporalJoins(t *testing.T) {
+	db := setupTestDB(t)
+	require.NoError(t, db.AutoMigrate(&TestShift{}))
+
+	// Rate changes from 40 to 55 at 12:00; the shift started at 10:00 and was recorded at 13:00
+	now := time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)
+	jobs, err := NewRepository[*TestJob](db, WithClock(func() time.Time { return now }))
+	require.NoError(t, err)
+
+	job, err := jobs.Create(&TestJob{Model: Model{ID: "join-job"}, Rate: 40})
+	require.NoError(t, err)
+	_, err = CreateNew(db, &TestTimelog{Model: Model{ID: "join-log"}, JobUID: job.UID})
+	require.NoError(t, err)
+
+	now = now.Add(3 * time.Hour)
+	_, err = jobs.Update("join-job", func(j *TestJob) { j.Rate = 55 })
+	require.NoError(t, err)
+
+	startedAt := time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC).Unix()
+	shifts, err := NewRepository[*TestShift](db, WithClock(func() time.Time { return now.Add(time.Hour) }))
+	require.NoError(t, err)
+	_, err = shifts.Create(&TestShift{Model: Model{ID: "shift-1"}, JobID: "join-job", StartedAt: startedAt})
+	require.NoError(t, err)
+
+	// Pinned version next to the latest version of the same business job
+	var rates []jobRates
+	err = db.Model(&TestTimelog{}).
+		Select("test_timelogs.id, job.rate AS pinned_rate, latest_job.rate AS latest_rate").
+		Scopes(
+			Latest,
+			JoinSCD(&TestJob{}).As("job").ByUID("job_uid"),
+			JoinSCD(&TestJob{}).As("latest_job").Latest("job.id"),
+		).
+		Scan(&rates).Error
+	require.NoError(t, err)
+	require.Len(t, rates, 1)
+	assert.Equal(t, 40.0, rates[0].PinnedRate, "Pinned join reads the referenced version")
+	assert.Equal(t, 55.0, rates[0].LatestRate, "Floating join reads the current version")
+
+	// Version valid when the shift was recorded (timestamp column)
+	var atCreation []struct{ Rate float64 }
+	err = db.Model(&TestShift{}).
+		Select("job.rate").
+		Scopes(Latest, JoinSCD(&TestJob{}).As("job").AsOf("job_id", "valid_from")).
+		Scan(&atCreation).Error
+	require.NoError(t, err)
+	require.Len(t, atCreation, 1)
+	assert.Equal(t, 55.0, atCreation[0].Rate, "The shift row was written after the rate change")
+
+	// Version valid at the shift's start (unix seconds column)
+	var atStart []struct{ Rate float64 }
+	err = db.Model(&TestShift{}).
+		Select("job.rate").
+		Scopes(Latest, JoinSCD(&TestJob{}).As("job").AsOfUnix("job_id", "started_at")).
+		Scan(&atStart).Error
+	require.NoError(t, err)
+	require.Len(t, atStart, 1)
+	assert.Equal(t, 40.0, atStart[0].Rate, "The shift started before the rate change")
+
+	// A left join keeps rows without a version valid at that time
+	require.NoError(t, db.Model(&TestShift{}).Where("id = ?", "shift-1").
+		Update("started_at", startedAt-2*3600).Error)
+	var missing []struct {
+		ID   string
+		Rate *float64
+	}
+	err = db.Model(&TestShift{}).
+		Select("test_shifts.id, job.rate").
+		Scopes(Latest, JoinSCD(&TestJob{}).As("job").Left().AsOfUnix("job_id", "started_at")).
+		Scan(&missing).Error
+	require.NoError(t, err)
+	require.Len(t, missing, 1)
+	assert.Nil(t, missing[0].Rate, "No job version existed at 08:00")
+}
@@ -0,0 +1,96 @@
+package scd
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// TestPayment references a job version with both pinned and floating relations
+type TestPayment struct {
+	Model
+	JobUID    uuid.UUID `json:"job_uid"`
+	Amount    float64   `json:"amount"`
+	Job       *TestJob  `gorm:"foreignKey:JobUID;references:UID" json:"job,omitempty"`
+	LatestJob *TestJob  `gorm:"-" scd:"latest:JobUID" json:"latest_job,omitempty"`
+}
+
+// setupPreloadTestDB creates a test database with the relations plugin registered
+func setupPreloadTestDB(t *testing.T) *gorm.DB {
+	db := setupTestDB(t)
+	require.NoError(t, db.AutoMigrate(&TestPayment{}))
+	require.NoError(t, db.Use(&RelationsPlugin{}))
+	return db
+}
+
+// countQueries counts the statements executed through db
+func countQueries(db *gorm.DB) *int {
+	count := new(int)
+	db.Callback().Query().Before("gorm:query").Register("test:count_queries", func(*gorm.DB) { *count++ })
+	db.Callback().Row().Before("gorm:row").Register("test:count_rows", func(*gorm.DB) { *count++ })
+	return count
+}
+
+// TestPreloadPinnedAndLatest tests loading the referenced version and the latest version together
+func TestPreloadPinnedAndLatest(t *testing.T) {
+	db := setupPreloadTestDB(t)
+
+	var jobUIDs []uuid.UUID
+	for _, id := range []string{"preload-job-1", "preload-job-2", "preload-job-3"} {
+		job, err := CreateNew(db, &TestJob{Model: Model{ID: id}, Rate: 10})
+		require.NoError(t, err)
+		jobUIDs = append(jobUIDs, job.UID)
+
+		_, err = CreateNew(db, &TestPayment{Model: Model{ID: "payment-" + id}, JobUID: job.UID, Amount: 100})
+		require.NoError(t, err)
+	}
+
+	// Two jobs move on, one is deleted
+	_, err := Update(db, "preload-job-1", func(j *TestJob) { j.Rate = 20 })
+	require.NoError(t, err)
+	_, err = Update(db, "preload-job-2", func(j *TestJob) { j.Rate = 30 })
+	require.NoError(t, err)
+	require.NoError(t, SoftDelete[*TestJob](db, "preload-job-3"))
+
+	queries := countQueries(db)
+
+	var payments []TestPayment
+	err = db.Scopes(Latest, OrderByTime(false), PreloadLatest("LatestJob")).Preload("Job").Find(&payments).Error
+	require.NoError(t, err)
+	require.Len(t, payments, 3)
+	assert.Equal(t, 4, *queries, "Payments, pinned jobs and two queries for latest jobs, independent of row count")
+
+	for i, p := range payments {
+		require.NotNil(t, p.Job)
+		assert.Equal(t, jobUIDs[i], p.Job.UID, "Pinned relation is the referenced version")
+		assert.Equal(t, 10.0, p.Job.Rate)
+	}
+	require.NotNil(t, payments[0].LatestJob)
+	assert.Equal(t, 20.0, payments[0].LatestJob.Rate, "Floating relation follows the business entity")
+	assert.Equal(t, 2, payments[0].LatestJob.Version)
+	require.NotNil(t, payments[1].LatestJob)
+	assert.Equal(t, 30.0, payments[1].LatestJob.Rate)
+	assert.Nil(t, payments[2].LatestJob, "Deleted entities have no latest version")
+
+	// A new version of a payment with preloaded relations must not write through them
+	updated, err := Update(db, payments[0].ID, func(p *TestPayment) { p.Amount = 200 })
+	require.NoError(t, err)
+	assert.Equal(t, jobUIDs[0], updated.JobUID)
+
+	var jobCount int64
+	require.NoError(t, db.Model(&TestJob{}).Count(&jobCount).Error)
+	assert.Equal(t, int64(5), jobCount, "No job rows are created through associations")
+}
+
+// TestPreloadLatestRequiresPlugin tests the error when the plugin is missing
+func TestPreloadLatestRequiresPlugin(t *testing.T) {
+	db := setupTestDB(t)
+	require.NoError(t, db.AutoMigrate(&TestPayment{}))
+
+	var payments []TestPayment
+	err := db.Scopes(PreloadLatest("LatestJob")).Find(&payments).Error
+	assert.ErrorIs(t, err, ErrPreloadNotRegistered)
+}
@@ -0,0 +1,64 @@
+package scd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestTimelineCollapsesEqualValues tests that consecutive versions with the same value are merged
+func TestTimelineCollapsesEqualValues(t *testing.T) {
+	db := setupTestDB(t)
+
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	jobs, err := NewRepository[*TestJob](db, WithClock(func() time.Time { return now }))
+	require.NoError(t, err)
+
+	_, err = jobs.Create(&TestJob{Model: Model{ID: "timeline-job"}, Rate: 50, Status: "active"})
+	require.NoError(t, err)
+
+	// v2 changes status only, v3 raises the rate, v4 changes status again
+	steps := []func(*TestJob){
+		func(j *TestJob) { j.Status = "paused" },
+		func(j *TestJob) { j.Rate = 60 },
+		func(j *TestJob) { j.Status = "active" },
+	}
+	for _, step := range steps {
+		now = now.Add(24 * time.Hour)
+		_, err = jobs.Update("timeline-job", step)
+		require.NoError(t, err)
+	}
+
+	intervals, err := jobs.Timeline("timeline-job", "rate")
+	require.NoError(t, err)
+	require.Len(t, intervals, 2, "Status-only versions must not split the rate timeline")
+
+	assert.Equal(t, 50.0, intervals[0].Value)
+	assert.Equal(t, 1, intervals[0].FromVersion)
+	assert.Equal(t, 2, intervals[0].ToVersion)
+	assert.True(t, intervals[0].From.Equal(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)))
+	require.NotNil(t, intervals[0].To)
+	assert.True(t, intervals[0].To.Equal(time.Date(2025, 1, 3, 0, 0, 0, 0, time.UTC)))
+
+	assert.Equal(t, 60.0, intervals[1].Value)
+	assert.Equal(t, 3, intervals[1].FromVersion)
+	assert.Equal(t, 4, intervals[1].ToVersion)
+	assert.Nil(t, intervals[1].To, "The current value has an open interval")
+
+	statuses, err := Timeline[*TestJob](db, "timeline-job", "status")
+	require.NoError(t, err)
+	assert.Len(t, statuses, 3, "active, paused, active")
+
+	// Deleting closes the last interval
+	now = now.Add(24 * time.Hour)
+	require.NoError(t, jobs.Delete("timeline-job"))
+	intervals, err = jobs.Timeline("timeline-job", "rate")
+	require.NoError(t, err)
+	require.NotNil(t, intervals[1].To)
+	assert.True(t, intervals[1].To.Equal(now))
+
+	_, err = jobs.Timeline("timeline-job", "salary")
+	assert.Error(t, err, "Unknown fields should be rejected")
+}
@@ -0,0 +1,83 @@
+package scd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// TestChangedBetween tests per-entity version changes in a window, with and without a column filter
+func TestChangedBetween(t *testing.T) {
+	db := setupTestDB(t)
+
+	monday := time.Date(2025, 6, 2, 0, 0, 0, 0, time.UTC)
+	now := monday.Add(-72 * time.Hour)
+	jobs, err := NewRepository[*TestJob](db, WithClock(func() time.Time { return now }))
+	require.NoError(t, err)
+
+	for _, id := range []string{"window-a", "window-b", "window-c"} {
+		_, err := jobs.Create(&TestJob{Model: Model{ID: id}, Status: "active", Rate: 50})
+		require.NoError(t, err)
+	}
+
+	// Before the window: a rate change that must not be reported
+	now = monday.Add(-time.Hour)
+	_, err = jobs.Update("window-a", func(j *TestJob) { j.Rate = 55 })
+	require.NoError(t, err)
+
+	// Inside the window
+	now = monday.Add(24 * time.Hour)
+	_, err = jobs.Update("window-a", func(j *TestJob) { j.Status = "paused" })
+	require.NoError(t, err)
+	now = monday.Add(48 * time.Hour)
+	_, err = jobs.Update("window-b", func(j *TestJob) { j.Rate = 60 })
+	require.NoError(t, err)
+	now = monday.Add(72 * time.Hour)
+	_, err = jobs.Update("window-b", func(j *TestJob) { j.Title = "Renamed" }) // no-op for status
+	require.NoError(t, err)
+
+	// A new entity created inside the window is not a version change
+	_, err = jobs.Create(&TestJob{Model: Model{ID: "window-new"}, Status: "active"})
+	require.NoError(t, err)
+
+	saturday := monday.Add(5 * 24 * time.Hour)
+
+	all, err := jobs.ChangedBetween(monday, saturday)
+	require.NoError(t, err)
+	require.Len(t, all, 3)
+	assert.Equal(t, "window-a", all[0].BusinessID)
+	assert.Equal(t, 2, all[0].FromVersion)
+	assert.Equal(t, 3, all[0].ToVersion)
+	assert.True(t, all[0].At.Equal(monday.Add(24*time.Hour)))
+	assert.Equal(t, []Change{{Field: "status", Old: "active", New: "paused"}}, all[0].Changes)
+	assert.Equal(t, []Change{{Field: "rate", Old: 50.0, New: 60.0}}, all[1].Changes)
+	assert.Equal(t, []Change{{Field: "title", Old: "", New: "Renamed"}}, all[2].Changes)
+
+	statusOnly, err := ChangedBetween[*TestJob](db, monday, saturday, "status")
+	require.NoError(t, err)
+	require.Len(t, statusOnly, 1, "Only window-a changed its status")
+	assert.Equal(t, "window-a", statusOnly[0].BusinessID)
+
+	rateOrStatus, err := ChangedBetween[*TestJob](db, monday, saturday, "status", "rate")
+	require.NoError(t, err)
+	assert.Len(t, rateOrStatus, 2)
+
+	none, err := ChangedBetween[*TestJob](db, saturday, saturday.Add(24*time.Hour))
+	require.NoError(t, err)
+	assert.Empty(t, none)
+
+	paused, err := NewRepository[*TestJob](db, WithDefaultScopes(func(db *gorm.DB) *gorm.DB {
+		return db.Where("test_jobs.status = ?", "paused")
+	}))
+	require.NoError(t, err)
+	scoped, err := paused.ChangedBetween(monday, saturday)
+	require.NoError(t, err)
+	require.Len(t, scoped, 1, "Default scopes select the entities to report")
+	assert.Equal(t, "window-a", scoped[0].BusinessID)
+
+	_, err = ChangedBetween[*TestJob](db, monday, saturday, "valid_to")
+	assert.ErrorIs(t, err, ErrUnknownField, "Bookkeeping columns cannot be compared")
+}
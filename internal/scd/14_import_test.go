@@ -0,0 +1,100 @@
+package scd
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// importedJob builds a backfilled job version with an explicit validity window
+func importedJob(id string, rate float64, from time.Time, to *time.Time) *TestJob {
+	return &TestJob{Model: Model{ID: id, ValidFrom: from, ValidTo: to}, Status: "active", Rate: rate}
+}
+
+// TestImportBackfillsHistory tests that imported versions keep their validity windows
+func TestImportBackfillsHistory(t *testing.T) {
+	db := setupTestDB(t)
+
+	d := func(year int, month time.Month) time.Time { return time.Date(year, month, 1, 0, 0, 0, 0, time.UTC) }
+	at := func(year int, month time.Month) *time.Time { t := d(year, month); return &t }
+
+	result, err := Import(db, []*TestJob{
+		importedJob("legacy-1", 30, d(2021, 1), at(2022, 3)),
+		importedJob("legacy-2", 80, d(2022, 6), nil),
+		importedJob("legacy-1", 35, d(2022, 3), at(2023, 7)),
+		importedJob("legacy-1", 40, d(2023, 7), nil),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, ImportResult{Entities: 2, Versions: 4}, result)
+
+	versions, err := GetAllVersions[*TestJob](db, "legacy-1")
+	require.NoError(t, err)
+	require.Len(t, versions, 3)
+	for i, version := range versions {
+		assert.Equal(t, i+1, version.Version, "Versions are numbered from the input order")
+	}
+	assert.True(t, versions[1].ValidFrom.Equal(d(2022, 3)), "valid_from is taken from the input")
+	require.NotNil(t, versions[1].ValidTo)
+	assert.True(t, versions[1].ValidTo.Equal(d(2023, 7)))
+
+	var past TestJob
+	require.NoError(t, db.Scopes(AsOf(d(2022, 12)), ByBusinessID("legacy-1")).First(&past).Error)
+	assert.Equal(t, 35.0, past.Rate)
+
+	// Imported entities continue through the normal write path
+	updated, err := Update(db, "legacy-1", func(j *TestJob) { j.Rate = 45 })
+	require.NoError(t, err)
+	assert.Equal(t, 4, updated.Version)
+}
+
+// TestImportRejectsInvalidChains tests that every problem is reported and nothing is written
+func TestImportRejectsInvalidChains(t *testing.T) {
+	db := setupTestDB(t)
+
+	d := func(day int) time.Time { return time.Date(2024, 1, day, 0, 0, 0, 0, time.UTC) }
+	at := func(day int) *time.Time { t := d(day); return &t }
+
+	_, err := CreateNew(db, &TestJob{Model: Model{ID: "existing"}, Status: "active", Rate: 10})
+	require.NoError(t, err)
+
+	numbered := importedJob("numbered", 10, d(1), nil)
+	numbered.Version = 2
+
+	_, err = Import(db, []*TestJob{
+		importedJob("gap", 10, d(1), at(5)),
+		importedJob("gap", 11, d(6), nil),
+		importedJob("overlap", 10, d(1), at(7)),
+		importedJob("overlap", 11, d(6), nil),
+		importedJob("open", 10, d(1), nil),
+		importedJob("open", 11, d(6), nil),
+		importedJob("unordered", 10, d(6), nil),
+		importedJob("unordered", 11, d(1), at(6)),
+		importedJob("empty-window", 10, d(3), at(3)),
+		numbered,
+		importedJob("", 10, d(1), nil),
+		importedJob("existing", 10, d(1), nil),
+		importedJob("valid", 10, d(1), nil),
+	})
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ErrInvalidImport))
+
+	var importErr *ImportError
+	require.ErrorAs(t, err, &importErr)
+	reported := map[string]bool{}
+	for _, issue := range importErr.Issues {
+		reported[issue.BusinessID] = true
+	}
+	for _, id := range []string{"gap", "overlap", "open", "unordered", "empty-window", "numbered", "", "existing"} {
+		assert.True(t, reported[id], "Expected an issue for %q", id)
+	}
+	assert.False(t, reported["valid"], "Valid chains should not be reported")
+
+	exists, err := Exists[*TestJob](db, "valid")
+	require.NoError(t, err)
+	assert.False(t, exists, "Nothing is written when validation fails")
+
+	assert.NoError(t, ValidateImport(db, []*TestJob{importedJob("valid", 10, d(1), nil)}))
+}
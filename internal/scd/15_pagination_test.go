@@ -0,0 +1,123 @@
+package scd
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRepositoryPageIsStable tests that pages neither skip nor repeat entities while versions are written
+func TestRepositoryPageIsStable(t *testing.T) {
+	db := setupTestDB(t)
+
+	now := time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)
+	jobs, err := NewRepository[*TestJob](db, WithClock(func() time.Time { return now }))
+	require.NoError(t, err)
+
+	for i := 1; i <= 7; i++ {
+		_, err := jobs.Create(&TestJob{Model: Model{ID: fmt.Sprintf("page-job-%d", i)}, Status: "active", Rate: float64(i)})
+		require.NoError(t, err)
+	}
+
+	now = now.Add(time.Hour)
+	first, err := jobs.Page(ListOptions{}, PageRequest{Size: 3})
+	require.NoError(t, err)
+	require.Len(t, first.Items, 3)
+	require.NotEmpty(t, first.NextCursor)
+
+	// Writes between pages: a new version on each side of the cursor and a new entity
+	now = now.Add(time.Hour)
+	_, err = jobs.Update("page-job-2", func(j *TestJob) { j.Rate = 20 })
+	require.NoError(t, err)
+	_, err = jobs.Update("page-job-5", func(j *TestJob) { j.Rate = 50 })
+	require.NoError(t, err)
+	_, err = jobs.Create(&TestJob{Model: Model{ID: "page-job-8"}, Status: "active"})
+	require.NoError(t, err)
+
+	seen := map[string]int{}
+	for _, job := range first.Items {
+		seen[job.ID]++
+	}
+	cursor := first.NextCursor
+	pages := 1
+	for cursor != "" {
+		page, err := jobs.Page(ListOptions{}, PageRequest{Size: 3, Cursor: cursor})
+		require.NoError(t, err)
+		for _, job := range page.Items {
+			seen[job.ID]++
+			if job.ID == "page-job-5" {
+				assert.Equal(t, 1, job.Version, "Later pages read the first page's snapshot")
+			}
+		}
+		cursor = page.NextCursor
+		pages++
+	}
+
+	assert.Equal(t, 3, pages)
+	assert.Len(t, seen, 7, "Entities created after the first page are not included")
+	for id, count := range seen {
+		assert.Equal(t, 1, count, "%s returned more than once", id)
+	}
+
+	// A fresh first page sees the new writes
+	fresh, err := jobs.Page(ListOptions{Filters: map[string]interface{}{"rate": 50.0}}, PageRequest{})
+	require.NoError(t, err)
+	require.Len(t, fresh.Items, 1)
+	assert.Equal(t, 2, fresh.Items[0].Version)
+	assert.Empty(t, fresh.NextCursor, "A single page has no next cursor")
+}
+
+// TestPaginateByValidFrom tests keyset pagination over all versions with tied timestamps
+func TestPaginateByValidFrom(t *testing.T) {
+	db := setupTestDB(t)
+
+	now := time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)
+	jobs, err := NewRepository[*TestJob](db, WithClock(func() time.Time { return now }))
+	require.NoError(t, err)
+
+	// Two entities share every timestamp, so ties must be broken by business ID
+	for _, id := range []string{"vf-b", "vf-a"} {
+		_, err := jobs.Create(&TestJob{Model: Model{ID: id}, Status: "active"})
+		require.NoError(t, err)
+	}
+	for day := 1; day <= 2; day++ {
+		now = now.Add(24 * time.Hour)
+		for _, id := range []string{"vf-b", "vf-a"} {
+			_, err := jobs.Update(id, func(j *TestJob) { j.Rate = float64(day) })
+			require.NoError(t, err)
+		}
+	}
+
+	var keys []string
+	req := PageRequest{Order: PageByValidFrom, Size: 4}
+	for {
+		page, err := Paginate[*TestJob](db.Model(&TestJob{}), req)
+		require.NoError(t, err)
+		for _, job := range page.Items {
+			keys = append(keys, fmt.Sprintf("%s@%d", job.ID, job.Version))
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		req.Cursor = page.NextCursor
+	}
+	assert.Equal(t, []string{"vf-a@1", "vf-b@1", "vf-a@2", "vf-b@2", "vf-a@3", "vf-b@3"}, keys)
+}
+
+// TestPaginateRejectsBadCursors tests cursor validation
+func TestPaginateRejectsBadCursors(t *testing.T) {
+	db := setupTestDB(t)
+
+	_, err := Paginate[*TestJob](db.Model(&TestJob{}), PageRequest{Cursor: "not-a-cursor"})
+	assert.ErrorIs(t, err, ErrInvalidCursor)
+
+	byID := Cursor{Order: PageByBusinessID, BusinessID: "job-1", Version: 1}.Encode()
+	_, err = Paginate[*TestJob](db.Model(&TestJob{}), PageRequest{Order: PageByValidFrom, Cursor: byID})
+	assert.ErrorIs(t, err, ErrInvalidCursor, "Cursors cannot switch ordering")
+
+	_, err = Paginate[*TestJob](db.Model(&TestJob{}), PageRequest{Cursor: byID})
+	assert.NoError(t, err)
+}
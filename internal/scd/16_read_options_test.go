@@ -0,0 +1,83 @@
+package scd
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// TestRepositoryReadOptions tests as-of, valid-during and include-deleted reads
+func TestRepositoryReadOptions(t *testing.T) {
+	db := setupTestDB(t)
+
+	day := func(d int) time.Time { return time.Date(2025, 5, d, 12, 0, 0, 0, time.UTC) }
+	now := day(1)
+	jobs, err := NewRepository[*TestJob](db, WithClock(func() time.Time { return now }))
+	require.NoError(t, err)
+
+	// kept: v1 on day 1, v2 on day 3; gone: v1 on day 1, v2 on day 2, deleted on day 4
+	_, err = jobs.Create(&TestJob{Model: Model{ID: "kept"}, Rate: 10})
+	require.NoError(t, err)
+	_, err = jobs.Create(&TestJob{Model: Model{ID: "gone"}, Rate: 20})
+	require.NoError(t, err)
+	now = day(2)
+	_, err = jobs.Update("gone", func(j *TestJob) { j.Rate = 21 })
+	require.NoError(t, err)
+	now = day(3)
+	_, err = jobs.Update("kept", func(j *TestJob) { j.Rate = 11 })
+	require.NoError(t, err)
+	now = day(4)
+	require.NoError(t, jobs.Delete("gone"))
+	now = day(5)
+
+	_, err = jobs.Get("gone", ReadOptions{})
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	gone, err := jobs.Get("gone", ReadOptions{IncludeDeleted: true})
+	require.NoError(t, err)
+	assert.Equal(t, 2, gone.Version, "Deleted entities are returned as their last version")
+
+	past, err := jobs.Get("gone", ReadOptions{AsOf: ptr(day(2).Add(-time.Hour))})
+	require.NoError(t, err)
+	assert.Equal(t, 1, past.Version)
+
+	lastKnown, err := jobs.Get("kept", ReadOptions{AsOf: ptr(day(2)), IncludeDeleted: true})
+	require.NoError(t, err)
+	assert.Equal(t, 1, lastKnown.Version, "Versions written after as_of are ignored")
+
+	rates := func(opts ListOptions) map[string]float64 {
+		results, err := jobs.List(opts)
+		require.NoError(t, err)
+		out := map[string]float64{}
+		for _, job := range results {
+			out[fmt.Sprintf("%s@%d", job.ID, job.Version)] = job.Rate
+		}
+		return out
+	}
+
+	assert.Equal(t, map[string]float64{"kept@2": 11}, rates(ListOptions{}))
+	assert.Equal(t, map[string]float64{"kept@2": 11, "gone@2": 21}, rates(ListOptions{IncludeDeleted: true}))
+	assert.Equal(t, map[string]float64{"kept@1": 10, "gone@2": 21}, rates(ListOptions{AsOf: ptr(day(2).Add(time.Hour))}))
+	assert.Equal(t, map[string]float64{"kept@1": 10, "gone@1": 20, "gone@2": 21},
+		rates(ListOptions{ValidDuring: &TimeRange{Start: day(1), End: day(2).Add(time.Hour)}}))
+
+	_, err = jobs.List(ListOptions{AsOf: ptr(day(2)), ValidDuring: &TimeRange{Start: day(1), End: day(2)}})
+	assert.Error(t, err, "AsOf and ValidDuring are exclusive")
+
+	page, err := jobs.Page(ListOptions{IncludeDeleted: true}, PageRequest{Size: 1})
+	require.NoError(t, err)
+	require.Len(t, page.Items, 1)
+	page, err = jobs.Page(ListOptions{IncludeDeleted: true}, PageRequest{Size: 1, Cursor: page.NextCursor})
+	require.NoError(t, err)
+	require.Len(t, page.Items, 1)
+	assert.Equal(t, "kept", page.Items[0].ID)
+}
+
+// ptr returns a pointer to t
+func ptr(t time.Time) *time.Time {
+	return &t
+}
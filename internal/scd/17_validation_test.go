@@ -0,0 +1,65 @@
+package scd
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestShiftPlan is a model with validate tags
+type TestShiftPlan struct {
+	Model
+	Status string `json:"status" validate:"oneof=draft published"`
+	Start  int64  `json:"start" validate:"required"`
+	End    int64  `json:"end" validate:"required,gtfield=Start"`
+}
+
+// TestValidationOnEveryWritePath tests that create, direct inserts, update, the plugin and import reject invalid versions
+func TestValidationOnEveryWritePath(t *testing.T) {
+	db := setupPluginTestDB(t)
+	require.NoError(t, db.AutoMigrate(&TestShiftPlan{}))
+
+	_, err := CreateNew(db, &TestShiftPlan{Model: Model{ID: "plan-bad"}, Status: "banana", Start: 10, End: 5})
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ErrValidation))
+
+	var invalid *ValidationError
+	require.ErrorAs(t, err, &invalid)
+	assert.Equal(t, "TestShiftPlan", invalid.Model)
+	assert.Equal(t, "plan-bad", invalid.BusinessID)
+	require.Len(t, invalid.Fields, 2, "Every failing field is reported")
+	assert.Equal(t, FieldError{Field: "status", Rule: "oneof", Param: "draft published", Message: "status must be one of: draft published"}, invalid.Fields[0])
+	assert.Equal(t, "end", invalid.Fields[1].Field)
+	assert.Equal(t, "start", invalid.Fields[1].Param, "Cross-field rules use JSON names")
+
+	exists, err := Exists[*TestShiftPlan](db, "plan-bad")
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	assert.ErrorIs(t, db.Create(&TestShiftPlan{Model: Model{ID: "plan-direct"}, Status: "banana", Start: 1, End: 2}).Error, ErrValidation, "Direct inserts are validated")
+
+	plan, err := CreateNew(db, &TestShiftPlan{Model: Model{ID: "plan-1"}, Status: "draft", Start: 10, End: 20})
+	require.NoError(t, err)
+
+	_, err = Update(db, "plan-1", func(p *TestShiftPlan) { p.End = 1 })
+	assert.ErrorIs(t, err, ErrValidation)
+
+	plan.Status = "archived"
+	assert.ErrorIs(t, db.Save(plan).Error, ErrValidation, "Versioned writes through the plugin are validated")
+
+	versions, err := GetAllVersions[*TestShiftPlan](db, "plan-1")
+	require.NoError(t, err)
+	require.Len(t, versions, 1, "Rejected updates must not write or close versions")
+	assert.Nil(t, versions[0].ValidTo)
+
+	_, err = Import(db, []*TestShiftPlan{
+		{Model: Model{ID: "plan-imported", ValidFrom: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}, Status: "published", Start: 1},
+	})
+	var importErr *ImportError
+	require.ErrorAs(t, err, &importErr)
+	require.Len(t, importErr.Issues, 1)
+	assert.Equal(t, "end is required", importErr.Issues[0].Message)
+}
@@ -0,0 +1,75 @@
+package scd
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestTicket is a model whose status follows a transition table
+type TestTicket struct {
+	Model
+	Status string `json:"status"`
+	Note   string `json:"note"`
+}
+
+var ticketTransitions = Transitions{
+	"open":        {"in-progress", "closed"},
+	"in-progress": {"open", "closed"},
+	"closed":      {},
+}
+
+func (t *TestTicket) State() string            { return t.Status }
+func (t *TestTicket) Transitions() Transitions { return ticketTransitions }
+
+// TestTransitionsTable tests Allows and Next on a transition table
+func TestTransitionsTable(t *testing.T) {
+	assert.True(t, ticketTransitions.Allows("open", "closed"))
+	assert.True(t, ticketTransitions.Allows("closed", "closed"), "Keeping the same state is always allowed")
+	assert.False(t, ticketTransitions.Allows("closed", "open"))
+	assert.False(t, ticketTransitions.Allows("unknown", "open"))
+
+	next := ticketTransitions.Next("open")
+	assert.Equal(t, []string{"in-progress", "closed"}, next)
+	next[0] = "mutated"
+	assert.Equal(t, "in-progress", ticketTransitions["open"][0], "Next returns a copy")
+	assert.Empty(t, ticketTransitions.Next("closed"))
+}
+
+// TestTransitionsEnforcedOnNewVersions tests that Update and the plugin reject disallowed state changes
+func TestTransitionsEnforcedOnNewVersions(t *testing.T) {
+	db := setupPluginTestDB(t)
+	require.NoError(t, db.AutoMigrate(&TestTicket{}))
+
+	_, err := CreateNew(db, &TestTicket{Model: Model{ID: "ticket-1"}, Status: "open"})
+	require.NoError(t, err)
+
+	_, err = Update(db, "ticket-1", func(ticket *TestTicket) { ticket.Note = "triaged" })
+	require.NoError(t, err, "Updates that keep the state are allowed")
+
+	closed, err := Update(db, "ticket-1", func(ticket *TestTicket) { ticket.Status = "closed" })
+	require.NoError(t, err)
+
+	_, err = Update(db, "ticket-1", func(ticket *TestTicket) { ticket.Status = "open" })
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ErrInvalidTransition))
+
+	var transition *TransitionError
+	require.ErrorAs(t, err, &transition)
+	assert.Equal(t, "TestTicket", transition.Model)
+	assert.Equal(t, "ticket-1", transition.BusinessID)
+	assert.Equal(t, "closed", transition.From)
+	assert.Equal(t, "open", transition.To)
+	assert.Empty(t, transition.Allowed)
+
+	closed.Status = "in-progress"
+	assert.ErrorIs(t, db.Save(closed).Error, ErrInvalidTransition, "Versioned writes through the plugin are checked")
+
+	versions, err := GetAllVersions[*TestTicket](db, "ticket-1")
+	require.NoError(t, err)
+	require.Len(t, versions, 3, "Rejected transitions must not write or close versions")
+	assert.Nil(t, versions[2].ValidTo)
+	assert.Equal(t, "closed", versions[2].Status)
+}
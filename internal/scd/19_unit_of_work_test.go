@@ -0,0 +1,78 @@
+package scd
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestUnitOfWorkSharesTimestamp tests that versions written in one unit share ValidFrom
+func TestUnitOfWorkSharesTimestamp(t *testing.T) {
+	db := setupPluginTestDB(t)
+	require.NoError(t, db.AutoMigrate(&TestTicket{}))
+
+	for _, id := range []string{"ticket-1", "ticket-2"} {
+		_, err := CreateNew(db, &TestTicket{Model: Model{ID: id}, Status: "open"})
+		require.NoError(t, err)
+	}
+	repo, err := NewRepository[*TestTicket](db)
+	require.NoError(t, err)
+
+	now := time.Now().Add(time.Minute)
+	err = runUnitOfWork(db, now, func(u *UnitOfWork) error {
+		if _, err := UpdateIn(u, "ticket-1", func(ticket *TestTicket) { ticket.Status = "closed" }); err != nil {
+			return err
+		}
+		_, err := repo.In(u).Update("ticket-2", func(ticket *TestTicket) { ticket.Status = "closed" })
+		return err
+	})
+	require.NoError(t, err)
+
+	first, err := GetLatest[*TestTicket](db, "ticket-1")
+	require.NoError(t, err)
+	second, err := GetLatest[*TestTicket](db, "ticket-2")
+	require.NoError(t, err)
+	assert.True(t, first.ValidFrom.Equal(now))
+	assert.True(t, second.ValidFrom.Equal(now))
+
+	var before []*TestTicket
+	require.NoError(t, db.Scopes(AsOf(now.Add(-time.Nanosecond))).Order("id").Find(&before).Error)
+	require.Len(t, before, 2)
+	assert.Equal(t, "open", before[0].Status)
+	assert.Equal(t, "open", before[1].Status)
+}
+
+// TestUnitOfWorkRollsBack tests that a failing write undoes every write of the unit
+func TestUnitOfWorkRollsBack(t *testing.T) {
+	db := setupPluginTestDB(t)
+	require.NoError(t, db.AutoMigrate(&TestTicket{}))
+
+	_, err := CreateNew(db, &TestTicket{Model: Model{ID: "ticket-1"}, Status: "open"})
+	require.NoError(t, err)
+	_, err = CreateNew(db, &TestTicket{Model: Model{ID: "ticket-2"}, Status: "closed"})
+	require.NoError(t, err)
+
+	err = RunUnitOfWork(db, func(u *UnitOfWork) error {
+		if _, err := CreateIn(u, &TestTicket{Model: Model{ID: "ticket-3"}, Status: "open"}); err != nil {
+			return err
+		}
+		if _, err := UpdateIn(u, "ticket-1", func(ticket *TestTicket) { ticket.Status = "in-progress" }); err != nil {
+			return err
+		}
+		_, err := UpdateIn(u, "ticket-2", func(ticket *TestTicket) { ticket.Status = "open" })
+		return err
+	})
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ErrInvalidTransition))
+
+	first, err := GetLatest[*TestTicket](db, "ticket-1")
+	require.NoError(t, err)
+	assert.Equal(t, "open", first.Status)
+	assert.Equal(t, 1, first.Version)
+	exists, err := Exists[*TestTicket](db, "ticket-3")
+	require.NoError(t, err)
+	assert.False(t, exists)
+}
@@ -0,0 +1,176 @@
+package scd
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// VersionChange is one version-to-version change of an entity, as reported by ChangedBetween
+type VersionChange struct {
+	BusinessID  string    `json:"id"`
+	FromVersion int       `json:"from_version"`
+	ToVersion   int       `json:"to_version"`
+	At          time.Time `json:"at"` // valid_from of the new version
+	Changes     []Change  `json:"changes"`
+}
+
+// ChangedBetween returns the version changes that took effect in [start, end)
+// A version change is a new version whose value differs from the previous version in at least
+// one of the given columns (any business column when none are given). Previous values are
+// read with LAG over the version order, so the comparison happens in the database:
+//
+//	// Jobs whose status changed during the week
+//	changes, err := scd.ChangedBetween[*models.Job](db, monday, saturday, "status")
+//
+// First versions (creations) have nothing to compare with and are not reported,
+// nor are soft deletes, which close a version without writing a new one. Scopes on db
+// select the entities to report, e.g. Repository default scopes.
+func ChangedBetween[T SCDModel](db *gorm.DB, start, end time.Time, columns ...string) ([]VersionChange, error) {
+	var zero T
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(zero); err != nil {
+		return nil, fmt.Errorf("failed to parse model schema: %w", err)
+	}
+	s := stmt.Schema
+	if s.PrioritizedPrimaryField == nil {
+		return nil, fmt.Errorf("%s has no primary key", s.Table)
+	}
+	cols, err := schemaColumns(s)
+	if err != nil {
+		return nil, err
+	}
+
+	fields, err := comparedFields(s, cols, columns)
+	if err != nil {
+		return nil, err
+	}
+	if len(fields) == 0 {
+		return nil, nil
+	}
+
+	// 1. Find the changed versions and their predecessors with window functions
+	pk := s.PrioritizedPrimaryField.DBName
+	q := stmt.Quote
+	selects := []string{q(pk), q(cols.BusinessID), q(cols.ValidFrom), "LAG(" + q(pk) + ") OVER w AS " + q("prev_"+pk)}
+	var changed []string
+	for _, field := range fields {
+		prev := "prev_" + field.DBName
+		selects = append(selects, q(field.DBName), "LAG("+q(field.DBName)+") OVER w AS "+q(prev))
+		changed = append(changed, "x."+q(field.DBName)+" "+distinctFrom(db)+" x."+q(prev))
+	}
+
+	// Entities with a version starting in the window; scopes on db restrict which ones are compared
+	entities := db.Session(&gorm.Session{}).Model(reflect.New(s.ModelType).Interface()).
+		Select(q(s.Table)+"."+q(cols.BusinessID)).
+		Where(q(s.Table)+"."+q(cols.ValidFrom)+" >= ? AND "+q(s.Table)+"."+q(cols.ValidFrom)+" < ?", start, end)
+
+	sql := `SELECT x.` + q(pk) + ` AS uid, x.` + q("prev_"+pk) + ` AS prev_uid
+		FROM (
+			SELECT ` + strings.Join(selects, ", ") + `
+			FROM ` + q(s.Table) + `
+			WHERE ` + q(cols.BusinessID) + ` IN (?)
+			WINDOW w AS (PARTITION BY ` + q(cols.BusinessID) + ` ORDER BY ` + q(cols.Version) + `)
+		) x
+		WHERE x.` + q(cols.ValidFrom) + ` >= ? AND x.` + q(cols.ValidFrom) + ` < ?
+		  AND x.` + q("prev_"+pk) + ` IS NOT NULL
+		  AND (` + strings.Join(changed, " OR ") + `)
+		ORDER BY x.` + q(cols.ValidFrom) + `, x.` + q(cols.BusinessID)
+
+	var pairs []struct {
+		UID     string
+		PrevUID string
+	}
+	raw := db.Session(&gorm.Session{NewDB: true})
+	if err := raw.Raw(sql, entities, start, end).Scan(&pairs).Error; err != nil {
+		return nil, fmt.Errorf("failed to query changes: %w", err)
+	}
+	if len(pairs) == 0 {
+		return nil, nil
+	}
+
+	// 2. Load both sides of every version change in one query
+	uids := make([]string, 0, 2*len(pairs))
+	for _, pair := range pairs {
+		uids = append(uids, pair.UID, pair.PrevUID)
+	}
+	var versions []T
+	if err := raw.Where(q(pk)+" IN ?", uids).Find(&versions).Error; err != nil {
+		return nil, fmt.Errorf("failed to load changed versions: %w", err)
+	}
+	byUID := make(map[string]T, len(versions))
+	for _, version := range versions {
+		byUID[version.GetUID().String()] = version
+	}
+
+	// 3. Describe each version change, keeping the database order
+	changes := make([]VersionChange, 0, len(pairs))
+	for _, pair := range pairs {
+		next, ok := byUID[pair.UID]
+		prev, okPrev := byUID[pair.PrevUID]
+		if !ok || !okPrev {
+			continue
+		}
+
+		nextRow := reflect.Indirect(reflect.ValueOf(next))
+		prevRow := reflect.Indirect(reflect.ValueOf(prev))
+		change := VersionChange{
+			BusinessID:  next.GetBusinessID(),
+			FromVersion: prev.GetVersion(),
+			ToVersion:   next.GetVersion(),
+		}
+		if validFrom := s.LookUpField(cols.ValidFrom); validFrom != nil {
+			change.At = timeValue(validFrom.ReflectValueOf(stmt.Context, nextRow))
+		}
+		for _, field := range fields {
+			oldValue, _ := field.ValueOf(stmt.Context, prevRow)
+			newValue, _ := field.ValueOf(stmt.Context, nextRow)
+			if !reflect.DeepEqual(oldValue, newValue) {
+				change.Changes = append(change.Changes, Change{Field: fieldName(field.StructField), Old: oldValue, New: newValue})
+			}
+		}
+		changes = append(changes, change)
+	}
+
+	return changes, nil
+}
+
+// comparedFields returns the schema fields to compare, defaulting to every business column
+func comparedFields(s *schema.Schema, cols Columns, columns []string) ([]*schema.Field, error) {
+	bookkeeping := cols.all()
+	for _, name := range s.PrimaryFieldDBNames {
+		bookkeeping[name] = true
+	}
+
+	if len(columns) == 0 {
+		var fields []*schema.Field
+		for _, field := range s.Fields {
+			if field.DBName != "" && !bookkeeping[field.DBName] {
+				fields = append(fields, field)
+			}
+		}
+		return fields, nil
+	}
+
+	fields := make([]*schema.Field, 0, len(columns))
+	for _, column := range columns {
+		field := s.LookUpField(column)
+		if field == nil || field.DBName == "" || bookkeeping[field.DBName] {
+			return nil, fmt.Errorf("%w %q on %s", ErrUnknownField, column, s.Table)
+		}
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+// distinctFrom returns the null-safe inequality operator of the dialect
+func distinctFrom(db *gorm.DB) string {
+	if db.Dialector.Name() == "sqlite" {
+		return "IS NOT"
+	}
+	return "IS DISTINCT FROM"
+}
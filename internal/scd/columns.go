@@ -0,0 +1,154 @@
+package scd
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// Columns names the SCD bookkeeping columns of a table
+// The primary key (uid) is taken from the GORM schema and is not configurable here.
+type Columns struct {
+	BusinessID string
+	Version    string
+	ValidFrom  string
+	ValidTo    string
+}
+
+// DefaultColumns are the columns used by models embedding scd.Model
+var DefaultColumns = Columns{
+	BusinessID: "id",
+	Version:    "version",
+	ValidFrom:  "valid_from",
+	ValidTo:    "valid_to",
+}
+
+// Struct tag values recognised on model fields, e.g. `scd:"valid_from"`
+const (
+	tagBusinessID = "business_id"
+	tagVersion    = "version"
+	tagValidFrom  = "valid_from"
+	tagValidTo    = "valid_to"
+)
+
+// registeredColumns holds the columns declared with RegisterColumns, keyed by struct type
+var registeredColumns sync.Map
+
+// RegisterColumns declares the SCD column names for an existing table
+// Use it when the struct cannot carry `scd` tags, for example:
+//
+//	scd.RegisterColumns(&LegacyRate{}, scd.Columns{ValidFrom: "effective_start", ValidTo: "effective_end"})
+//
+// Empty entries keep their default names. Registration takes precedence over struct tags.
+func RegisterColumns(model interface{}, cols Columns) {
+	registeredColumns.Store(structType(model), cols.withDefaults())
+}
+
+// ColumnsOf returns the SCD column names of a model
+// Names come from RegisterColumns, then from `scd:"..."` field tags, then from DefaultColumns,
+// and are validated against the model's GORM schema.
+func ColumnsOf(db *gorm.DB, model interface{}) (Columns, error) {
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(model); err != nil {
+		return Columns{}, fmt.Errorf("failed to parse model schema: %w", err)
+	}
+	return schemaColumns(stmt.Schema)
+}
+
+// schemaColumns resolves the SCD columns of a parsed schema
+func schemaColumns(s *schema.Schema) (Columns, error) {
+	if registered, ok := registeredColumns.Load(s.ModelType); ok {
+		cols := registered.(Columns)
+		for _, name := range []*string{&cols.BusinessID, &cols.Version, &cols.ValidFrom, &cols.ValidTo} {
+			field := s.LookUpField(*name)
+			if field == nil || field.DBName == "" {
+				return Columns{}, fmt.Errorf("registered SCD column %q not found on %s", *name, s.Table)
+			}
+			*name = field.DBName
+		}
+		return cols, nil
+	}
+
+	var cols Columns
+	for _, field := range s.Fields {
+		if field.DBName == "" {
+			continue
+		}
+		switch field.Tag.Get("scd") {
+		case tagBusinessID:
+			cols.BusinessID = field.DBName
+		case tagVersion:
+			cols.Version = field.DBName
+		case tagValidFrom:
+			cols.ValidFrom = field.DBName
+		case tagValidTo:
+			cols.ValidTo = field.DBName
+		}
+	}
+	return cols.withDefaults(), nil
+}
+
+// statementColumns resolves the SCD columns for the model a scope is applied to
+// Scopes run before GORM parses the statement, so the model (or destination) is parsed here.
+// Statements without a parseable model, e.g. db.Table("jobs").Find(&rows) into maps, use DefaultColumns.
+func statementColumns(db *gorm.DB) Columns {
+	if db.Statement.Schema != nil {
+		if cols, err := schemaColumns(db.Statement.Schema); err == nil {
+			return cols
+		}
+		return DefaultColumns
+	}
+
+	model := db.Statement.Model
+	if model == nil {
+		model = db.Statement.Dest
+	}
+	if model == nil {
+		return DefaultColumns
+	}
+
+	cols, err := ColumnsOf(db, model)
+	if err != nil {
+		return DefaultColumns
+	}
+	return cols
+}
+
+// withDefaults fills empty column names from DefaultColumns
+func (c Columns) withDefaults() Columns {
+	if c.BusinessID == "" {
+		c.BusinessID = DefaultColumns.BusinessID
+	}
+	if c.Version == "" {
+		c.Version = DefaultColumns.Version
+	}
+	if c.ValidFrom == "" {
+		c.ValidFrom = DefaultColumns.ValidFrom
+	}
+	if c.ValidTo == "" {
+		c.ValidTo = DefaultColumns.ValidTo
+	}
+	return c
+}
+
+// all returns the bookkeeping columns as a set
+func (c Columns) all() map[string]bool {
+	return map[string]bool{
+		c.BusinessID: true,
+		c.Version:    true,
+		c.ValidFrom:  true,
+		c.ValidTo:    true,
+	}
+}
+
+// structType returns the struct type behind a model value or pointer
+func structType(model interface{}) reflect.Type {
+	t := reflect.TypeOf(model)
+	for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+		t = t.Elem()
+	}
+	return t
+}
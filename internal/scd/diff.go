@@ -0,0 +1,94 @@
+package scd
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Change describes a single field that differs between two versions
+type Change struct {
+	Field string      `json:"field"`
+	Old   interface{} `json:"old"`
+	New   interface{} `json:"new"`
+}
+
+// Diff compares two versions of the same entity and returns the business fields that changed
+// SCD bookkeeping fields (uid, id, version, valid_from, valid_to, or fields tagged `scd:"..."`) are ignored.
+// Field names use the JSON tag when present so diffs line up with API payloads.
+// A nil prev is treated as an empty entity, so every populated field is reported.
+func Diff(prev, next interface{}) ([]Change, error) {
+	nextVal := indirectValue(reflect.ValueOf(next))
+	if !nextVal.IsValid() || nextVal.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("diff requires a struct, got %T", next)
+	}
+
+	prevVal := indirectValue(reflect.ValueOf(prev))
+	if !prevVal.IsValid() {
+		prevVal = reflect.Zero(nextVal.Type())
+	}
+	if prevVal.Type() != nextVal.Type() {
+		return nil, fmt.Errorf("cannot diff %s against %s", prevVal.Type(), nextVal.Type())
+	}
+
+	var changes []Change
+	collectChanges(prevVal, nextVal, &changes)
+	return changes, nil
+}
+
+// collectChanges walks struct fields, skipping the embedded SCD model and unexported fields
+func collectChanges(prev, next reflect.Value, changes *[]Change) {
+	modelType := reflect.TypeOf(Model{})
+
+	for i := 0; i < next.NumField(); i++ {
+		field := next.Type().Field(i)
+		if !field.IsExported() || field.Type == modelType || field.Tag.Get("scd") != "" {
+			continue
+		}
+
+		// Related versions (preloaded relations) are not part of this entity's state
+		if field.Type.Kind() == reflect.Ptr && field.Type.Implements(scdModelType) {
+			continue
+		}
+
+		// Recurse into other embedded structs (e.g. shared mixins)
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			collectChanges(prev.Field(i), next.Field(i), changes)
+			continue
+		}
+
+		name := fieldName(field)
+		if name == "" {
+			continue
+		}
+
+		oldValue := prev.Field(i).Interface()
+		newValue := next.Field(i).Interface()
+		if !reflect.DeepEqual(oldValue, newValue) {
+			*changes = append(*changes, Change{Field: name, Old: oldValue, New: newValue})
+		}
+	}
+}
+
+// fieldName returns the JSON name of a field, or "" when the field is hidden from JSON
+func fieldName(field reflect.StructField) string {
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return ""
+	}
+	if name := strings.Split(tag, ",")[0]; name != "" {
+		return name
+	}
+	return field.Name
+}
+
+// indirectValue dereferences pointers until it reaches a non-pointer value
+func indirectValue(v reflect.Value) reflect.Value {
+	for v.IsValid() && v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+	return v
+}
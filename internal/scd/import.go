@@ -0,0 +1,209 @@
+package scd
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// importBatchSize is the number of rows inserted per statement by Import
+const importBatchSize = 500
+
+// ErrInvalidImport is wrapped by the ImportError returned when backfilled versions fail validation
+var ErrInvalidImport = errors.New("invalid import")
+
+// ImportIssue describes one problem found in the imported versions
+type ImportIssue struct {
+	BusinessID string `json:"id"`
+	Index      int    `json:"index"` // position of the offending version in the input
+	Message    string `json:"message"`
+}
+
+// ImportError lists every issue found while validating an import
+// Nothing is written when validation fails.
+type ImportError struct {
+	Issues []ImportIssue
+}
+
+// Error implements error
+func (e *ImportError) Error() string {
+	lines := make([]string, 0, len(e.Issues))
+	for _, issue := range e.Issues {
+		lines = append(lines, fmt.Sprintf("#%d %s: %s", issue.Index, issue.BusinessID, issue.Message))
+	}
+	return fmt.Sprintf("%s: %d issue(s): %s", ErrInvalidImport, len(e.Issues), strings.Join(lines, "; "))
+}
+
+// Unwrap lets errors.Is match ErrInvalidImport
+func (e *ImportError) Unwrap() error {
+	return ErrInvalidImport
+}
+
+// ImportResult summarises a successful import
+type ImportResult struct {
+	Entities int `json:"entities"`
+	Versions int `json:"versions"`
+}
+
+// Import backfills historical versions with explicit validity windows
+// Unlike CreateNew and Update, valid_from and valid_to are taken from the input, so years of
+// history can be loaded at once. Versions of the same business ID must be given oldest first,
+// and form an unbroken chain:
+//
+//	v1 [2021-01-01, 2022-03-01) → v2 [2022-03-01, 2023-07-15) → v3 [2023-07-15, open)
+//
+// Only the last version may be open (valid_to NULL). Version numbers may be left at zero and are
+// assigned from the order; when set they must be 1..n. Business IDs that already have versions
+// are rejected. All checks run before anything is written and every problem is reported in an
+// *ImportError; the rows are then inserted in batches inside one transaction.
+func Import[T SCDModel](db *gorm.DB, versions []T) (ImportResult, error) {
+	groups, order, err := validateImport(db, versions)
+	if err != nil {
+		return ImportResult{}, err
+	}
+	if len(versions) == 0 {
+		return ImportResult{}, nil
+	}
+
+	rows := make([]T, 0, len(versions))
+	for _, id := range order {
+		for i, index := range groups[id] {
+			version := versions[index]
+			if version.GetUID() == uuid.Nil {
+				version.SetUID(uuid.New())
+			}
+			version.SetVersion(i + 1)
+			rows = append(rows, version)
+		}
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		return skipVersioning(tx).Omit(clause.Associations).CreateInBatches(rows, importBatchSize).Error
+	})
+	if err != nil {
+		return ImportResult{}, fmt.Errorf("failed to insert imported versions: %w", err)
+	}
+
+	return ImportResult{Entities: len(order), Versions: len(rows)}, nil
+}
+
+// ValidateImport runs the checks of Import without writing anything
+func ValidateImport[T SCDModel](db *gorm.DB, versions []T) error {
+	_, _, err := validateImport(db, versions)
+	return err
+}
+
+// validateImport groups the versions by business ID (keeping first-seen order) and checks each chain
+func validateImport[T SCDModel](db *gorm.DB, versions []T) (map[string][]int, []string, error) {
+	var zero T
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(zero); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse model schema: %w", err)
+	}
+	cols, err := schemaColumns(stmt.Schema)
+	if err != nil {
+		return nil, nil, err
+	}
+	validFrom := stmt.Schema.LookUpField(cols.ValidFrom)
+	validTo := stmt.Schema.LookUpField(cols.ValidTo)
+	if validFrom == nil || validTo == nil {
+		return nil, nil, fmt.Errorf("validity columns not found on %s", stmt.Schema.Table)
+	}
+
+	var issues []ImportIssue
+	report := func(id string, index int, format string, args ...interface{}) {
+		issues = append(issues, ImportIssue{BusinessID: id, Index: index, Message: fmt.Sprintf(format, args...)})
+	}
+
+	groups := map[string][]int{}
+	var order []string
+	for index, version := range versions {
+		id := version.GetBusinessID()
+		if id == "" {
+			report(id, index, "business ID is required")
+			continue
+		}
+		if _, ok := groups[id]; !ok {
+			order = append(order, id)
+		}
+		groups[id] = append(groups[id], index)
+	}
+
+	for _, id := range order {
+		indexes := groups[id]
+		for i, index := range indexes {
+			from, to := importWindow(stmt, validFrom, validTo, versions[index])
+			if from.IsZero() {
+				report(id, index, "%s is required", cols.ValidFrom)
+				continue
+			}
+			if to != nil && !to.After(from) {
+				report(id, index, "%s %s is not after %s %s", cols.ValidTo, to.Format(time.RFC3339), cols.ValidFrom, from.Format(time.RFC3339))
+			}
+			var invalid *ValidationError
+			if err := Validate(versions[index]); errors.As(err, &invalid) {
+				for _, field := range invalid.Fields {
+					report(id, index, "%s", field.Message)
+				}
+			} else if err != nil {
+				return nil, nil, err
+			}
+			if v := versions[index].GetVersion(); v != 0 && v != i+1 {
+				report(id, index, "expected version %d, got %d", i+1, v)
+			}
+
+			if i == len(indexes)-1 {
+				continue
+			}
+			nextFrom, _ := importWindow(stmt, validFrom, validTo, versions[indexes[i+1]])
+			switch {
+			case !nextFrom.IsZero() && !nextFrom.After(from):
+				report(id, indexes[i+1], "versions are out of order: %s %s is not after the previous version's %s",
+					cols.ValidFrom, nextFrom.Format(time.RFC3339), from.Format(time.RFC3339))
+			case to == nil:
+				report(id, index, "only the last version may be open, but %s is NULL", cols.ValidTo)
+			case nextFrom.IsZero():
+				// reported when the next version is checked
+			case to.Before(nextFrom):
+				report(id, index, "gap: %s %s is before the next version's %s %s",
+					cols.ValidTo, to.Format(time.RFC3339), cols.ValidFrom, nextFrom.Format(time.RFC3339))
+			case to.After(nextFrom):
+				report(id, index, "overlap: %s %s is after the next version's %s %s",
+					cols.ValidTo, to.Format(time.RFC3339), cols.ValidFrom, nextFrom.Format(time.RFC3339))
+			}
+		}
+	}
+
+	// Backfills only create new entities, they never rewrite existing history
+	for start := 0; start < len(order); start += importBatchSize {
+		end := min(start+importBatchSize, len(order))
+		var existing []string
+		err := db.Table(stmt.Schema.Table).Distinct(cols.BusinessID).
+			Where(cols.BusinessID+" IN ?", order[start:end]).
+			Pluck(cols.BusinessID, &existing).Error
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to check existing entities: %w", err)
+		}
+		for _, id := range existing {
+			report(id, groups[id][0], "entity already has versions in %s", stmt.Schema.Table)
+		}
+	}
+
+	if len(issues) > 0 {
+		return nil, nil, &ImportError{Issues: issues}
+	}
+	return groups, order, nil
+}
+
+// importWindow reads the validity window of one imported version
+func importWindow(stmt *gorm.Statement, validFrom, validTo *schema.Field, version SCDModel) (time.Time, *time.Time) {
+	row := reflect.Indirect(reflect.ValueOf(version))
+	return timeValue(validFrom.ReflectValueOf(stmt.Context, row)), optionalTimeValue(validTo.ReflectValueOf(stmt.Context, row))
+}
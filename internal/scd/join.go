@@ -0,0 +1,170 @@
+package scd
+
+import (
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// TemporalJoin joins an SCD model onto the current query
+// Three join semantics are supported, matching how references between versions are read:
+//
+//	// Pinned: the exact version a foreign key points at
+//	db.Model(&models.PaymentLineItem{}).Scopes(scd.JoinSCD(&models.Job{}).As("job").ByUID("job_uid"))
+//
+//	// Floating: the latest version of the same business entity
+//	db.Scopes(scd.JoinSCD(&models.Job{}).As("latest_job").Latest("job.id"))
+//
+//	// As-of: the version valid at a time column of the other table
+//	db.Model(&models.Timelog{}).Scopes(
+//		scd.JoinSCD(&models.Job{}).As("job").ByUID("job_uid"),
+//		scd.JoinSCD(&models.Job{}).As("job_then").AsOfUnix("job.id", "time_start"),
+//	)
+//
+// Column arguments without a table prefix refer to the query's main table.
+// Use On(model).As(alias) to add further SCD conditions on the joined alias.
+type TemporalJoin struct {
+	model    interface{}
+	alias    string
+	joinType string
+}
+
+// JoinSCD starts a join onto the table of the SCD model
+func JoinSCD(model interface{}) TemporalJoin {
+	return TemporalJoin{model: model, joinType: "JOIN"}
+}
+
+// As sets the alias of the joined table, required when the same model is joined twice
+func (j TemporalJoin) As(alias string) TemporalJoin {
+	j.alias = alias
+	return j
+}
+
+// Left turns the join into a LEFT JOIN so rows without a matching version are kept
+func (j TemporalJoin) Left() TemporalJoin {
+	j.joinType = "LEFT JOIN"
+	return j
+}
+
+// ByUID joins the exact version referenced by uidColumn (pinned semantics)
+func (j TemporalJoin) ByUID(uidColumn string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		joined, ok := j.resolve(db)
+		if !ok {
+			return db
+		}
+		return db.Joins(j.joinType+" ? ON ? = ?", joined.table, joined.primaryKey, joinColumn(uidColumn))
+	}
+}
+
+// Latest joins the current version of the business entity in businessIDColumn (floating semantics)
+func (j TemporalJoin) Latest(businessIDColumn string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		joined, ok := j.resolve(db)
+		if !ok {
+			return db
+		}
+		return db.Joins(j.joinType+" ? ON ? = ? AND ? IS NULL",
+			joined.table, joined.column(joined.cols.BusinessID), joinColumn(businessIDColumn), joined.column(joined.cols.ValidTo))
+	}
+}
+
+// AsOf joins the version of the business entity that was valid at timeColumn
+// timeColumn must hold timestamps, e.g. another table's valid_from
+func (j TemporalJoin) AsOf(businessIDColumn, timeColumn string) func(*gorm.DB) *gorm.DB {
+	return j.asOf(businessIDColumn, timeColumn, false)
+}
+
+// AsOfUnix is AsOf for a time column holding unix seconds, e.g. timelogs.time_start
+func (j TemporalJoin) AsOfUnix(businessIDColumn, unixColumn string) func(*gorm.DB) *gorm.DB {
+	return j.asOf(businessIDColumn, unixColumn, true)
+}
+
+func (j TemporalJoin) asOf(businessIDColumn, timeColumn string, unix bool) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		joined, ok := j.resolve(db)
+		if !ok {
+			return db
+		}
+
+		dialect := db.Dialector.Name()
+		at := timeExpr(dialect, joinColumn(timeColumn), unix)
+		validFrom := timeExpr(dialect, joined.column(joined.cols.ValidFrom), false)
+		validTo := timeExpr(dialect, joined.column(joined.cols.ValidTo), false)
+
+		return db.Joins(j.joinType+" ? ON ? = ? AND ? <= ? AND (? IS NULL OR ? > ?)",
+			joined.table, joined.column(joined.cols.BusinessID), joinColumn(businessIDColumn),
+			validFrom, at, joined.column(joined.cols.ValidTo), validTo, at)
+	}
+}
+
+// joinedTable describes the table being joined
+type joinedTable struct {
+	table      clause.Table
+	ref        string
+	primaryKey clause.Column
+	cols       Columns
+}
+
+// column returns a column of the joined table qualified with its alias
+func (t joinedTable) column(name string) clause.Column {
+	return clause.Column{Table: t.ref, Name: name}
+}
+
+// resolve parses the joined model, adding an error to db when it is not a valid model
+func (j TemporalJoin) resolve(db *gorm.DB) (joinedTable, bool) {
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(j.model); err != nil {
+		db.AddError(err)
+		return joinedTable{}, false
+	}
+	cols, err := schemaColumns(stmt.Schema)
+	if err != nil {
+		db.AddError(err)
+		return joinedTable{}, false
+	}
+
+	joined := joinedTable{table: clause.Table{Name: stmt.Schema.Table}, ref: stmt.Schema.Table, cols: cols}
+	if j.alias != "" && j.alias != stmt.Schema.Table {
+		joined.table.Alias = j.alias
+		joined.ref = j.alias
+	}
+
+	pk := "uid"
+	if stmt.Schema.PrioritizedPrimaryField != nil {
+		pk = stmt.Schema.PrioritizedPrimaryField.DBName
+	}
+	joined.primaryKey = joined.column(pk)
+
+	return joined, true
+}
+
+// joinColumn parses "table.column" or "column" (on the main table) into a quoted column reference
+func joinColumn(name string) clause.Column {
+	if table, column, ok := strings.Cut(name, "."); ok {
+		return clause.Column{Table: table, Name: column}
+	}
+	return clause.Column{Table: clause.CurrentTable, Name: name}
+}
+
+// timeExpr wraps a column so timestamps compare correctly on the given dialect
+// SQLite stores timestamps as text with a zone offset, so both sides are normalised with julianday.
+func timeExpr(dialect string, col clause.Column, unix bool) clause.Expression {
+	switch dialect {
+	case "sqlite":
+		if unix {
+			return clause.Expr{SQL: "julianday(?, 'unixepoch')", Vars: []interface{}{col}}
+		}
+		return clause.Expr{SQL: "julianday(?)", Vars: []interface{}{col}}
+	case "postgres":
+		if unix {
+			return clause.Expr{SQL: "to_timestamp(?)", Vars: []interface{}{col}}
+		}
+	case "mysql":
+		if unix {
+			return clause.Expr{SQL: "FROM_UNIXTIME(?)", Vars: []interface{}{col}}
+		}
+	}
+	return clause.Expr{SQL: "?", Vars: []interface{}{col}}
+}
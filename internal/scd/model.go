@@ -6,6 +6,7 @@ import (
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // SCDModel interface ensures models have required SCD methods
@@ -64,6 +65,7 @@ func (m *Model) SetValidFrom(t time.Time) {
 }
 
 // BeforeCreate sets Version=1 for new business IDs, increments for existing IDs
+// It also validates the version, so direct inserts that bypass CreateNew are checked too.
 func (m *Model) BeforeCreate(tx *gorm.DB) error {
 	// Generate UUID if not set
 	if m.UID == uuid.Nil {
@@ -75,10 +77,25 @@ func (m *Model) BeforeCreate(tx *gorm.DB) error {
 		return errors.New("business ID cannot be empty")
 	}
 
-	// If version not set, determine next version
+	// Versions inserted directly (e.g. raw db.Create) are held to the same rules as CreateNew
+	if model, ok := tx.Statement.Model.(SCDModel); ok {
+		if err := Validate(model); err != nil {
+			return err
+		}
+	}
+
+	// If version not set, determine next version from the table being written,
+	// using the columns declared for the model being created
 	if m.Version == 0 {
+		cols, err := ColumnsOf(tx, tx.Statement.Model)
+		if err != nil {
+			return err
+		}
 		var maxVersion int
-		err := tx.Model(m).Select("COALESCE(MAX(version), 0)").Where("id = ?", m.ID).Scan(&maxVersion).Error
+		err = newSession(tx).Table(tx.Statement.Table).
+			Select("COALESCE(MAX(" + tx.Statement.Quote(cols.Version) + "), 0)").
+			Where(clause.Eq{Column: clause.Column{Name: cols.BusinessID}, Value: m.ID}).
+			Scan(&maxVersion).Error
 		if err != nil {
 			return err
 		}
@@ -0,0 +1,200 @@
+package scd
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Page size limits applied by Paginate
+const (
+	DefaultPageSize = 50
+	MaxPageSize     = 500
+)
+
+// ErrInvalidCursor is returned for cursors that are malformed or belong to another ordering
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// PageOrder is the keyset a listing is paginated by
+type PageOrder string
+
+const (
+	// PageByBusinessID orders by business ID, then version
+	PageByBusinessID PageOrder = "id"
+	// PageByValidFrom orders by validity start, then business ID and version
+	PageByValidFrom PageOrder = "valid_from"
+)
+
+// Cursor is the position after the last row of a page
+// Snapshot pins later pages to the versions that were valid when the first page was read,
+// so entities updated in the meantime are neither skipped nor returned twice.
+type Cursor struct {
+	Order      PageOrder  `json:"o"`
+	BusinessID string     `json:"id"`
+	Version    int        `json:"v"`
+	ValidFrom  *time.Time `json:"f,omitempty"`
+	Snapshot   *time.Time `json:"s,omitempty"`
+}
+
+// Encode returns the opaque string form of the cursor
+func (c Cursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor parses a cursor returned in Page.NextCursor
+func DecodeCursor(value string) (Cursor, error) {
+	var cursor Cursor
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return cursor, fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+	}
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return cursor, fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+	}
+	if cursor.BusinessID == "" || (cursor.Order == PageByValidFrom && cursor.ValidFrom == nil) {
+		return cursor, fmt.Errorf("%w: missing position", ErrInvalidCursor)
+	}
+	if cursor.Order != PageByBusinessID && cursor.Order != PageByValidFrom {
+		return cursor, fmt.Errorf("%w: unknown order %q", ErrInvalidCursor, cursor.Order)
+	}
+	return cursor, nil
+}
+
+// PageRequest selects one page of a listing
+type PageRequest struct {
+	Order  PageOrder // defaults to PageByBusinessID
+	Cursor string    // NextCursor of the previous page, empty for the first page
+	Size   int       // defaults to DefaultPageSize, capped at MaxPageSize
+}
+
+// Page is one page of results
+type Page[T SCDModel] struct {
+	Items      []T    `json:"data"`
+	NextCursor string `json:"next_cursor,omitempty"` // empty on the last page
+}
+
+// Paginate reads one page of the query using keyset pagination
+// The keyset columns are qualified with the model's table, so queries with joins are supported:
+//
+//	page, err := scd.Paginate[*models.Job](db.Scopes(scd.Latest), scd.PageRequest{Size: 100})
+//	next, err := scd.Paginate[*models.Job](db.Scopes(scd.Latest), scd.PageRequest{Size: 100, Cursor: page.NextCursor})
+//
+// Unlike offsets, a keyset never skips or repeats rows when rows before the cursor change.
+// Use Repository.Page to also pin later pages to the first page's snapshot.
+func Paginate[T SCDModel](query *gorm.DB, req PageRequest) (Page[T], error) {
+	var after *Cursor
+	if req.Cursor != "" {
+		cursor, err := DecodeCursor(req.Cursor)
+		if err != nil {
+			return Page[T]{}, err
+		}
+		after = &cursor
+	}
+	return paginate[T](query, req, after, nil)
+}
+
+// paginate implements Paginate, recording snapshot in the next cursor
+func paginate[T SCDModel](query *gorm.DB, req PageRequest, after *Cursor, snapshot *time.Time) (Page[T], error) {
+	order := req.Order
+	if order == "" {
+		order = PageByBusinessID
+	}
+	if order != PageByBusinessID && order != PageByValidFrom {
+		return Page[T]{}, fmt.Errorf("%w: unknown order %q", ErrInvalidCursor, order)
+	}
+	if after != nil && after.Order != order {
+		return Page[T]{}, fmt.Errorf("%w: cursor is for order %q", ErrInvalidCursor, after.Order)
+	}
+	size := req.Size
+	if size <= 0 {
+		size = DefaultPageSize
+	}
+	size = min(size, MaxPageSize)
+
+	// Read one extra row to know whether another page follows
+	var items []T
+	if err := query.Scopes(keyset[T](order, after)).Limit(size + 1).Find(&items).Error; err != nil {
+		return Page[T]{}, err
+	}
+	if len(items) <= size {
+		return Page[T]{Items: items}, nil
+	}
+	items = items[:size]
+
+	last := items[size-1]
+	next := Cursor{Order: order, BusinessID: last.GetBusinessID(), Version: last.GetVersion(), Snapshot: snapshot}
+	if order == PageByValidFrom {
+		validFrom, err := validFromOf(query, last)
+		if err != nil {
+			return Page[T]{}, err
+		}
+		next.ValidFrom = &validFrom
+	}
+	return Page[T]{Items: items, NextCursor: next.Encode()}, nil
+}
+
+// keyset orders by the page keys and starts after the cursor
+func keyset[T SCDModel](order PageOrder, after *Cursor) func(*gorm.DB) *gorm.DB {
+	var zero T
+	bound := On(zero)
+	return func(db *gorm.DB) *gorm.DB {
+		cols := bound.Columns(db)
+		businessID := bound.Column(db, cols.BusinessID)
+		version := bound.Column(db, cols.Version)
+
+		keys := []clause.Column{businessID, version}
+		var values []interface{}
+		if after != nil {
+			values = []interface{}{after.BusinessID, after.Version}
+		}
+		if order == PageByValidFrom {
+			keys = append([]clause.Column{bound.Column(db, cols.ValidFrom)}, keys...)
+			if after != nil {
+				values = append([]interface{}{*after.ValidFrom}, values...)
+			}
+		}
+
+		for _, key := range keys {
+			db = db.Order(clause.OrderByColumn{Column: key})
+		}
+		if after == nil {
+			return db
+		}
+
+		// (k1 > v1) OR (k1 = v1 AND k2 > v2) OR (k1 = v1 AND k2 = v2 AND k3 > v3)
+		var branches []clause.Expression
+		for i := range keys {
+			var conds []clause.Expression
+			for j := 0; j < i; j++ {
+				conds = append(conds, clause.Eq{Column: keys[j], Value: values[j]})
+			}
+			conds = append(conds, clause.Gt{Column: keys[i], Value: values[i]})
+			branches = append(branches, clause.And(conds...))
+		}
+		return db.Where(clause.Or(branches...))
+	}
+}
+
+// validFromOf reads the validity start of a version through the model schema
+func validFromOf(db *gorm.DB, version SCDModel) (time.Time, error) {
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(version); err != nil {
+		return time.Time{}, fmt.Errorf("failed to parse model schema: %w", err)
+	}
+	cols, err := schemaColumns(stmt.Schema)
+	if err != nil {
+		return time.Time{}, err
+	}
+	field := stmt.Schema.LookUpField(cols.ValidFrom)
+	if field == nil {
+		return time.Time{}, fmt.Errorf("validity columns not found on %s", stmt.Schema.Table)
+	}
+	return timeValue(field.ReflectValueOf(stmt.Context, reflect.Indirect(reflect.ValueOf(version)))), nil
+}
@@ -0,0 +1,280 @@
+package scd
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/callbacks"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// skipVersioningKey marks statements issued by the library itself so the plugin lets them through
+const skipVersioningKey = "scd:skip_versioning"
+
+var (
+	// ErrHistoricalVersion is returned when a write targets a version that has already been closed
+	ErrHistoricalVersion = errors.New("historical SCD versions are immutable")
+
+	// ErrUnsupportedAssignment is returned when an update uses a SQL expression the plugin cannot apply in memory
+	ErrUnsupportedAssignment = errors.New("SQL expression assignments are not supported on SCD models")
+)
+
+// scdModelType is used to detect schemas whose model implements SCDModel
+var scdModelType = reflect.TypeOf((*SCDModel)(nil)).Elem()
+
+// VersioningPlugin rewrites in-place writes on SCD models into versioned writes
+//
+//	db.Use(&scd.VersioningPlugin{})
+//
+// Once registered, db.Save(&job) and db.Model(&job).Updates(...) create a new version
+// instead of mutating the row, and db.Delete(&job) soft deletes the latest version.
+// Writes that target an already closed version fail with ErrHistoricalVersion.
+// Physical deletes are only allowed through db.Unscoped().Delete(...).
+type VersioningPlugin struct {
+	// AfterVersion, if set, runs in the write's transaction after each new version is written,
+	// e.g. to keep rows that reference the entity in step. An error fails the write.
+	AfterVersion func(tx *gorm.DB, version SCDModel) error
+}
+
+// Name implements gorm.Plugin
+func (VersioningPlugin) Name() string {
+	return "scd:versioning"
+}
+
+// Initialize implements gorm.Plugin by wrapping the default update and delete callbacks
+func (p VersioningPlugin) Initialize(db *gorm.DB) error {
+	update := db.Callback().Update()
+	if original := update.Get("gorm:update"); original != nil {
+		if err := update.Replace("gorm:update", p.update(original)); err != nil {
+			return fmt.Errorf("failed to register versioned update: %w", err)
+		}
+	}
+
+	del := db.Callback().Delete()
+	if original := del.Get("gorm:delete"); original != nil {
+		if err := del.Replace("gorm:delete", p.delete(original)); err != nil {
+			return fmt.Errorf("failed to register versioned delete: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// skipVersioning marks a statement so the plugin passes it to the default callbacks
+func skipVersioning(db *gorm.DB) *gorm.DB {
+	return db.Set(skipVersioningKey, true)
+}
+
+// isVersioned reports whether the statement targets an SCD model and was not issued by the library
+func isVersioned(db *gorm.DB) bool {
+	if db.Statement.Schema == nil {
+		return false
+	}
+	if skip, ok := db.Get(skipVersioningKey); ok && skip.(bool) {
+		return false
+	}
+	return reflect.PointerTo(db.Statement.Schema.ModelType).Implements(scdModelType)
+}
+
+// update replaces gorm:update with a versioned update for SCD models
+func (p VersioningPlugin) update(original func(*gorm.DB)) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		if db.Error != nil || !isVersioned(db) {
+			original(db)
+			return
+		}
+
+		stmt := db.Statement
+		set := callbacks.ConvertToAssignments(stmt)
+		if len(set) == 0 {
+			return
+		}
+		if !hasWhere(db) {
+			db.AddError(gorm.ErrMissingWhereClause)
+			return
+		}
+
+		// Bookkeeping columns are managed by the library and never copied from an update
+		cols, err := schemaColumns(stmt.Schema)
+		if err != nil {
+			db.AddError(err)
+			return
+		}
+		bookkeeping := cols.all()
+		for _, name := range stmt.Schema.PrimaryFieldDBNames {
+			bookkeeping[name] = true
+		}
+
+		targets, err := findTargets(db)
+		if err != nil {
+			db.AddError(err)
+			return
+		}
+
+		ts := time.Now()
+		tx := newSession(db)
+		var written []reflect.Value
+
+		for _, prev := range targets {
+			next := reflect.New(stmt.Schema.ModelType)
+			next.Elem().Set(prev.Elem())
+
+			for _, assignment := range set {
+				if bookkeeping[assignment.Column.Name] {
+					continue
+				}
+				field := stmt.Schema.LookUpField(assignment.Column.Name)
+				if field == nil {
+					continue
+				}
+				if _, ok := assignment.Value.(clause.Expression); ok {
+					db.AddError(fmt.Errorf("%w: column %s", ErrUnsupportedAssignment, assignment.Column.Name))
+					return
+				}
+				if err := field.Set(stmt.Context, next.Elem(), assignment.Value); err != nil {
+					db.AddError(fmt.Errorf("failed to apply %s: %w", assignment.Column.Name, err))
+					return
+				}
+			}
+
+			if err := writeNextVersion(tx, stmt.Table, prev.Interface().(SCDModel), next.Interface().(SCDModel), ts); err != nil {
+				db.AddError(err)
+				return
+			}
+			if p.AfterVersion != nil {
+				hookTx := db.Session(&gorm.Session{NewDB: true, Context: stmt.Context})
+				if err := p.AfterVersion(hookTx, next.Interface().(SCDModel)); err != nil {
+					db.AddError(err)
+					return
+				}
+			}
+			written = append(written, next)
+		}
+
+		// Reflect the new version back into the caller's struct, e.g. after db.Save(&job)
+		if len(written) == 1 && stmt.ReflectValue.Kind() == reflect.Struct && stmt.ReflectValue.CanSet() &&
+			stmt.ReflectValue.Type() == stmt.Schema.ModelType {
+			stmt.ReflectValue.Set(written[0].Elem())
+		}
+
+		db.RowsAffected = int64(len(written))
+	}
+}
+
+// delete replaces gorm:delete with a soft delete for SCD models unless the statement is Unscoped
+func (VersioningPlugin) delete(original func(*gorm.DB)) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		if db.Error != nil || db.Statement.Unscoped || !isVersioned(db) {
+			original(db)
+			return
+		}
+
+		stmt := db.Statement
+		addPrimaryKeyConditions(stmt)
+		if !hasWhere(db) {
+			db.AddError(gorm.ErrMissingWhereClause)
+			return
+		}
+
+		cols, err := schemaColumns(stmt.Schema)
+		if err != nil {
+			db.AddError(err)
+			return
+		}
+
+		targets, err := findTargets(db)
+		if err != nil {
+			db.AddError(err)
+			return
+		}
+
+		ts := time.Now()
+		tx := newSession(db)
+		for _, target := range targets {
+			if err := tx.Model(target.Interface()).Update(cols.ValidTo, ts).Error; err != nil {
+				db.AddError(fmt.Errorf("failed to soft delete entity: %w", err))
+				return
+			}
+		}
+
+		db.RowsAffected = int64(len(targets))
+	}
+}
+
+// findTargets loads the rows matched by the statement's conditions, keeping only latest versions
+// When the statement names a specific version by primary key and that version is closed,
+// ErrHistoricalVersion is returned instead of silently skipping it.
+func findTargets(db *gorm.DB) ([]reflect.Value, error) {
+	stmt := db.Statement
+
+	rows := reflect.New(reflect.SliceOf(reflect.PointerTo(stmt.Schema.ModelType)))
+	query := newSession(db).Table(stmt.Table)
+	if where, ok := stmt.Clauses["WHERE"]; ok {
+		query = query.Clauses(where.Expression)
+	}
+	if err := query.Find(rows.Interface()).Error; err != nil {
+		return nil, fmt.Errorf("failed to load versions to modify: %w", err)
+	}
+
+	cols, err := schemaColumns(stmt.Schema)
+	if err != nil {
+		return nil, err
+	}
+	validTo := stmt.Schema.LookUpField(cols.ValidTo)
+	if validTo == nil {
+		return nil, fmt.Errorf("SCD column %q not found on %s", cols.ValidTo, stmt.Table)
+	}
+
+	pinned := targetsPrimaryKey(stmt)
+	var targets []reflect.Value
+	for i := 0; i < rows.Elem().Len(); i++ {
+		row := rows.Elem().Index(i)
+		if _, open := validTo.ValueOf(stmt.Context, row.Elem()); !open {
+			if pinned {
+				return nil, fmt.Errorf("%w: %s version %d", ErrHistoricalVersion,
+					row.Interface().(SCDModel).GetBusinessID(), row.Interface().(SCDModel).GetVersion())
+			}
+			continue
+		}
+		targets = append(targets, row)
+	}
+
+	return targets, nil
+}
+
+// targetsPrimaryKey reports whether the statement's model carries a non-zero primary key
+func targetsPrimaryKey(stmt *gorm.Statement) bool {
+	if stmt.ReflectValue.Kind() != reflect.Struct {
+		return false
+	}
+	for _, field := range stmt.Schema.PrimaryFields {
+		if _, isZero := field.ValueOf(stmt.Context, stmt.ReflectValue); !isZero {
+			return true
+		}
+	}
+	return false
+}
+
+// addPrimaryKeyConditions mirrors gorm:delete, restricting the statement to the given record(s)
+func addPrimaryKeyConditions(stmt *gorm.Statement) {
+	_, queryValues := schema.GetIdentityFieldValuesMap(stmt.Context, stmt.ReflectValue, stmt.Schema.PrimaryFields)
+	column, values := schema.ToQueryValues(stmt.Table, stmt.Schema.PrimaryFieldDBNames, queryValues)
+	if len(values) > 0 {
+		stmt.AddClause(clause.Where{Exprs: []clause.Expression{clause.IN{Column: column, Values: values}}})
+	}
+}
+
+// hasWhere reports whether the statement is restricted, honouring AllowGlobalUpdate
+func hasWhere(db *gorm.DB) bool {
+	_, ok := db.Statement.Clauses["WHERE"]
+	return ok || db.AllowGlobalUpdate
+}
+
+// newSession returns a clean session on the same connection (and transaction) that bypasses the plugin
+func newSession(db *gorm.DB) *gorm.DB {
+	return skipVersioning(db.Session(&gorm.Session{NewDB: true, Context: db.Statement.Context}))
+}
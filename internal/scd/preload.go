@@ -0,0 +1,223 @@
+package scd
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// preloadLatestKey holds the floating relations requested for a query
+const preloadLatestKey = "scd:preload_latest"
+
+// preloadLatestCallback is the name of the query callback that loads floating relations
+const preloadLatestCallback = "scd:preload_latest"
+
+// latestTagPrefix marks a floating relation, e.g. `scd:"latest:JobUID"`
+const latestTagPrefix = "latest:"
+
+// ErrPreloadNotRegistered is returned when PreloadLatest is used without RelationsPlugin
+var ErrPreloadNotRegistered = errors.New("scd.RelationsPlugin must be registered to preload latest versions")
+
+// RelationsPlugin loads floating relations between SCD models
+//
+//	db.Use(&scd.RelationsPlugin{})
+//
+// A floating relation is a field holding the latest version of the entity that a
+// version foreign key points at. It is declared next to the usual pinned relation:
+//
+//	JobUID    uuid.UUID
+//	Job       *Job `gorm:"foreignKey:JobUID;references:UID"` // exact version, db.Preload("Job")
+//	LatestJob *Job `gorm:"-" scd:"latest:JobUID"`            // latest version, scd.PreloadLatest("LatestJob")
+//
+// Floating relations are loaded with two queries per field regardless of the number of rows.
+type RelationsPlugin struct{}
+
+// Name implements gorm.Plugin
+func (RelationsPlugin) Name() string {
+	return "scd:relations"
+}
+
+// Initialize implements gorm.Plugin by adding the floating relation loader after gorm:preload
+func (RelationsPlugin) Initialize(db *gorm.DB) error {
+	if err := db.Callback().Query().After("gorm:preload").Register(preloadLatestCallback, preloadLatest); err != nil {
+		return fmt.Errorf("failed to register latest preload: %w", err)
+	}
+	return nil
+}
+
+// latestPreload is the PreloadLatest request stored on a statement
+// It is bound to the queried model so nested queries (e.g. gorm:preload) ignore it.
+type latestPreload struct {
+	model  reflect.Type
+	fields []string
+}
+
+// PreloadLatest loads the named floating relations after the query, e.g.
+//
+//	db.Preload("Job").Scopes(scd.PreloadLatest("LatestJob")).Find(&payments)
+func PreloadLatest(fields ...string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if db.Callback().Query().Get(preloadLatestCallback) == nil {
+			db.AddError(ErrPreloadNotRegistered)
+			return db
+		}
+
+		model := db.Statement.Model
+		if model == nil {
+			model = db.Statement.Dest
+		}
+		if model == nil {
+			db.AddError(errors.New("PreloadLatest requires a model or destination"))
+			return db
+		}
+
+		request := latestPreload{model: structType(model)}
+		if existing, ok := db.Get(preloadLatestKey); ok {
+			request.fields = append(request.fields, existing.(latestPreload).fields...)
+		}
+		request.fields = append(request.fields, fields...)
+		return db.Set(preloadLatestKey, request)
+	}
+}
+
+// preloadLatest fills the requested floating relations on the query results
+func preloadLatest(db *gorm.DB) {
+	if db.Error != nil || db.Statement.Schema == nil {
+		return
+	}
+	value, ok := db.Get(preloadLatestKey)
+	if !ok {
+		return
+	}
+	request := value.(latestPreload)
+	if request.model != db.Statement.Schema.ModelType {
+		return
+	}
+
+	for _, name := range request.fields {
+		if err := loadLatestRelation(db, name); err != nil {
+			db.AddError(err)
+			return
+		}
+	}
+}
+
+// loadLatestRelation resolves one floating relation for every row in the statement's result
+func loadLatestRelation(db *gorm.DB, name string) error {
+	stmt := db.Statement
+
+	field, ok := stmt.Schema.ModelType.FieldByName(name)
+	if !ok {
+		return fmt.Errorf("%s has no field %s", stmt.Schema.Name, name)
+	}
+	fkName, ok := strings.CutPrefix(field.Tag.Get("scd"), latestTagPrefix)
+	if !ok {
+		return fmt.Errorf("field %s.%s is not tagged scd:\"latest:<ForeignKey>\"", stmt.Schema.Name, name)
+	}
+	fk := stmt.Schema.LookUpField(fkName)
+	if fk == nil {
+		return fmt.Errorf("foreign key %s not found on %s", fkName, stmt.Schema.Name)
+	}
+	if field.Type.Kind() != reflect.Ptr || field.Type.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("field %s.%s must be a pointer to an SCD model", stmt.Schema.Name, name)
+	}
+
+	// Collect the rows and the version UIDs they reference
+	rows := resultRows(stmt.ReflectValue)
+	var pinned []interface{}
+	seen := map[string]bool{}
+	for _, row := range rows {
+		value, isZero := fk.ValueOf(stmt.Context, row)
+		if isZero {
+			continue
+		}
+		if key := fmt.Sprint(value); !seen[key] {
+			seen[key] = true
+			pinned = append(pinned, value)
+		}
+	}
+	if len(pinned) == 0 {
+		return nil
+	}
+
+	related := &gorm.Statement{DB: db}
+	if err := related.Parse(reflect.New(field.Type.Elem()).Interface()); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", field.Type.Elem().Name(), err)
+	}
+	cols, err := schemaColumns(related.Schema)
+	if err != nil {
+		return err
+	}
+	if related.Schema.PrioritizedPrimaryField == nil {
+		return fmt.Errorf("%s has no primary key", related.Schema.Name)
+	}
+	pk := related.Schema.PrioritizedPrimaryField.DBName
+
+	// 1. Map each referenced version to its business ID
+	var refs []struct {
+		Pinned     string
+		BusinessID string
+	}
+	err = newSession(db).Table(related.Schema.Table).
+		Select(pk+" AS pinned, "+cols.BusinessID+" AS business_id").
+		Where(pk+" IN ?", pinned).
+		Scan(&refs).Error
+	if err != nil {
+		return fmt.Errorf("failed to resolve %s business IDs: %w", related.Schema.Table, err)
+	}
+	businessIDs := make(map[string]string, len(refs))
+	var ids []string
+	for _, ref := range refs {
+		businessIDs[ref.Pinned] = ref.BusinessID
+		ids = append(ids, ref.BusinessID)
+	}
+
+	// 2. Load the latest version of each business entity
+	latest := reflect.New(reflect.SliceOf(field.Type))
+	err = newSession(db).Model(reflect.New(field.Type.Elem()).Interface()).
+		Where(cols.BusinessID+" IN ? AND "+cols.ValidTo+" IS NULL", ids).
+		Find(latest.Interface()).Error
+	if err != nil {
+		return fmt.Errorf("failed to load latest %s: %w", related.Schema.Table, err)
+	}
+	byBusinessID := make(map[string]reflect.Value, latest.Elem().Len())
+	for i := 0; i < latest.Elem().Len(); i++ {
+		version := latest.Elem().Index(i)
+		byBusinessID[version.Interface().(SCDModel).GetBusinessID()] = version
+	}
+
+	// 3. Assign, leaving the field nil when the entity has been deleted
+	for _, row := range rows {
+		value, isZero := fk.ValueOf(stmt.Context, row)
+		if isZero {
+			continue
+		}
+		if version, ok := byBusinessID[businessIDs[fmt.Sprint(value)]]; ok {
+			row.FieldByIndex(field.Index).Set(version)
+		}
+	}
+
+	return nil
+}
+
+// resultRows returns the addressable struct values held by a query destination
+func resultRows(value reflect.Value) []reflect.Value {
+	value = reflect.Indirect(value)
+	switch value.Kind() {
+	case reflect.Struct:
+		return []reflect.Value{value}
+	case reflect.Slice, reflect.Array:
+		rows := make([]reflect.Value, 0, value.Len())
+		for i := 0; i < value.Len(); i++ {
+			row := reflect.Indirect(value.Index(i))
+			if row.Kind() == reflect.Struct {
+				rows = append(rows, row)
+			}
+		}
+		return rows
+	}
+	return nil
+}
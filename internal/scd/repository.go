@@ -0,0 +1,298 @@
+package scd
+
+import (
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Repository bundles the database handle, table metadata, clock and options for one SCD model
+// It wraps the package-level functions so callers don't repeat the type parameter everywhere:
+//
+//	jobs, err := scd.NewRepository[*models.Job](db)
+//	job, err := jobs.Latest("job-1")
+type Repository[T SCDModel] struct {
+	db     *gorm.DB
+	table  string
+	cols   Columns
+	clock  func() time.Time
+	scopes []func(*gorm.DB) *gorm.DB
+}
+
+// RepositoryOption configures a Repository
+type RepositoryOption func(*repositoryConfig)
+
+type repositoryConfig struct {
+	clock  func() time.Time
+	scopes []func(*gorm.DB) *gorm.DB
+}
+
+// WithClock overrides the time source used for new versions and soft deletes
+// Useful for deterministic tests and for backdating writes in a single unit of work
+func WithClock(clock func() time.Time) RepositoryOption {
+	return func(c *repositoryConfig) {
+		c.clock = clock
+	}
+}
+
+// WithDefaultScopes applies the given scopes to every read issued by the repository
+func WithDefaultScopes(scopes ...func(*gorm.DB) *gorm.DB) RepositoryOption {
+	return func(c *repositoryConfig) {
+		c.scopes = append(c.scopes, scopes...)
+	}
+}
+
+// ListOptions controls which versions Repository.List returns
+type ListOptions struct {
+	// Filters are equality conditions keyed by column name, e.g. {"company_id": "company-acme"}
+	Filters map[string]interface{}
+
+	// AsOf returns the versions valid at that instant instead of the latest versions
+	AsOf *time.Time
+
+	// ValidDuring returns every version valid at some point in the range; it excludes AsOf
+	ValidDuring *TimeRange
+
+	// IncludeDeleted also returns soft-deleted entities, as their last version
+	IncludeDeleted bool
+
+	// Scopes are applied after the built-in conditions (joins, ordering, limits, ...)
+	Scopes []func(*gorm.DB) *gorm.DB
+}
+
+// TimeRange is a closed interval [Start, End]
+type TimeRange struct {
+	Start time.Time
+	End   time.Time
+}
+
+// ReadOptions selects which version of an entity Repository.Get returns
+type ReadOptions struct {
+	// AsOf returns the version valid at that instant instead of the latest version
+	AsOf *time.Time
+
+	// IncludeDeleted returns the last version of a soft-deleted entity instead of not found
+	IncludeDeleted bool
+}
+
+// NewRepository creates a repository for the SCD model T
+func NewRepository[T SCDModel](db *gorm.DB, opts ...RepositoryOption) (*Repository[T], error) {
+	cfg := repositoryConfig{clock: time.Now}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	var zero T
+	table, err := getTableName(db, zero)
+	if err != nil {
+		return nil, fmt.Errorf("failed to determine table name: %w", err)
+	}
+	cols, err := ColumnsOf(db, zero)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Repository[T]{
+		db:     db,
+		table:  table,
+		cols:   cols,
+		clock:  cfg.clock,
+		scopes: cfg.scopes,
+	}, nil
+}
+
+// DB returns the underlying database handle
+func (r *Repository[T]) DB() *gorm.DB {
+	return r.db
+}
+
+// Table returns the table name of the model
+func (r *Repository[T]) Table() string {
+	return r.table
+}
+
+// Columns returns the SCD column names of the model
+func (r *Repository[T]) Columns() Columns {
+	return r.cols
+}
+
+// WithTx returns a copy of the repository bound to the given transaction
+func (r *Repository[T]) WithTx(tx *gorm.DB) *Repository[T] {
+	clone := *r
+	clone.db = tx
+	return &clone
+}
+
+// Create stores the first version of a new business entity
+func (r *Repository[T]) Create(entity T) (T, error) {
+	return createNew(r.db, entity, r.clock())
+}
+
+// Update creates a new version of an entity with the mutator applied
+func (r *Repository[T]) Update(businessID string, mutator func(T)) (T, error) {
+	return update(r.db, businessID, mutator, r.clock())
+}
+
+// Delete soft deletes an entity by closing its latest version
+func (r *Repository[T]) Delete(businessID string) error {
+	return softDelete[T](r.db, businessID, r.clock())
+}
+
+// Import backfills historical versions with explicit validity windows, see Import
+func (r *Repository[T]) Import(versions []T) (ImportResult, error) {
+	return Import(r.db, versions)
+}
+
+// Latest returns the current version of an entity
+func (r *Repository[T]) Latest(businessID string) (T, error) {
+	return r.first(r.query().Scopes(Latest, ByBusinessID(businessID)))
+}
+
+// AsOf returns the version of an entity that was valid at time t
+func (r *Repository[T]) AsOf(businessID string, t time.Time) (T, error) {
+	return r.first(r.query().Scopes(AsOf(t), ByBusinessID(businessID)))
+}
+
+// Get returns the latest or as-of version of an entity, optionally including deleted entities
+func (r *Repository[T]) Get(businessID string, opts ReadOptions) (T, error) {
+	var zero T
+	return r.first(r.query().Scopes(r.current(On(zero), opts.AsOf, opts.IncludeDeleted), ByBusinessID(businessID)))
+}
+
+// Version returns a specific version of an entity
+func (r *Repository[T]) Version(businessID string, version int) (T, error) {
+	return r.first(r.query().Scopes(ByBusinessID(businessID), ByVersion(version)))
+}
+
+// History returns every version of an entity ordered from oldest to newest
+func (r *Repository[T]) History(businessID string) ([]T, error) {
+	var versions []T
+	if err := r.query().Scopes(ByBusinessID(businessID), OrderByVersion(false)).Find(&versions).Error; err != nil {
+		return nil, err
+	}
+	return versions, nil
+}
+
+// Timeline returns the history of one field of an entity as collapsed intervals
+func (r *Repository[T]) Timeline(businessID, field string) ([]Interval, error) {
+	return Timeline[T](r.query(), businessID, field)
+}
+
+// ChangedBetween returns the version changes that took effect in [start, end), optionally limited to columns
+func (r *Repository[T]) ChangedBetween(start, end time.Time, columns ...string) ([]VersionChange, error) {
+	return ChangedBetween[T](r.query(), start, end, columns...)
+}
+
+// List returns the latest (or as-of) versions matching the options
+func (r *Repository[T]) List(opts ListOptions) ([]T, error) {
+	query, err := r.listQuery(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []T
+	if err := query.Find(&results).Error; err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// Page returns one page of List results using keyset pagination
+// The first page records the read time in its cursor and later pages return the versions valid
+// at that instant, so page boundaries stay stable while new versions are being written.
+func (r *Repository[T]) Page(opts ListOptions, req PageRequest) (Page[T], error) {
+	var after *Cursor
+	if req.Cursor != "" {
+		cursor, err := DecodeCursor(req.Cursor)
+		if err != nil {
+			return Page[T]{}, err
+		}
+		after = &cursor
+	}
+
+	if opts.AsOf == nil && opts.ValidDuring == nil {
+		snapshot := r.clock()
+		if after != nil && after.Snapshot != nil {
+			snapshot = *after.Snapshot
+		}
+		opts.AsOf = &snapshot
+	}
+
+	query, err := r.listQuery(opts)
+	if err != nil {
+		return Page[T]{}, err
+	}
+	return paginate[T](query, req, after, opts.AsOf)
+}
+
+// listQuery builds the query used by List
+// Conditions are qualified with the table name so they stay unambiguous when scopes add joins
+func (r *Repository[T]) listQuery(opts ListOptions) (*gorm.DB, error) {
+	var zero T
+	bound := On(zero)
+	query := r.query()
+
+	if opts.ValidDuring != nil {
+		if opts.AsOf != nil {
+			return nil, fmt.Errorf("AsOf and ValidDuring cannot be combined")
+		}
+		query = query.Scopes(bound.ValidDuring(opts.ValidDuring.Start, opts.ValidDuring.End))
+	} else {
+		query = query.Scopes(r.current(bound, opts.AsOf, opts.IncludeDeleted))
+	}
+
+	if len(opts.Filters) > 0 {
+		stmt := &gorm.Statement{DB: r.db}
+		if err := stmt.Parse(zero); err != nil {
+			return nil, fmt.Errorf("failed to parse model schema: %w", err)
+		}
+		for column, value := range opts.Filters {
+			field := stmt.Schema.LookUpField(column)
+			if field == nil || field.DBName == "" {
+				return nil, fmt.Errorf("%w %q on %s", ErrUnknownField, column, r.table)
+			}
+			query = query.Where(r.table+"."+field.DBName+" = ?", value)
+		}
+	}
+
+	return query.Scopes(opts.Scopes...), nil
+}
+
+// current restricts the bound table to one version per entity: the latest one, or the one valid at asOf
+// With includeDeleted, entities whose last version was closed (before asOf) are kept as that version.
+func (r *Repository[T]) current(bound BoundScopes, asOf *time.Time, includeDeleted bool) func(*gorm.DB) *gorm.DB {
+	if !includeDeleted {
+		if asOf != nil {
+			return bound.AsOf(*asOf)
+		}
+		return bound.Latest
+	}
+
+	return func(db *gorm.DB) *gorm.DB {
+		cols := bound.Columns(db)
+		q := db.Statement.Quote
+		sql := "? = (SELECT MAX(v." + q(cols.Version) + ") FROM " + q(r.table) + " v WHERE v." + q(cols.BusinessID) + " = ?"
+		vars := []interface{}{bound.Column(db, cols.Version), bound.Column(db, cols.BusinessID)}
+		if asOf != nil {
+			sql += " AND v." + q(cols.ValidFrom) + " <= ?"
+			vars = append(vars, *asOf)
+		}
+		return db.Where(sql+")", vars...)
+	}
+}
+
+// query starts a new statement with the default scopes applied
+func (r *Repository[T]) query() *gorm.DB {
+	return r.db.Scopes(r.scopes...)
+}
+
+// first loads a single entity, returning gorm.ErrRecordNotFound when nothing matches
+func (r *Repository[T]) first(query *gorm.DB) (T, error) {
+	var entity T
+	if err := query.First(&entity).Error; err != nil {
+		var zero T
+		return zero, err
+	}
+	return entity, nil
+}
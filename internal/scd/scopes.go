@@ -4,26 +4,26 @@ import (
 	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // Latest returns only the current/active versions (valid_to IS NULL)
 // This is the most common query pattern (90% of use cases)
+// Like every scope here, columns come from the model's Columns and are qualified with its table
 func Latest(db *gorm.DB) *gorm.DB {
-	return db.Where("valid_to IS NULL")
+	return current.Latest(db)
 }
 
 // AsOf returns versions that were valid at the specified time
 // Useful for point-in-time reporting and historical analysis
 func AsOf(t time.Time) func(*gorm.DB) *gorm.DB {
-	return func(db *gorm.DB) *gorm.DB {
-		return db.Where("valid_from <= ? AND (valid_to IS NULL OR valid_to > ?)", t, t)
-	}
+	return current.AsOf(t)
 }
 
 // Historical returns all versions for analysis and audit trails
 // This excludes the latest version and shows only historical records
 func Historical(db *gorm.DB) *gorm.DB {
-	return db.Where("valid_to IS NOT NULL")
+	return current.Historical(db)
 }
 
 // AllVersions returns all versions (both current and historical)
@@ -35,64 +35,188 @@ func AllVersions(db *gorm.DB) *gorm.DB {
 // ByBusinessID filters by the business identifier across all versions
 // Useful when you need all versions of a specific business entity
 func ByBusinessID(businessID string) func(*gorm.DB) *gorm.DB {
-	return func(db *gorm.DB) *gorm.DB {
-		return db.Where("id = ?", businessID)
-	}
+	return current.ByBusinessID(businessID)
 }
 
 // ByVersion filters by specific version number
 // Useful for retrieving exact version of an entity
 func ByVersion(version int) func(*gorm.DB) *gorm.DB {
-	return func(db *gorm.DB) *gorm.DB {
-		return db.Where("version = ?", version)
-	}
+	return current.ByVersion(version)
 }
 
 // ValidDuring returns versions that were valid during the specified time range
 // Useful for period-based reporting and analysis
 func ValidDuring(start, end time.Time) func(*gorm.DB) *gorm.DB {
-	return func(db *gorm.DB) *gorm.DB {
-		return db.Where(
-			"valid_from <= ? AND (valid_to IS NULL OR valid_to >= ?)",
-			end, start,
-		)
-	}
+	return current.ValidDuring(start, end)
 }
 
 // CreatedAfter returns versions created after the specified time
 // Useful for incremental processing and change tracking
 func CreatedAfter(t time.Time) func(*gorm.DB) *gorm.DB {
-	return func(db *gorm.DB) *gorm.DB {
-		return db.Where("valid_from > ?", t)
-	}
+	return current.CreatedAfter(t)
 }
 
 // CreatedBefore returns versions created before the specified time
 // Useful for historical analysis and cleanup operations
 func CreatedBefore(t time.Time) func(*gorm.DB) *gorm.DB {
-	return func(db *gorm.DB) *gorm.DB {
-		return db.Where("valid_from < ?", t)
-	}
+	return current.CreatedBefore(t)
 }
 
 // OrderByVersion orders results by version number
 // Can be combined with other scopes for consistent ordering
 func OrderByVersion(desc bool) func(*gorm.DB) *gorm.DB {
-	return func(db *gorm.DB) *gorm.DB {
-		if desc {
-			return db.Order("version DESC")
-		}
-		return db.Order("version ASC")
-	}
+	return current.OrderByVersion(desc)
 }
 
 // OrderByTime orders results by temporal validity
 // Useful for chronological analysis of changes
 func OrderByTime(desc bool) func(*gorm.DB) *gorm.DB {
+	return current.OrderByTime(desc)
+}
+
+// current applies scopes to the statement's own model and table
+var current = BoundScopes{}
+
+// BoundScopes are the SCD scopes bound to a specific model (and optionally an alias)
+// Use them for joined tables, where the package-level scopes only cover the main model:
+//
+//	jobs := scd.On(&models.Job{})
+//	db.Scopes(scd.Latest, jobs.Latest).
+//		Joins("JOIN jobs ON payment_line_items.job_uid = jobs.uid").
+//		Where("jobs.contractor_id = ?", contractor).
+//		Find(&payments)
+type BoundScopes struct {
+	model interface{}
+	alias string
+}
+
+// On returns scopes bound to the table of model
+func On(model interface{}) BoundScopes {
+	return BoundScopes{model: model}
+}
+
+// As returns the scopes bound to a table alias, e.g. for self joins
+func (b BoundScopes) As(alias string) BoundScopes {
+	b.alias = alias
+	return b
+}
+
+// Column returns a column of the bound table, qualified with its table name or alias
+// Useful for building join conditions with the same resolution rules as the scopes
+func (b BoundScopes) Column(db *gorm.DB, name string) clause.Column {
+	table, _ := b.resolve(db)
+	return clause.Column{Table: table, Name: name}
+}
+
+// Columns returns the SCD columns of the bound model
+func (b BoundScopes) Columns(db *gorm.DB) Columns {
+	_, cols := b.resolve(db)
+	return cols
+}
+
+// Latest returns only the current versions of the bound table
+func (b BoundScopes) Latest(db *gorm.DB) *gorm.DB {
+	table, cols := b.resolve(db)
+	return db.Where("? IS NULL", clause.Column{Table: table, Name: cols.ValidTo})
+}
+
+// Historical returns only the closed versions of the bound table
+func (b BoundScopes) Historical(db *gorm.DB) *gorm.DB {
+	table, cols := b.resolve(db)
+	return db.Where("? IS NOT NULL", clause.Column{Table: table, Name: cols.ValidTo})
+}
+
+// AsOf restricts the bound table to the versions valid at time t
+func (b BoundScopes) AsOf(t time.Time) func(*gorm.DB) *gorm.DB {
 	return func(db *gorm.DB) *gorm.DB {
-		if desc {
-			return db.Order("valid_from DESC")
+		table, cols := b.resolve(db)
+		validFrom := clause.Column{Table: table, Name: cols.ValidFrom}
+		validTo := clause.Column{Table: table, Name: cols.ValidTo}
+		return db.Where("? <= ? AND (? IS NULL OR ? > ?)", validFrom, t, validTo, validTo, t)
+	}
+}
+
+// ValidDuring restricts the bound table to versions valid at any point in [start, end]
+func (b BoundScopes) ValidDuring(start, end time.Time) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		table, cols := b.resolve(db)
+		validFrom := clause.Column{Table: table, Name: cols.ValidFrom}
+		validTo := clause.Column{Table: table, Name: cols.ValidTo}
+		return db.Where("? <= ? AND (? IS NULL OR ? >= ?)", validFrom, end, validTo, validTo, start)
+	}
+}
+
+// ByBusinessID filters the bound table by business identifier
+func (b BoundScopes) ByBusinessID(businessID string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		table, cols := b.resolve(db)
+		return db.Where(clause.Eq{Column: clause.Column{Table: table, Name: cols.BusinessID}, Value: businessID})
+	}
+}
+
+// ByVersion filters the bound table by version number
+func (b BoundScopes) ByVersion(version int) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		table, cols := b.resolve(db)
+		return db.Where(clause.Eq{Column: clause.Column{Table: table, Name: cols.Version}, Value: version})
+	}
+}
+
+// CreatedAfter returns versions of the bound table created after t
+func (b BoundScopes) CreatedAfter(t time.Time) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		table, cols := b.resolve(db)
+		return db.Where(clause.Gt{Column: clause.Column{Table: table, Name: cols.ValidFrom}, Value: t})
+	}
+}
+
+// CreatedBefore returns versions of the bound table created before t
+func (b BoundScopes) CreatedBefore(t time.Time) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		table, cols := b.resolve(db)
+		return db.Where(clause.Lt{Column: clause.Column{Table: table, Name: cols.ValidFrom}, Value: t})
+	}
+}
+
+// OrderByVersion orders by the bound table's version number
+func (b BoundScopes) OrderByVersion(desc bool) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		table, cols := b.resolve(db)
+		return db.Order(clause.OrderByColumn{Column: clause.Column{Table: table, Name: cols.Version}, Desc: desc})
+	}
+}
+
+// OrderByTime orders by the bound table's validity start
+func (b BoundScopes) OrderByTime(desc bool) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		table, cols := b.resolve(db)
+		return db.Order(clause.OrderByColumn{Column: clause.Column{Table: table, Name: cols.ValidFrom}, Desc: desc})
+	}
+}
+
+// resolve returns the table name (or alias) and SCD columns the scopes apply to
+// Unbound scopes use the statement's current table; a model that cannot be parsed adds an error to db
+func (b BoundScopes) resolve(db *gorm.DB) (string, Columns) {
+	table := b.alias
+	if b.model == nil {
+		if table == "" {
+			table = clause.CurrentTable
 		}
-		return db.Order("valid_from ASC")
+		return table, statementColumns(db)
+	}
+
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(b.model); err != nil {
+		db.AddError(err)
+		return table, DefaultColumns
+	}
+	cols, err := schemaColumns(stmt.Schema)
+	if err != nil {
+		db.AddError(err)
+		return table, DefaultColumns
+	}
+	if table == "" {
+		table = stmt.Schema.Table
 	}
+	return table, cols
 }
@@ -0,0 +1,100 @@
+package scd
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ErrUnknownField is returned when a query names a column the model does not have
+var ErrUnknownField = errors.New("unknown field")
+
+// Interval is a period [From, To) during which a field kept the same value
+type Interval struct {
+	Value       interface{} `json:"value"`
+	From        time.Time   `json:"from"`
+	To          *time.Time  `json:"to"` // nil while the value is still current
+	FromVersion int         `json:"from_version"`
+	ToVersion   int         `json:"to_version"`
+}
+
+// Timeline returns the history of one field of an entity as collapsed intervals
+// Consecutive versions with equal values are merged, so a rate that stayed at 50
+// across versions 1-3 is reported once. A gap between versions (e.g. after a
+// soft delete and re-import) always starts a new interval.
+func Timeline[T SCDModel](db *gorm.DB, businessID, field string) ([]Interval, error) {
+	var zero T
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(zero); err != nil {
+		return nil, fmt.Errorf("failed to parse model schema: %w", err)
+	}
+	target := stmt.Schema.LookUpField(field)
+	if target == nil || target.DBName == "" {
+		return nil, fmt.Errorf("%w %q on %s", ErrUnknownField, field, stmt.Schema.Table)
+	}
+
+	cols, err := schemaColumns(stmt.Schema)
+	if err != nil {
+		return nil, err
+	}
+	validFrom := stmt.Schema.LookUpField(cols.ValidFrom)
+	validTo := stmt.Schema.LookUpField(cols.ValidTo)
+	if validFrom == nil || validTo == nil {
+		return nil, fmt.Errorf("validity columns not found on %s", stmt.Schema.Table)
+	}
+
+	versions, err := GetAllVersions[T](db, businessID)
+	if err != nil {
+		return nil, err
+	}
+
+	var intervals []Interval
+	for _, version := range versions {
+		row := reflect.Indirect(reflect.ValueOf(version))
+		value, _ := target.ValueOf(stmt.Context, row)
+		from := timeValue(validFrom.ReflectValueOf(stmt.Context, row))
+		to := optionalTimeValue(validTo.ReflectValueOf(stmt.Context, row))
+
+		if n := len(intervals); n > 0 {
+			last := &intervals[n-1]
+			if last.To != nil && last.To.Equal(from) && reflect.DeepEqual(last.Value, value) {
+				last.To = to
+				last.ToVersion = version.GetVersion()
+				continue
+			}
+		}
+
+		intervals = append(intervals, Interval{
+			Value:       value,
+			From:        from,
+			To:          to,
+			FromVersion: version.GetVersion(),
+			ToVersion:   version.GetVersion(),
+		})
+	}
+
+	return intervals, nil
+}
+
+// timeValue reads a time.Time (or *time.Time) field value
+func timeValue(v reflect.Value) time.Time {
+	if t := optionalTimeValue(v); t != nil {
+		return *t
+	}
+	return time.Time{}
+}
+
+// optionalTimeValue reads a nullable time field, returning nil for NULL
+func optionalTimeValue(v reflect.Value) *time.Time {
+	v = reflect.Indirect(v)
+	if !v.IsValid() {
+		return nil
+	}
+	if t, ok := v.Interface().(time.Time); ok {
+		return &t
+	}
+	return nil
+}
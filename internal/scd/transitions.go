@@ -0,0 +1,89 @@
+package scd
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// ErrInvalidTransition is wrapped by the TransitionError returned for a disallowed state change
+var ErrInvalidTransition = errors.New("invalid state transition")
+
+// Transitions is a declarative transition table: each state maps to the states it may move to
+// States without an entry (or with an empty list) are final.
+type Transitions map[string][]string
+
+// Allows reports whether a version in state from may be followed by a version in state to
+// Keeping the same state is always allowed, so updates to other fields are unaffected.
+func (t Transitions) Allows(from, to string) bool {
+	if from == to {
+		return true
+	}
+	for _, next := range t[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// Next returns the states reachable from state
+func (t Transitions) Next(state string) []string {
+	return append([]string{}, t[state]...)
+}
+
+// StateMachine is implemented by models whose state may only change along a transition table
+// Update (and the versioning plugin) reject a new version whose state is not reachable from the
+// state of the version it replaces:
+//
+//	func (j *Job) State() string              { return j.Status }
+//	func (j *Job) Transitions() scd.Transitions { return jobTransitions }
+type StateMachine interface {
+	State() string
+	Transitions() Transitions
+}
+
+// TransitionError describes a rejected state change
+type TransitionError struct {
+	Model      string   `json:"model"`
+	BusinessID string   `json:"id"`
+	From       string   `json:"from"`
+	To         string   `json:"to"`
+	Allowed    []string `json:"allowed"`
+}
+
+// Error implements error
+func (e *TransitionError) Error() string {
+	allowed := "none, " + e.From + " is final"
+	if len(e.Allowed) > 0 {
+		allowed = strings.Join(e.Allowed, ", ")
+	}
+	return fmt.Sprintf("%s %s: %s from %q to %q (allowed: %s)", e.Model, e.BusinessID, ErrInvalidTransition, e.From, e.To, allowed)
+}
+
+// Unwrap lets errors.Is match ErrInvalidTransition
+func (e *TransitionError) Unwrap() error {
+	return ErrInvalidTransition
+}
+
+// checkTransition verifies that next may follow prev when the model is a StateMachine
+func checkTransition(prev, next SCDModel) error {
+	from, ok := prev.(StateMachine)
+	if !ok {
+		return nil
+	}
+	to := next.(StateMachine)
+
+	table := from.Transitions()
+	if table.Allows(from.State(), to.State()) {
+		return nil
+	}
+	return &TransitionError{
+		Model:      reflect.Indirect(reflect.ValueOf(prev)).Type().Name(),
+		BusinessID: prev.GetBusinessID(),
+		From:       from.State(),
+		To:         to.State(),
+		Allowed:    table.Next(from.State()),
+	}
+}
@@ -0,0 +1,55 @@
+package scd
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// UnitOfWork writes versions of several entities in one transaction at one timestamp
+// Every version written through it gets the same ValidFrom and closes its predecessor at that
+// instant, so AsOf queries see either all of the changes or none of them. Write each entity
+// at most once per unit; a second write would leave a zero-length version behind.
+type UnitOfWork struct {
+	tx  *gorm.DB
+	now time.Time
+}
+
+// RunUnitOfWork runs fn in a transaction; if fn returns an error every write is rolled back
+func RunUnitOfWork(db *gorm.DB, fn func(*UnitOfWork) error) error {
+	return runUnitOfWork(db, time.Now(), fn)
+}
+
+// runUnitOfWork implements RunUnitOfWork with an explicit timestamp
+func runUnitOfWork(db *gorm.DB, now time.Time, fn func(*UnitOfWork) error) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		return fn(&UnitOfWork{tx: tx, now: now})
+	})
+}
+
+// DB returns the transaction, for reads that should see the unit's own writes
+func (u *UnitOfWork) DB() *gorm.DB {
+	return u.tx
+}
+
+// Now returns the timestamp shared by every version written in the unit
+func (u *UnitOfWork) Now() time.Time {
+	return u.now
+}
+
+// CreateIn creates the first version of a new business entity within the unit, see CreateNew
+func CreateIn[T SCDModel](u *UnitOfWork, entity T) (T, error) {
+	return createNew(u.tx, entity, u.now)
+}
+
+// UpdateIn creates a new version of an entity within the unit, see Update
+func UpdateIn[T SCDModel](u *UnitOfWork, businessID string, mutator func(T)) (T, error) {
+	return update(u.tx, businessID, mutator, u.now)
+}
+
+// In returns a copy of the repository that writes within the unit
+func (r *Repository[T]) In(u *UnitOfWork) *Repository[T] {
+	clone := r.WithTx(u.tx)
+	clone.clock = u.Now
+	return clone
+}
@@ -9,24 +9,31 @@ import (
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// ErrAlreadyExists is returned by CreateNew when the business ID already has a current version
+var ErrAlreadyExists = errors.New("already exists")
+
 // Update creates a new version of an existing record with the specified mutations
 // This is the primary way to modify SCD entities while preserving history
 func Update[T SCDModel](db *gorm.DB, businessID string, mutator func(T)) (T, error) {
+	return update(db, businessID, mutator, time.Now())
+}
+
+// update implements Update with an explicit timestamp for the new version
+// ts is shared by the new version and the closed one so validity windows never overlap.
+func update[T SCDModel](db *gorm.DB, businessID string, mutator func(T), ts time.Time) (T, error) {
 	var result T
 
 	err := db.Transaction(func(tx *gorm.DB) error {
-		// 1. Create single timestamp to eliminate overlapping validity windows
-		ts := time.Now()
-
-		// 2. Get the current latest version
+		// 1. Get the current latest version
 		var latest T
-		if err := tx.Scopes(Latest).Where("id = ?", businessID).First(&latest).Error; err != nil {
+		if err := tx.Scopes(Latest, ByBusinessID(businessID)).First(&latest).Error; err != nil {
 			return fmt.Errorf("failed to find latest version of %s: %w", businessID, err)
 		}
 
-		// 3. Make a deep copy of the latest version so we don't mutate the original struct
+		// 2. Make a deep copy of the latest version so we don't mutate the original struct
 		prevLatest := latest // Keep reference to close later
 
 		// Use reflection to create a new instance of the underlying struct and copy the field values
@@ -39,58 +46,18 @@ func Update[T SCDModel](db *gorm.DB, businessID string, mutator func(T)) (T, err
 
 		result = copyVal.Interface().(T)
 
-		// 4. Get table name for atomic version allocation
+		// 3. Get table name for atomic version allocation
 		tableName, err := getTableName(tx, result)
 		if err != nil {
 			return fmt.Errorf("failed to determine table name: %w", err)
 		}
 
-		// 5. Atomically allocate next version number using CTE
-		// This prevents race conditions where concurrent updates try the same version
-		var nextVersion int
-		if err := tx.Raw(`
-			SELECT COALESCE(MAX(version), 0) + 1 AS next_version
-			FROM `+tableName+` 
-			WHERE id = ?`,
-			businessID,
-		).Scan(&nextVersion).Error; err != nil {
-			return fmt.Errorf("failed to get next version: %w", err)
-		}
-
-		// 6. Create a copy for the new version with mutations applied
+		// 4. Create a copy for the new version with mutations applied
 		mutator(result)
 
-		// 7. Prepare new version with atomic version number and explicit ValidFrom
-		result.SetUID(uuid.New())
-		result.SetVersion(nextVersion)
-		result.SetValidFrom(ts) // Use the same timestamp to prevent overlaps
-
-		// 8. Insert new version first (with retry logic for race condition protection)
-		maxRetries := 3
-		for attempt := 0; attempt < maxRetries; attempt++ {
-			if err := tx.Create(result).Error; err != nil {
-				// Check if it's a unique constraint violation on (id, version)
-				if attempt < maxRetries-1 && isUniqueConstraintError(err) {
-					// Recalculate version and retry
-					if err := tx.Raw(`
-						SELECT COALESCE(MAX(version), 0) + 1 AS next_version
-						FROM `+tableName+` 
-						WHERE id = ?`,
-						businessID,
-					).Scan(&nextVersion).Error; err != nil {
-						return fmt.Errorf("failed to recalculate version on retry: %w", err)
-					}
-					result.SetVersion(nextVersion)
-					continue // Retry with new version
-				}
-				return fmt.Errorf("failed to create new version: %w", err)
-			}
-			break // Success
-		}
-
-		// 9. Close the previous version with the SAME timestamp to prevent overlaps
-		if err := tx.Model(prevLatest).Update("valid_to", ts).Error; err != nil {
-			return fmt.Errorf("failed to close previous version: %w", err)
+		// 5. Insert the new version and close the previous one at the same timestamp
+		if err := writeNextVersion(tx, tableName, prevLatest, result, ts); err != nil {
+			return err
 		}
 
 		return nil
@@ -104,21 +71,93 @@ func Update[T SCDModel](db *gorm.DB, businessID string, mutator func(T)) (T, err
 	return result, nil
 }
 
+// writeNextVersion inserts next as the version following prev and closes prev
+// Both rows share the timestamp ts so validity windows never overlap.
+// Used by Update and by the versioning plugin.
+func writeNextVersion(tx *gorm.DB, tableName string, prev, next SCDModel, ts time.Time) error {
+	businessID := prev.GetBusinessID()
+
+	cols, err := ColumnsOf(tx, prev)
+	if err != nil {
+		return err
+	}
+	nextVersionSQL := `
+		SELECT COALESCE(MAX(` + cols.Version + `), 0) + 1 AS next_version
+		FROM ` + tableName + `
+		WHERE ` + cols.BusinessID + ` = ?`
+
+	// Atomically allocate next version number
+	// This prevents race conditions where concurrent updates try the same version
+	var nextVersion int
+	if err := tx.Raw(nextVersionSQL, businessID).Scan(&nextVersion).Error; err != nil {
+		return fmt.Errorf("failed to get next version: %w", err)
+	}
+
+	// Prepare new version with atomic version number and explicit ValidFrom
+	next.SetUID(uuid.New())
+	next.SetBusinessID(businessID)
+	next.SetVersion(nextVersion)
+	next.SetValidFrom(ts) // Use the same timestamp to prevent overlaps
+
+	if err := Validate(next); err != nil {
+		return err
+	}
+	if err := checkTransition(prev, next); err != nil {
+		return err
+	}
+
+	// Insert new version first (with retry logic for race condition protection)
+	// Preloaded relations point at other versions and are never written through
+	maxRetries := 3
+	for attempt := 0; attempt < maxRetries; attempt++ {
+		if err := tx.Omit(clause.Associations).Create(next).Error; err != nil {
+			// Check if it's a unique constraint violation on (id, version)
+			if attempt < maxRetries-1 && isUniqueConstraintError(err) {
+				// Recalculate version and retry
+				if err := tx.Raw(nextVersionSQL, businessID).Scan(&nextVersion).Error; err != nil {
+					return fmt.Errorf("failed to recalculate version on retry: %w", err)
+				}
+				next.SetVersion(nextVersion)
+				continue // Retry with new version
+			}
+			return fmt.Errorf("failed to create new version: %w", err)
+		}
+		break // Success
+	}
+
+	// Close the previous version with the SAME timestamp to prevent overlaps
+	if err := skipVersioning(tx).Model(prev).Update(cols.ValidTo, ts).Error; err != nil {
+		return fmt.Errorf("failed to close previous version: %w", err)
+	}
+
+	return nil
+}
+
 // CreateNew creates the first version of a new business entity
 // Use this for creating brand new entities, not for updating existing ones
 func CreateNew[T SCDModel](db *gorm.DB, entity T) (T, error) {
+	return createNew(db, entity, time.Now())
+}
+
+// createNew implements CreateNew with an explicit validity start
+func createNew[T SCDModel](db *gorm.DB, entity T, now time.Time) (T, error) {
 	// Validate business ID is provided
 	if entity.GetBusinessID() == "" {
 		var zero T
 		return zero, errors.New("business ID is required for new entities")
 	}
 
+	if err := Validate(entity); err != nil {
+		var zero T
+		return zero, err
+	}
+
 	// Check if entity already exists
 	var exists T
-	err := db.Scopes(Latest).Where("id = ?", entity.GetBusinessID()).First(&exists).Error
+	err := db.Scopes(Latest, ByBusinessID(entity.GetBusinessID())).First(&exists).Error
 	if err == nil {
 		var zero T
-		return zero, fmt.Errorf("entity with business ID %s already exists", entity.GetBusinessID())
+		return zero, fmt.Errorf("entity with business ID %s %w", entity.GetBusinessID(), ErrAlreadyExists)
 	}
 	if !errors.Is(err, gorm.ErrRecordNotFound) {
 		var zero T
@@ -128,10 +167,10 @@ func CreateNew[T SCDModel](db *gorm.DB, entity T) (T, error) {
 	// Set SCD fields for new entity
 	entity.SetUID(uuid.New())
 	entity.SetVersion(1)
-	entity.SetValidFrom(time.Now())
+	entity.SetValidFrom(now)
 
 	// Create the entity
-	if err := db.Create(entity).Error; err != nil {
+	if err := db.Omit(clause.Associations).Create(entity).Error; err != nil {
 		var zero T
 		return zero, fmt.Errorf("failed to create new entity: %w", err)
 	}
@@ -142,7 +181,7 @@ func CreateNew[T SCDModel](db *gorm.DB, entity T) (T, error) {
 // GetLatest retrieves the current version of an entity by business ID
 func GetLatest[T SCDModel](db *gorm.DB, businessID string) (T, error) {
 	var entity T
-	err := db.Scopes(Latest).Where("id = ?", businessID).First(&entity).Error
+	err := db.Scopes(Latest, ByBusinessID(businessID)).First(&entity).Error
 	if err != nil {
 		var zero T
 		return zero, err
@@ -175,13 +214,22 @@ func GetVersion[T SCDModel](db *gorm.DB, businessID string, version int) (T, err
 // SoftDelete marks the latest version as invalid by setting valid_to
 // This preserves all historical data while making the entity "deleted"
 func SoftDelete[T SCDModel](db *gorm.DB, businessID string) error {
+	return softDelete[T](db, businessID, time.Now())
+}
+
+// softDelete implements SoftDelete with an explicit close timestamp
+func softDelete[T SCDModel](db *gorm.DB, businessID string, now time.Time) error {
 	latest, err := GetLatest[T](db, businessID)
 	if err != nil {
 		return fmt.Errorf("failed to find latest version for soft delete: %w", err)
 	}
 
-	now := time.Now()
-	if err := db.Model(latest).Update("valid_to", now).Error; err != nil {
+	cols, err := ColumnsOf(db, latest)
+	if err != nil {
+		return err
+	}
+
+	if err := skipVersioning(db).Model(latest).Update(cols.ValidTo, now).Error; err != nil {
 		return fmt.Errorf("failed to soft delete entity: %w", err)
 	}
 
@@ -214,7 +262,7 @@ func getTableName[T any](db *gorm.DB, model T) (string, error) {
 // Exists checks if an entity with the given business ID exists (has any version)
 func Exists[T SCDModel](db *gorm.DB, businessID string) (bool, error) {
 	var count int64
-	err := db.Model(new(T)).Where("id = ?", businessID).Count(&count).Error
+	err := db.Model(new(T)).Scopes(ByBusinessID(businessID)).Count(&count).Error
 	if err != nil {
 		return false, err
 	}
@@ -224,7 +272,7 @@ func Exists[T SCDModel](db *gorm.DB, businessID string) (bool, error) {
 // HasLatestVersion checks if an entity has a current/active version
 func HasLatestVersion[T SCDModel](db *gorm.DB, businessID string) (bool, error) {
 	var count int64
-	err := db.Model(new(T)).Scopes(Latest).Where("id = ?", businessID).Count(&count).Error
+	err := db.Model(new(T)).Scopes(Latest, ByBusinessID(businessID)).Count(&count).Error
 	if err != nil {
 		return false, err
 	}
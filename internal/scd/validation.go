@@ -0,0 +1,123 @@
+package scd
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// ErrValidation is wrapped by the ValidationError returned when a version fails its `validate:` tags
+var ErrValidation = errors.New("validation failed")
+
+// FieldError is one failed validation rule
+type FieldError struct {
+	Field   string `json:"field"` // JSON name of the field
+	Rule    string `json:"rule"`  // validate tag, e.g. oneof
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message"`
+}
+
+// ValidationError lists every field of a version that failed validation
+// Every write path (CreateNew, Update, the versioning plugin, Import and direct inserts) returns it
+// before anything is stored.
+type ValidationError struct {
+	Model      string       `json:"model"`
+	BusinessID string       `json:"id"`
+	Fields     []FieldError `json:"fields"`
+}
+
+// Error implements error
+func (e *ValidationError) Error() string {
+	messages := make([]string, 0, len(e.Fields))
+	for _, field := range e.Fields {
+		messages = append(messages, field.Message)
+	}
+	return fmt.Sprintf("%s %s: %s: %s", e.Model, e.BusinessID, ErrValidation, strings.Join(messages, "; "))
+}
+
+// Unwrap lets errors.Is match ErrValidation
+func (e *ValidationError) Unwrap() error {
+	return ErrValidation
+}
+
+// validate evaluates `validate:` tags, naming fields by their JSON names
+var validate = func() *validator.Validate {
+	v := validator.New()
+	v.RegisterTagNameFunc(jsonName)
+	return v
+}()
+
+// RegisterValidationType lets `validate:` rules check values of custom types through fn
+// For example, a money type can expose its minor units so `validate:"gte=0"` applies to them:
+//
+//	scd.RegisterValidationType(func(v reflect.Value) interface{} { return v.Interface().(money.Money).Minor() }, money.Money{})
+func RegisterValidationType(fn func(field reflect.Value) interface{}, types ...interface{}) {
+	validate.RegisterCustomTypeFunc(fn, types...)
+}
+
+// Validate checks a model against its `validate:` tags
+// It returns a *ValidationError listing every failing field, or nil. Models without tags always pass.
+func Validate(model SCDModel) error {
+	err := validate.Struct(model)
+	if err == nil {
+		return nil
+	}
+	var errs validator.ValidationErrors
+	if !errors.As(err, &errs) {
+		return fmt.Errorf("failed to validate %T: %w", model, err)
+	}
+
+	modelType := reflect.Indirect(reflect.ValueOf(model)).Type()
+	result := &ValidationError{Model: modelType.Name(), BusinessID: model.GetBusinessID()}
+	for _, e := range errs {
+		param := e.Param()
+		if strings.HasSuffix(e.Tag(), "field") {
+			// Cross-field rules name the other field by its Go name
+			if other, ok := modelType.FieldByName(param); ok {
+				param = jsonName(other)
+			}
+		}
+		result.Fields = append(result.Fields, FieldError{
+			Field:   e.Field(),
+			Rule:    e.Tag(),
+			Param:   param,
+			Message: validationMessage(e.Field(), e.Tag(), param),
+		})
+	}
+	return result
+}
+
+// validationMessage describes a failed rule in plain words
+func validationMessage(field, rule, param string) string {
+	switch rule {
+	case "required":
+		return field + " is required"
+	case "oneof":
+		return fmt.Sprintf("%s must be one of: %s", field, param)
+	case "gtfield":
+		return fmt.Sprintf("%s must be greater than %s", field, param)
+	case "gte":
+		return fmt.Sprintf("%s must be at least %s", field, param)
+	case "min", "max":
+		return fmt.Sprintf("%s must satisfy %s=%s", field, rule, param)
+	}
+	if param != "" {
+		return fmt.Sprintf("%s failed %s=%s", field, rule, param)
+	}
+	return fmt.Sprintf("%s failed %s", field, rule)
+}
+
+// jsonName returns the JSON name of a struct field, falling back to the Go name
+func jsonName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	switch name {
+	case "-":
+		return ""
+	case "":
+		return field.Name
+	}
+	return name
+}
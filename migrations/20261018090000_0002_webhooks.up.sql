@@ -0,0 +1,34 @@
+-- Webhook subscriptions for entity change notifications
+CREATE TABLE webhook_subscriptions (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  url TEXT NOT NULL,
+  filters TEXT,                    -- JSON array, e.g. ["payment.status=failed"]
+  secret TEXT NOT NULL,            -- HMAC-SHA256 signing secret
+  active BOOLEAN NOT NULL DEFAULT TRUE,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Delivery log: one row per (subscription, entity version) with retry state
+CREATE TABLE webhook_deliveries (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  subscription_id UUID NOT NULL REFERENCES webhook_subscriptions(id) ON DELETE CASCADE,
+  version_uid UUID NOT NULL,       -- UID of the SCD version that triggered the event
+  event_id TEXT NOT NULL,
+  event TEXT NOT NULL,
+  occurred_at TIMESTAMPTZ NOT NULL,
+  payload TEXT NOT NULL,
+  status TEXT NOT NULL,            -- pending, succeeded, failed
+  attempts INT NOT NULL DEFAULT 0,
+  response_status INT,
+  last_error TEXT,
+  next_attempt_at TIMESTAMPTZ,
+  delivered_at TIMESTAMPTZ,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  UNIQUE(subscription_id, version_uid)
+);
+
+-- Retry scanner looks up due pending deliveries
+CREATE INDEX idx_webhook_deliveries_due ON webhook_deliveries(next_attempt_at) WHERE status = 'pending';
+CREATE INDEX idx_webhook_deliveries_occurred ON webhook_deliveries(occurred_at);
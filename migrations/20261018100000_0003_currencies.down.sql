@@ -0,0 +1,4 @@
+ALTER TABLE payment_line_items DROP COLUMN IF EXISTS fx_rate_uid;
+ALTER TABLE payment_line_items DROP COLUMN IF EXISTS currency;
+ALTER TABLE jobs DROP COLUMN IF EXISTS currency;
+DROP TABLE IF EXISTS fx_rates;
@@ -0,0 +1,19 @@
+-- Exchange rates, versioned like every other entity; one business ID per currency pair, e.g. "EUR-USD"
+CREATE TABLE fx_rates (
+  uid UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  id TEXT NOT NULL,
+  version INT NOT NULL,
+  valid_from TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  valid_to TIMESTAMPTZ,
+  base TEXT NOT NULL,               -- currency converted from
+  quote TEXT NOT NULL,              -- currency converted to
+  rate NUMERIC(18, 8) NOT NULL,     -- units of quote per unit of base
+  UNIQUE(id, version)
+);
+
+CREATE INDEX idx_fx_rates_id ON fx_rates(id);
+
+-- Existing rates and amounts were implicitly USD
+ALTER TABLE jobs ADD COLUMN currency TEXT NOT NULL DEFAULT 'USD';
+ALTER TABLE payment_line_items ADD COLUMN currency TEXT NOT NULL DEFAULT 'USD';
+ALTER TABLE payment_line_items ADD COLUMN fx_rate_uid UUID REFERENCES fx_rates(uid); -- exchange rate version used, if converted
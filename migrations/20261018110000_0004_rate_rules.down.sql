@@ -0,0 +1,3 @@
+ALTER TABLE payment_line_items DROP COLUMN IF EXISTS explanation;
+ALTER TABLE payment_line_items DROP COLUMN IF EXISTS rate_rule_uid;
+DROP TABLE IF EXISTS rate_rules;
@@ -0,0 +1,21 @@
+-- Pay rules of a job's contract, versioned like every other entity; one business ID per job, e.g. "rules-job-1"
+CREATE TABLE rate_rules (
+  uid UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  id TEXT NOT NULL,
+  version INT NOT NULL,
+  valid_from TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  valid_to TIMESTAMPTZ,
+  job_id TEXT NOT NULL,                                 -- business ID of the job
+  rounding_minutes INT NOT NULL DEFAULT 0,              -- round each timelog to the nearest increment, 0 = exact
+  minimum_minutes INT NOT NULL DEFAULT 0,               -- minimum billable time per timelog
+  daily_cap_minutes INT NOT NULL DEFAULT 0,             -- maximum billable time per day, 0 = no cap
+  weekly_overtime_after_minutes INT NOT NULL DEFAULT 0, -- overtime threshold per week, 0 = no overtime
+  overtime_percent INT NOT NULL DEFAULT 150,            -- overtime pay as a percentage of the rate
+  UNIQUE(id, version)
+);
+
+CREATE INDEX idx_rate_rules_id ON rate_rules(id);
+
+-- Line items priced by a pay-period calculation record the rule version and the breakdown
+ALTER TABLE payment_line_items ADD COLUMN rate_rule_uid UUID REFERENCES rate_rules(uid);
+ALTER TABLE payment_line_items ADD COLUMN explanation TEXT; -- JSON
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_payment_line_items_latest_payout_run;
+ALTER TABLE payment_line_items DROP COLUMN IF EXISTS payout_run_id;
+DROP TABLE IF EXISTS payout_runs;
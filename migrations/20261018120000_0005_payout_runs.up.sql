@@ -0,0 +1,23 @@
+-- Payout runs, versioned like every other entity; each groups the payable line items of one contractor and period
+CREATE TABLE payout_runs (
+  uid UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  id TEXT NOT NULL,
+  version INT NOT NULL,
+  valid_from TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  valid_to TIMESTAMPTZ,
+  contractor_id TEXT NOT NULL,
+  period_start TIMESTAMPTZ NOT NULL, -- first timelog start included
+  period_end TIMESTAMPTZ NOT NULL,   -- timelog starts before this are included
+  currency TEXT NOT NULL,            -- currency of every member item
+  total NUMERIC(12, 2) NOT NULL,     -- sum of the member amounts
+  item_count INT NOT NULL,
+  status TEXT NOT NULL,             -- draft, approved, submitted, settled, failed
+  UNIQUE(id, version)
+);
+
+CREATE INDEX idx_payout_runs_id ON payout_runs(id);
+CREATE INDEX idx_payout_runs_latest_contractor ON payout_runs(contractor_id) WHERE valid_to IS NULL;
+
+-- Line items record the run they belong to by business ID
+ALTER TABLE payment_line_items ADD COLUMN payout_run_id TEXT NOT NULL DEFAULT '';
+CREATE INDEX idx_payment_line_items_latest_payout_run ON payment_line_items(payout_run_id) WHERE valid_to IS NULL;
@@ -0,0 +1,4 @@
+ALTER TABLE payment_line_items DROP COLUMN IF EXISTS payout_response;
+ALTER TABLE payment_line_items DROP COLUMN IF EXISTS payout_status;
+ALTER TABLE payment_line_items DROP COLUMN IF EXISTS payout_ref;
+ALTER TABLE payment_line_items DROP COLUMN IF EXISTS payout_provider;
@@ -0,0 +1,5 @@
+-- Line items record what the payout provider reported on each new version
+ALTER TABLE payment_line_items ADD COLUMN payout_provider TEXT; -- provider the payout was submitted to
+ALTER TABLE payment_line_items ADD COLUMN payout_ref TEXT;      -- provider's reference for the payout
+ALTER TABLE payment_line_items ADD COLUMN payout_status TEXT;   -- pending, succeeded, failed, cancelled
+ALTER TABLE payment_line_items ADD COLUMN payout_response TEXT; -- last provider response, as received
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_payment_line_items_export_file;
+ALTER TABLE payment_line_items DROP COLUMN IF EXISTS export_file_id;
+DROP TABLE IF EXISTS bank_accounts;
@@ -0,0 +1,22 @@
+-- Contractor bank accounts, versioned like every other entity; one account entity per contractor
+CREATE TABLE bank_accounts (
+  uid UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  id TEXT NOT NULL,
+  version INT NOT NULL,
+  valid_from TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  valid_to TIMESTAMPTZ,
+  contractor_id TEXT NOT NULL,
+  holder_name TEXT NOT NULL, -- name on the account
+  iban TEXT,                 -- international account number, for pain.001 files
+  bic TEXT,
+  routing_number TEXT,       -- US ABA routing number, for NACHA files
+  account_number TEXT,
+  account_type TEXT,         -- checking or savings
+  UNIQUE(id, version)
+);
+
+CREATE INDEX idx_bank_accounts_id ON bank_accounts(id);
+
+-- Line items record the bank payment file they were exported in
+ALTER TABLE payment_line_items ADD COLUMN export_file_id TEXT NOT NULL DEFAULT '';
+CREATE INDEX idx_payment_line_items_export_file ON payment_line_items(export_file_id) WHERE export_file_id <> '';